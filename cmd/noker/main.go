@@ -11,9 +11,11 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/pedy4000/noker/internal/ai"
 	"github.com/pedy4000/noker/internal/api"
 	"github.com/pedy4000/noker/internal/queue"
 	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/internal/service"
 	"github.com/pedy4000/noker/pkg/config"
 	"github.com/pedy4000/noker/pkg/db"
 	"github.com/pedy4000/noker/pkg/logger"
@@ -37,13 +39,30 @@ func main() {
 		log.Fatal("database connection failed:", err)
 	}
 
-	queries := repository.New(dbConn)
+	queries := repository.NewStore(dbConn)
+
+	// A model with other dimensions than the column would fail every embedding
+	if err := ai.CheckEmbeddingDimensions(context.Background(), queries.Queries, cfg); err != nil {
+		log.Fatal("embedding config:", err)
+	}
+
+	// Link meetings the configured customer keys name but that have no customer yet
+	linked, err := service.NewOpportunityService(queries).BackfillCustomers(context.Background(), service.CustomerKeys{
+		Name: cfg.Customers.NameKeys,
+		Plan: cfg.Customers.PlanKey,
+		ARR:  cfg.Customers.ARRKey,
+	})
+	if err != nil {
+		logger.Error("Customer backfill failed:", err)
+	} else if linked > 0 {
+		logger.Info("Linked", linked, "meetings to their customers")
+	}
 
 	// Initialize queue processor
 	processor := queue.NewProcessor(queries, cfg)
 
 	// Create handler (API needs processor for enqueue)
-	handler := api.NewHandler(queries, processor)
+	handler := api.NewHandler(queries, processor, cfg)
 
 	// Create router
 	router := api.NewRouter(handler, cfg)
@@ -69,19 +88,27 @@ func main() {
 
 	// Start background worker (only if AI is enabled)
 	if cfg.AI.Enabled {
-		go func() {
-			logger.Info("Starting background AI worker...")
-			processor.Start()
-		}()
+		// Start only spawns the workers, so it returns before shutdown can call Stop.
+		logger.Info("Starting background AI worker...")
+		processor.Start()
 	} else {
 		logger.Info("AI worker disabled via config")
 	}
 
+	// Send webhook deliveries (events are queued either way)
+	var dispatcher *queue.WebhookDispatcher
+	if cfg.Webhooks.Enabled {
+		dispatcher = queue.NewWebhookDispatcher(queries, cfg)
+		dispatcher.Start()
+	} else {
+		logger.Info("Webhook dispatcher disabled via config")
+	}
+
 	// Graceful shutdown
-	waitForShutdown(srv, dbConn)
+	waitForShutdown(srv, processor, dispatcher, dbConn)
 }
 
-func waitForShutdown(srv *http.Server, dbConn *sql.DB) {
+func waitForShutdown(srv *http.Server, processor queue.Processor, dispatcher *queue.WebhookDispatcher, dbConn *sql.DB) {
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
 	<-sig
@@ -98,8 +125,17 @@ func waitForShutdown(srv *http.Server, dbConn *sql.DB) {
 			logger.Info("HTTP server stopped gracefully")
 		}
 	}
+
+	// Give worker time to finish current jobs, then release the rest
+	logger.Info("Draining background worker...")
+	processor.Stop()
+
+	if dispatcher != nil {
+		dispatcher.Stop()
+	}
+
 	dbConn.Close()
+	logger.Info("Database connection closed")
 
-	// Give worker time to finish current jobs
 	logger.Info("Noker stopped")
 }
@@ -0,0 +1,97 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pedy4000/noker/internal/models"
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/pkg/config"
+	"github.com/pedy4000/noker/pkg/logger"
+)
+
+// AnthropicExtractor talks to the Anthropic Messages API.
+type AnthropicExtractor struct {
+	client *http.Client
+	cfg    *config.Config
+}
+
+func NewAnthropicProvider(cfg *config.Config) *AnthropicExtractor {
+	return &AnthropicExtractor{
+		client: newHTTPClient(cfg),
+		cfg:    cfg,
+	}
+}
+
+func (e *AnthropicExtractor) Extract(ctx context.Context, meeting *models.Meeting, opps []repository.ListAllOpportunitiesForDeduplicationRow) ([]models.ExtractedOpportunity, error) {
+	settings := e.cfg.AI.Anthropic
+
+	reqBody := map[string]any{
+		"model":       e.cfg.AI.Model,
+		"max_tokens":  settings.MaxTokens,
+		"temperature": e.cfg.AI.Temperature,
+		"system":      SystemPrompt,
+		"messages": []map[string]string{
+			{"role": "user", "content": buildUserPrompt(meeting, opps)},
+		},
+	}
+
+	jsonBody, _ := json.Marshal(reqBody)
+
+	endpoint := strings.TrimRight(settings.BaseURL, "/") + "/v1/messages"
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("x-api-key", settings.APIKey)
+	req.Header.Set("anthropic-version", settings.Version)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != 200 {
+		logger.Error("Anthropic error:", resp.StatusCode, string(body))
+		return nil, &ProviderError{Provider: "anthropic", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var result struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		StopReason string `json:"stop_reason"`
+	}
+
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedOutput, err)
+	}
+
+	if result.StopReason == "max_tokens" {
+		// Asking again would get cut off at the same place.
+		return nil, fmt.Errorf("answer cut off at max_tokens (%d), raise ai.anthropic.max_tokens", settings.MaxTokens)
+	}
+
+	var text strings.Builder
+	for _, block := range result.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return nil, fmt.Errorf("%w: no response from LLM", ErrMalformedOutput)
+	}
+
+	return parseExtraction(text.String())
+}
@@ -0,0 +1,307 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pedy4000/noker/internal/models"
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/pkg/config"
+	"github.com/pedy4000/noker/pkg/logger"
+)
+
+const (
+	CassetteRecord      = "record"
+	CassetteReplay      = "replay"
+	CassettePassthrough = "passthrough"
+)
+
+// ErrCassetteMiss is returned in replay mode when nothing was recorded for a request,
+// or it was recorded with another provider, model or prompt.
+var ErrCassetteMiss = errors.New("no cassette recorded for this request")
+
+// CassetteProvider wraps any Provider and records its Extract calls to disk, or
+// replays them later without touching the network.
+//
+// A cassette keeps the raw HTTP exchanges with the provider, failed and malformed
+// ones included, next to the parsed results. Replay feeds the recorded responses
+// back through the wrapped provider, so a bad extraction fails the same way again.
+// Providers that don't speak HTTP, like the fake one, replay the parsed results.
+//
+// Opportunity IDs change from run to run, so requests are keyed by meeting content
+// and candidate struggles/themes only. On replay, matched IDs are translated back to
+// the current candidate with the same struggle and theme.
+type CassetteProvider struct {
+	next Provider
+	mode string
+	dir  string
+	cfg  *config.Config
+}
+
+type cassetteCandidate struct {
+	ID       string `json:"id"`
+	Struggle string `json:"struggle"`
+	Theme    string `json:"theme"`
+}
+
+type cassette struct {
+	Key        string `json:"key"`
+	Provider   string `json:"provider"`
+	Model      string `json:"model"`
+	PromptHash string `json:"prompt_hash"`
+	Meeting    struct {
+		Title    string `json:"title"`
+		Source   string `json:"source"`
+		RawNotes string `json:"raw_notes"`
+	} `json:"meeting"`
+	Candidates []cassetteCandidate           `json:"candidates"`
+	Exchanges  []cassetteExchange            `json:"exchanges,omitempty"`
+	Results    []models.ExtractedOpportunity `json:"results"`
+	Error      string                        `json:"error,omitempty"` // the extraction failed
+	RecordedAt time.Time                     `json:"recorded_at"`
+}
+
+// cassetteExchange is one raw HTTP round trip with the provider. Headers, and
+// with them API keys, are not recorded.
+type cassetteExchange struct {
+	Method   string `json:"method"`
+	URL      string `json:"url"`
+	Request  string `json:"request"`
+	Status   int    `json:"status,omitempty"`
+	Response string `json:"response,omitempty"`
+	Error    string `json:"error,omitempty"` // no response at all, e.g. a timeout
+}
+
+func NewCassetteProvider(next Provider, cfg *config.Config) *CassetteProvider {
+	return &CassetteProvider{
+		next: next,
+		mode: cfg.AI.Cassette.Mode,
+		dir:  cfg.AI.Cassette.Dir,
+		cfg:  cfg,
+	}
+}
+
+func (c *CassetteProvider) Extract(ctx context.Context, meeting *models.Meeting, opps []repository.ListAllOpportunitiesForDeduplicationRow) ([]models.ExtractedOpportunity, error) {
+	key := cassetteKey(meeting, opps)
+	path := filepath.Join(c.dir, key+".json")
+
+	switch c.mode {
+	case CassetteReplay:
+		return c.replay(ctx, path, key, meeting, opps)
+	case CassetteRecord:
+		tape := &tapeDeck{}
+		results, err := c.next.Extract(context.WithValue(ctx, tapeDeckKey{}, tape), meeting, opps)
+		if recErr := c.record(path, key, meeting, opps, tape.exchanges, results, err); recErr != nil {
+			logger.Error("Failed to record cassette:", path, recErr)
+		}
+		return results, err
+	default:
+		return c.next.Extract(ctx, meeting, opps)
+	}
+}
+
+func (c *CassetteProvider) replay(ctx context.Context, path, key string, meeting *models.Meeting, opps []repository.ListAllOpportunitiesForDeduplicationRow) ([]models.ExtractedOpportunity, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w (key %s)", ErrCassetteMiss, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var tape cassette
+	if err := json.Unmarshal(data, &tape); err != nil {
+		return nil, fmt.Errorf("corrupt cassette %s: %w", path, err)
+	}
+
+	// An answer to another prompt or model says nothing about this one.
+	if tape.Provider != c.cfg.AI.Provider || tape.Model != c.cfg.AI.Model || tape.PromptHash != promptHash() {
+		return nil, fmt.Errorf("%w (key %s): recorded with %s/%s and prompt %s, running %s/%s and prompt %s",
+			ErrCassetteMiss, key, tape.Provider, tape.Model, tape.PromptHash, c.cfg.AI.Provider, c.cfg.AI.Model, promptHash())
+	}
+
+	results := tape.Results
+	switch {
+	case len(tape.Exchanges) > 0:
+		deck := &tapeDeck{replay: true, exchanges: tape.Exchanges}
+		if results, err = c.next.Extract(context.WithValue(ctx, tapeDeckKey{}, deck), meeting, opps); err != nil {
+			return nil, err
+		}
+	case tape.Error != "":
+		return nil, fmt.Errorf("recorded failure: %s", tape.Error)
+	}
+
+	// recorded ID → current ID, through the (struggle, theme) both runs share
+	current := make(map[string]string, len(opps))
+	for _, o := range opps {
+		current[candidateFingerprint(o.Struggle, o.ThemeName)] = o.OpportunityID
+	}
+	translate := make(map[string]string, len(tape.Candidates))
+	for _, rc := range tape.Candidates {
+		if id, ok := current[candidateFingerprint(rc.Struggle, rc.Theme)]; ok {
+			translate[rc.ID] = id
+		}
+	}
+
+	translated := make([]models.ExtractedOpportunity, len(results))
+	for i, r := range results {
+		if id, ok := translate[r.ExistingOpportunityID]; ok {
+			r.ExistingOpportunityID = id
+		}
+		if id, ok := translate[r.ParentOpportunityID]; ok {
+			r.ParentOpportunityID = id
+		}
+		translated[i] = r
+	}
+
+	logger.Debug("Replayed cassette", key)
+	return translated, nil
+}
+
+func (c *CassetteProvider) record(path, key string, meeting *models.Meeting, opps []repository.ListAllOpportunitiesForDeduplicationRow, exchanges []cassetteExchange, results []models.ExtractedOpportunity, extractErr error) error {
+	tape := cassette{
+		Key:        key,
+		Provider:   c.cfg.AI.Provider,
+		Model:      c.cfg.AI.Model,
+		PromptHash: promptHash(),
+		Exchanges:  exchanges,
+		Results:    results,
+		RecordedAt: time.Now().UTC(),
+	}
+	if extractErr != nil {
+		tape.Error = extractErr.Error()
+	}
+	tape.Meeting.Title = meeting.Title
+	tape.Meeting.Source = string(meeting.Source)
+	tape.Meeting.RawNotes = meeting.RawNotes
+	for _, o := range opps {
+		tape.Candidates = append(tape.Candidates, cassetteCandidate{ID: o.OpportunityID, Struggle: o.Struggle, Theme: o.ThemeName})
+	}
+
+	data, err := json.MarshalIndent(tape, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return err
+	}
+
+	logger.Debug("Recorded cassette", key)
+	return os.WriteFile(path, data, 0o644)
+}
+
+type tapeDeckKey struct{}
+
+// tapeDeck holds the raw exchanges of one Extract call: it's filled while
+// recording and played back in order while replaying.
+type tapeDeck struct {
+	mu        sync.Mutex
+	replay    bool
+	exchanges []cassetteExchange
+	next      int
+}
+
+// tapeTransport is the transport of every provider's HTTP client. Requests whose
+// context carries a tapeDeck are recorded, or answered from it; others pass through.
+type tapeTransport struct {
+	next http.RoundTripper
+}
+
+func (t tapeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	deck, ok := req.Context().Value(tapeDeckKey{}).(*tapeDeck)
+	if !ok {
+		return t.next.RoundTrip(req)
+	}
+
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	deck.mu.Lock()
+	defer deck.mu.Unlock()
+
+	if deck.replay {
+		if deck.next >= len(deck.exchanges) {
+			return nil, fmt.Errorf("%w: the provider sent more requests than were recorded", ErrCassetteMiss)
+		}
+		ex := deck.exchanges[deck.next]
+		deck.next++
+		if ex.Error != "" {
+			return nil, errors.New(ex.Error)
+		}
+		return &http.Response{
+			Status:     http.StatusText(ex.Status),
+			StatusCode: ex.Status,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(ex.Response)),
+			Request:    req,
+		}, nil
+	}
+
+	ex := cassetteExchange{Method: req.Method, URL: req.URL.String(), Request: string(reqBody)}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		ex.Error = err.Error()
+		deck.exchanges = append(deck.exchanges, ex)
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	ex.Status = resp.StatusCode
+	ex.Response = string(respBody)
+	deck.exchanges = append(deck.exchanges, ex)
+	return resp, nil
+}
+
+// cassetteKey hashes what the model actually sees, minus the opportunity IDs.
+func cassetteKey(meeting *models.Meeting, opps []repository.ListAllOpportunitiesForDeduplicationRow) string {
+	candidates := make([]string, len(opps))
+	for i, o := range opps {
+		candidates[i] = candidateFingerprint(o.Struggle, o.ThemeName)
+	}
+	sort.Strings(candidates)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%s\n", meeting.Title, meeting.Source, meeting.RawNotes)
+	for _, c := range candidates {
+		fmt.Fprintln(h, c)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+func candidateFingerprint(struggle, theme string) string {
+	return strings.ToLower(strings.TrimSpace(struggle)) + " | " + strings.ToLower(strings.TrimSpace(theme))
+}
+
+// promptHash tells which prompt version a cassette was recorded with.
+func promptHash() string {
+	sum := sha256.Sum256([]byte(SystemPrompt + UserPromptTemplate))
+	return hex.EncodeToString(sum[:])[:12]
+}
@@ -0,0 +1,220 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pedy4000/noker/internal/models"
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/pkg/config"
+	"github.com/pedy4000/noker/pkg/utils"
+)
+
+// Chunk is a piece of meeting notes small enough for one prompt.
+type Chunk struct {
+	Index  int
+	Offset int // in characters (runes) from the start of the notes
+	Text   string
+}
+
+// speakerLine matches the start of a speaker turn: "Sara:", "[12:04] Sara:", "Customer (CFO):".
+var speakerLine = regexp.MustCompile(`^\s*(\[?\d{1,2}:\d{2}(:\d{2})?\]?\s*)?[\p{L}][\p{L}\p{N} ._'()-]{0,40}:(\s|$)`)
+
+// SplitNotes cuts notes into chunks of at most size characters. It prefers to cut
+// before a speaker turn or a paragraph, then at any line break, then at a space.
+// Chunks cover the notes exactly, so offsets point back into the original text.
+func SplitNotes(notes string, size int) []Chunk {
+	runes := []rune(notes)
+	if size <= 0 || len(runes) <= size {
+		return []Chunk{{Text: notes}}
+	}
+
+	var strong, weak []int // rune offsets of line starts, in order
+	pos := 0
+	prevBlank := false
+	for i, line := range strings.Split(notes, "\n") {
+		if i > 0 {
+			weak = append(weak, pos)
+			if prevBlank || speakerLine.MatchString(line) {
+				strong = append(strong, pos)
+			}
+		}
+		prevBlank = strings.TrimSpace(line) == ""
+		pos += len([]rune(line)) + 1
+	}
+
+	var chunks []Chunk
+	for start := 0; start < len(runes); {
+		end := len(runes)
+		if end-start > size {
+			limit := start + size
+			end = lastCut(strong, start, limit)
+			if end < 0 {
+				end = lastCut(weak, start, limit)
+			}
+			if end < 0 {
+				end = lastSpace(runes, start+size/2, limit)
+			}
+		}
+
+		if text := string(runes[start:end]); strings.TrimSpace(text) != "" {
+			chunks = append(chunks, Chunk{Index: len(chunks), Offset: start, Text: text})
+		}
+		start = end
+	}
+
+	return chunks
+}
+
+// lastCut returns the last cut in (start, limit], or -1.
+func lastCut(cuts []int, start, limit int) int {
+	for i := len(cuts) - 1; i >= 0; i-- {
+		if cuts[i] <= limit {
+			if cuts[i] > start {
+				return cuts[i]
+			}
+			break
+		}
+	}
+	return -1
+}
+
+// lastSpace cuts after the last whitespace in [from, limit), or hard at limit.
+func lastSpace(runes []rune, from, limit int) int {
+	for i := limit - 1; i >= from; i-- {
+		if unicode.IsSpace(runes[i]) {
+			return i + 1
+		}
+	}
+	return limit
+}
+
+// chunkRefPrefix marks opportunities found in earlier chunks of the same meeting
+// when they are offered to later chunks as match candidates.
+const chunkRefPrefix = "chunk-new-"
+
+// ChunkingProvider extracts long notes chunk by chunk and merges the results, so
+// the same pain mentioned in several chunks becomes one opportunity.
+// Notes that fit in one chunk are passed through untouched.
+type ChunkingProvider struct {
+	next Provider
+	size int
+}
+
+func NewChunkingProvider(next Provider, cfg *config.Config) *ChunkingProvider {
+	return &ChunkingProvider{next: next, size: cfg.AI.ChunkSize}
+}
+
+func (c *ChunkingProvider) Extract(ctx context.Context, meeting *models.Meeting, opps []repository.ListAllOpportunitiesForDeduplicationRow) ([]models.ExtractedOpportunity, error) {
+	chunks := SplitNotes(meeting.RawNotes, c.size)
+	if len(chunks) <= 1 {
+		return c.next.Extract(ctx, meeting, opps)
+	}
+
+	var merged []models.ExtractedOpportunity
+	for _, chunk := range chunks {
+		part := *meeting
+		part.Title = fmt.Sprintf("%s (part %d of %d)", meeting.Title, chunk.Index+1, len(chunks))
+		part.RawNotes = chunk.Text
+
+		// Later chunks can match what earlier chunks found, not just what's in the database.
+		candidates := append(opps[:len(opps):len(opps)], pendingCandidates(merged)...)
+
+		results, err := c.next.Extract(ctx, &part, candidates)
+		if err != nil {
+			return nil, fmt.Errorf("chunk %d of %d: %w", chunk.Index+1, len(chunks), err)
+		}
+
+		for i := range results {
+			for j := range results[i].EvidenceQuotes {
+				quote := &results[i].EvidenceQuotes[j]
+				quote.ChunkIndex = chunk.Index
+				// Point at the quote itself; a quote the model reworded beyond
+				// recognition falls back to the start of its chunk.
+				quote.ChunkOffset = chunk.Offset
+				if start, _, ok := LocateQuote(chunk.Text, quote.Quote); ok {
+					quote.ChunkOffset += start
+				}
+			}
+		}
+		merged = MergeExtractions(merged, results)
+	}
+
+	return merged, nil
+}
+
+func pendingCandidates(merged []models.ExtractedOpportunity) []repository.ListAllOpportunitiesForDeduplicationRow {
+	var rows []repository.ListAllOpportunitiesForDeduplicationRow
+	for i, item := range merged {
+		if item.Type != "new" {
+			continue
+		}
+		theme := utils.FormatTheme(item.Theme)
+		if theme == "" {
+			theme = "No theme"
+		}
+		rows = append(rows, repository.ListAllOpportunitiesForDeduplicationRow{
+			OpportunityID: chunkRefPrefix + strconv.Itoa(i),
+			Struggle:      item.Struggle,
+			ThemeName:     theme,
+		})
+	}
+	return rows
+}
+
+// MergeExtractions adds results to merged, folding duplicates into the item already
+// there: matches of the same opportunity, new items with the same struggle and theme,
+// and matches against an earlier chunk's new item.
+func MergeExtractions(merged, results []models.ExtractedOpportunity) []models.ExtractedOpportunity {
+	index := make(map[string]int, len(merged))
+	for i, item := range merged {
+		index[mergeKey(item)] = i
+	}
+
+	for _, item := range results {
+		target := -1
+		if item.Type == "match" && strings.HasPrefix(item.ExistingOpportunityID, chunkRefPrefix) {
+			if i, err := strconv.Atoi(strings.TrimPrefix(item.ExistingOpportunityID, chunkRefPrefix)); err == nil && i >= 0 && i < len(merged) {
+				target = i
+			}
+		} else if i, ok := index[mergeKey(item)]; ok {
+			target = i
+		}
+
+		if target < 0 {
+			index[mergeKey(item)] = len(merged)
+			merged = append(merged, item)
+			continue
+		}
+		merged[target].EvidenceQuotes = appendQuotes(merged[target].EvidenceQuotes, item.EvidenceQuotes)
+	}
+
+	return merged
+}
+
+func mergeKey(item models.ExtractedOpportunity) string {
+	if item.Type == "match" {
+		return "match:" + strings.ToLower(strings.TrimSpace(item.ExistingOpportunityID))
+	}
+	return "new:" + strings.ToLower(strings.TrimSpace(item.Struggle)) + "|" + utils.FormatTheme(item.Theme)
+}
+
+func appendQuotes(quotes, more []models.EvidenceQuote) []models.EvidenceQuote {
+	for _, q := range more {
+		duplicate := false
+		for _, existing := range quotes {
+			if existing.Quote == q.Quote {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			quotes = append(quotes, q)
+		}
+	}
+	return quotes
+}
@@ -0,0 +1,141 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/pkg/config"
+)
+
+// Embedder turns texts into vectors, so only the existing opportunities closest to a
+// meeting need to be sent to the model instead of all of them.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// NewEmbedder returns the embedder configured under ai.embedding, or nil when
+// embeddings are disabled or the provider has none (callers then fall back to
+// sending every opportunity).
+func NewEmbedder(cfg *config.Config) Embedder {
+	if cfg.AI.Embedding.Disabled || cfg.AI.Cassette.Mode == CassetteReplay {
+		// Replay must stay offline, and cassettes only hold extractions.
+		return nil
+	}
+
+	provider := cfg.AI.Embedding.Provider
+	if provider == "" {
+		provider = cfg.AI.Provider
+	}
+
+	switch provider {
+	case "openai", "":
+		return NewOpenAIProvider(cfg)
+	case "compatible":
+		return NewCompatibleProvider(cfg)
+	case "fake":
+		return NewFakeProvider(cfg)
+	default:
+		return nil
+	}
+}
+
+// CheckEmbeddingDimensions fails when ai.embedding.dimensions doesn't fit the
+// opportunity_embeddings column. Every embedding would be refused, and every
+// meeting quietly compared against all opportunities.
+func CheckEmbeddingDimensions(ctx context.Context, q *repository.Queries, cfg *config.Config) error {
+	if NewEmbedder(cfg) == nil {
+		return nil
+	}
+
+	dims, err := q.GetEmbeddingDimensions(ctx)
+	if err != nil {
+		return fmt.Errorf("reading the opportunity_embeddings column: %w", err)
+	}
+	if int(dims) != cfg.AI.Embedding.Dimensions {
+		return fmt.Errorf("ai.embedding.dimensions is %d but opportunity_embeddings.embedding is vector(%d): "+
+			"configure a model with %d dimensions or migrate the column", cfg.AI.Embedding.Dimensions, dims, dims)
+	}
+	return nil
+}
+
+func (e *OpenAIExtractor) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	reqBody := map[string]any{
+		"model": e.cfg.AI.Embedding.Model,
+		"input": texts,
+	}
+	if e.dimensions {
+		reqBody["dimensions"] = e.cfg.AI.Embedding.Dimensions
+	}
+
+	body, err := e.post(ctx, e.embeddings, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedOutput, err)
+	}
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("%w: got %d embeddings for %d inputs", ErrMalformedOutput, len(result.Data), len(texts))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, d := range result.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("%w: embedding index %d out of range", ErrMalformedOutput, d.Index)
+		}
+		if len(d.Embedding) != e.cfg.AI.Embedding.Dimensions {
+			return nil, fmt.Errorf("embedding has %d dimensions, expected %d (check ai.embedding.model)",
+				len(d.Embedding), e.cfg.AI.Embedding.Dimensions)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+
+	return vectors, nil
+}
+
+// Embed hashes words into buckets, so texts sharing words end up close together.
+// Crude, but deterministic and good enough to exercise retrieval offline.
+func (p *FakeProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		v := make([]float32, p.dimensions)
+		words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		})
+		for _, w := range words {
+			h := fnv.New32a()
+			h.Write([]byte(w))
+			v[h.Sum32()%uint32(p.dimensions)]++
+		}
+		vectors[i] = normalize(v)
+	}
+	return vectors, nil
+}
+
+func normalize(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return v
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range v {
+		v[i] /= norm
+	}
+	return v
+}
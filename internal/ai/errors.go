@@ -0,0 +1,46 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// ErrMalformedOutput means the provider answered, but not with the JSON we asked for.
+// Models are not deterministic, so asking again usually helps.
+var ErrMalformedOutput = errors.New("malformed LLM output")
+
+// ProviderError is returned when a provider answers with a non-200 status code.
+type ProviderError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("%s error %d", e.Provider, e.StatusCode)
+}
+
+// IsRetryable reports whether a failed extraction is worth another attempt.
+// Rate limits, provider outages, timeouts and malformed output are transient;
+// bad requests, auth errors and cancellations are not.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		return providerErr.StatusCode == http.StatusTooManyRequests ||
+			providerErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	if errors.Is(err, ErrMalformedOutput) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
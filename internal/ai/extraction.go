@@ -0,0 +1,57 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pedy4000/noker/internal/models"
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/pkg/logger"
+)
+
+type extractionResponse struct {
+	Results []models.ExtractedOpportunity `json:"results"`
+}
+
+func buildUserPrompt(meeting *models.Meeting, opps []repository.ListAllOpportunitiesForDeduplicationRow) string {
+	return fmt.Sprintf(UserPromptTemplate, meeting.Title, meeting.Source, meeting.RawNotes, formatExistingForAI(opps))
+}
+
+// parseExtraction decodes the model's answer. Models without a JSON mode sometimes
+// wrap it in a markdown code fence, which is stripped first.
+func parseExtraction(content string) ([]models.ExtractedOpportunity, error) {
+	content = strings.TrimSpace(content)
+	if strings.HasPrefix(content, "```") {
+		content = strings.TrimPrefix(content, "```json")
+		content = strings.TrimPrefix(content, "```")
+		content = strings.TrimSuffix(content, "```")
+	}
+
+	var extracted extractionResponse
+	if err := json.Unmarshal([]byte(content), &extracted); err != nil {
+		logger.Error("Failed to parse LLM JSON:", err, content)
+		return nil, fmt.Errorf("%w: %v", ErrMalformedOutput, err)
+	}
+
+	return extracted.Results, nil
+}
+
+func formatExistingForAI(opps []repository.ListAllOpportunitiesForDeduplicationRow) string {
+	if len(opps) == 0 {
+		return "None — this is the first meeting"
+	}
+
+	var lines []string
+	for _, o := range opps {
+		id := o.OpportunityID
+		struggle := o.Struggle
+		if len(struggle) > 100 {
+			struggle = struggle[:97] + "..."
+		}
+		theme := o.ThemeName
+
+		lines = append(lines, fmt.Sprintf("%s | %s | %s", id, struggle, theme))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,154 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pedy4000/noker/internal/models"
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/pkg/config"
+	"github.com/pedy4000/noker/pkg/logger"
+	"github.com/pedy4000/noker/pkg/utils"
+)
+
+// FakeProvider returns scripted extractions without any network call, for tests and demos.
+// A fixture named after the meeting hash wins; otherwise keyword rules are applied.
+type FakeProvider struct {
+	fixturesDir string
+	rules       []FakeRule
+	dimensions  int // size of the vectors returned by Embed
+}
+
+// FakeRule turns every sentence mentioning one of Keywords into evidence for one opportunity.
+// If an existing opportunity has the same struggle and theme, the rule produces a match.
+type FakeRule struct {
+	Keywords     []string `json:"keywords"`
+	UserSegment  string   `json:"user_segment"`
+	Struggle     string   `json:"struggle"`
+	WhyItMatters string   `json:"why_it_matters,omitempty"`
+	Workaround   string   `json:"workaround,omitempty"`
+	Theme        string   `json:"theme"`
+}
+
+func NewFakeProvider(cfg *config.Config) *FakeProvider {
+	p := &FakeProvider{fixturesDir: cfg.AI.Fake.FixturesDir, dimensions: cfg.AI.Embedding.Dimensions}
+	if p.dimensions <= 0 {
+		p.dimensions = 1536
+	}
+
+	if cfg.AI.Fake.RulesFile != "" {
+		rules, err := loadFakeRules(cfg.AI.Fake.RulesFile)
+		if err != nil {
+			logger.Error("Failed to load fake AI rules:", cfg.AI.Fake.RulesFile, err)
+		}
+		p.rules = rules
+	}
+
+	return p
+}
+
+func loadFakeRules(path string) ([]FakeRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Rules []FakeRule `json:"rules"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	return file.Rules, nil
+}
+
+// MeetingHash identifies a meeting by its content, so fixtures survive new meeting IDs.
+func MeetingHash(meeting *models.Meeting) string {
+	sum := sha256.Sum256([]byte(meeting.Title + "\n" + meeting.RawNotes))
+	return hex.EncodeToString(sum[:])
+}
+
+func (p *FakeProvider) Extract(ctx context.Context, meeting *models.Meeting, opps []repository.ListAllOpportunitiesForDeduplicationRow) ([]models.ExtractedOpportunity, error) {
+	if p.fixturesDir != "" {
+		data, err := os.ReadFile(filepath.Join(p.fixturesDir, MeetingHash(meeting)+".json"))
+		if err == nil {
+			return parseExtraction(string(data))
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	return p.applyRules(meeting, opps), nil
+}
+
+var sentenceEnd = regexp.MustCompile(`[.!?\n]+`)
+
+func (p *FakeProvider) applyRules(meeting *models.Meeting, opps []repository.ListAllOpportunitiesForDeduplicationRow) []models.ExtractedOpportunity {
+	var sentences []string
+	for _, s := range sentenceEnd.Split(meeting.RawNotes, -1) {
+		if s = strings.TrimSpace(s); s != "" {
+			sentences = append(sentences, s)
+		}
+	}
+
+	results := []models.ExtractedOpportunity{}
+	for _, rule := range p.rules {
+		var quotes []models.EvidenceQuote
+		for _, sentence := range sentences {
+			if containsAny(sentence, rule.Keywords) {
+				quotes = append(quotes, models.EvidenceQuote{Quote: sentence, Context: meeting.Title})
+			}
+		}
+		if len(quotes) == 0 {
+			continue
+		}
+
+		if id := findExisting(opps, rule); id != "" {
+			results = append(results, models.ExtractedOpportunity{
+				Type:                  "match",
+				ExistingOpportunityID: id,
+				EvidenceQuotes:        quotes,
+			})
+			continue
+		}
+
+		results = append(results, models.ExtractedOpportunity{
+			Type:           "new",
+			UserSegment:    rule.UserSegment,
+			Struggle:       rule.Struggle,
+			WhyItMatters:   rule.WhyItMatters,
+			Workaround:     rule.Workaround,
+			Theme:          rule.Theme,
+			EvidenceQuotes: quotes,
+		})
+	}
+
+	return results
+}
+
+func containsAny(sentence string, keywords []string) bool {
+	lower := strings.ToLower(sentence)
+	for _, k := range keywords {
+		if strings.Contains(lower, strings.ToLower(k)) {
+			return true
+		}
+	}
+	return false
+}
+
+func findExisting(opps []repository.ListAllOpportunitiesForDeduplicationRow, rule FakeRule) string {
+	for _, o := range opps {
+		if strings.EqualFold(o.Struggle, rule.Struggle) && o.ThemeName == utils.FormatTheme(rule.Theme) {
+			return o.OpportunityID
+		}
+	}
+	return ""
+}
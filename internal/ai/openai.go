@@ -7,8 +7,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
-	"time"
 
 	"github.com/pedy4000/noker/internal/models"
 	"github.com/pedy4000/noker/internal/repository"
@@ -16,57 +16,76 @@ import (
 	"github.com/pedy4000/noker/pkg/logger"
 )
 
+// OpenAIExtractor talks to the OpenAI chat completions API. The same wire format is
+// spoken by Ollama, vLLM and Azure OpenAI, so it also backs the "compatible" provider.
 type OpenAIExtractor struct {
 	client *http.Client
 	cfg    *config.Config
+
+	name           string // used in logs and errors
+	endpoint       string
+	embeddings     string // embeddings endpoint, see Embed
+	apiKey         string
+	authHeader     string
+	responseFormat bool
+	dimensions     bool // the server accepts "dimensions" for embeddings
 }
 
 func NewOpenAIProvider(cfg *config.Config) *OpenAIExtractor {
 	return &OpenAIExtractor{
-		client: &http.Client{Timeout: 90 * time.Second},
-		cfg:    cfg,
+		client:         newHTTPClient(cfg),
+		cfg:            cfg,
+		name:           "openai",
+		endpoint:       strings.TrimRight(cfg.AI.OpenAI.BaseURL, "/") + "/chat/completions",
+		embeddings:     strings.TrimRight(cfg.AI.OpenAI.BaseURL, "/") + "/embeddings",
+		apiKey:         cfg.AI.APIKey,
+		authHeader:     "Authorization",
+		responseFormat: true,
+		dimensions:     true,
 	}
 }
 
-type extractionResponse struct {
-	Results []models.ExtractedOpportunity `json:"results"`
+// NewCompatibleProvider points the OpenAI extractor at any OpenAI-compatible server.
+// For Azure, set base_url to the deployment URL, auth_header to "api-key" and api_version.
+func NewCompatibleProvider(cfg *config.Config) *OpenAIExtractor {
+	c := cfg.AI.Compatible
+
+	endpoint := strings.TrimRight(c.BaseURL, "/") + "/chat/completions"
+	embeddings := strings.TrimRight(c.BaseURL, "/") + "/embeddings"
+	if c.APIVersion != "" {
+		endpoint += "?api-version=" + url.QueryEscape(c.APIVersion)
+		embeddings += "?api-version=" + url.QueryEscape(c.APIVersion)
+	}
+
+	return &OpenAIExtractor{
+		client:         newHTTPClient(cfg),
+		cfg:            cfg,
+		name:           "compatible",
+		endpoint:       endpoint,
+		embeddings:     embeddings,
+		apiKey:         c.APIKey,
+		authHeader:     c.AuthHeader,
+		responseFormat: !c.SkipResponseFormat,
+	}
 }
 
 func (e *OpenAIExtractor) Extract(ctx context.Context, meeting *models.Meeting, opps []repository.ListAllOpportunitiesForDeduplicationRow) ([]models.ExtractedOpportunity, error) {
-	userPrompt := fmt.Sprintf(UserPromptTemplate, meeting.Title, meeting.Source, meeting.RawNotes, formatExistingForAI(opps))
-
 	reqBody := map[string]any{
 		"model":       e.cfg.AI.Model,
 		"temperature": e.cfg.AI.Temperature,
 		"messages": []map[string]string{
 			{"role": "system", "content": SystemPrompt},
-			{"role": "user", "content": userPrompt},
+			{"role": "user", "content": buildUserPrompt(meeting, opps)},
 		},
-		"response_format": map[string]string{"type": "json_object"},
 	}
-
-	jsonBody, _ := json.Marshal(reqBody)
-
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return nil, err
+	if e.responseFormat {
+		reqBody["response_format"] = map[string]string{"type": "json_object"}
 	}
 
-	req.Header.Set("Authorization", "Bearer "+e.cfg.AI.APIKey)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := e.client.Do(req)
+	body, err := e.post(ctx, e.endpoint, reqBody)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
-
-	if resp.StatusCode != 200 {
-		logger.Error("OpenAI error:", resp.StatusCode, string(body))
-		return nil, fmt.Errorf("openai error %d", resp.StatusCode)
-	}
 
 	var result struct {
 		Choices []struct {
@@ -77,37 +96,46 @@ func (e *OpenAIExtractor) Extract(ctx context.Context, meeting *models.Meeting,
 	}
 
 	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %v", ErrMalformedOutput, err)
 	}
 
 	if len(result.Choices) == 0 {
-		return nil, fmt.Errorf("no response from LLM")
+		return nil, fmt.Errorf("%w: no response from LLM", ErrMalformedOutput)
 	}
 
-	var extracted extractionResponse
-	if err := json.Unmarshal([]byte(result.Choices[0].Message.Content), &extracted); err != nil {
-		logger.Error("Failed to parse LLM JSON:", err, result.Choices[0].Message.Content)
+	return parseExtraction(result.Choices[0].Message.Content)
+}
+
+// post sends a JSON request and returns the body of a 200 response.
+// Any other status is returned as a *ProviderError.
+func (e *OpenAIExtractor) post(ctx context.Context, endpoint string, reqBody any) ([]byte, error) {
+	jsonBody, _ := json.Marshal(reqBody)
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
 		return nil, err
 	}
 
-	return extracted.Results, nil
-}
+	if e.apiKey != "" {
+		if e.authHeader == "Authorization" {
+			req.Header.Set("Authorization", "Bearer "+e.apiKey)
+		} else {
+			req.Header.Set(e.authHeader, e.apiKey)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
 
-func formatExistingForAI(opps []repository.ListAllOpportunitiesForDeduplicationRow) string {
-	if len(opps) == 0 {
-		return "None — this is the first meeting"
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
 	}
+	defer resp.Body.Close()
 
-	var lines []string
-	for _, o := range opps {
-		id := o.OpportunityID
-		struggle := o.Struggle
-		if len(struggle) > 100 {
-			struggle = struggle[:97] + "..."
-		}
-		theme := o.ThemeName
+	body, _ := io.ReadAll(resp.Body)
 
-		lines = append(lines, fmt.Sprintf("%s | %s | %s", id, struggle, theme))
+	if resp.StatusCode != 200 {
+		logger.Error("OpenAI error:", e.name, resp.StatusCode, string(body))
+		return nil, &ProviderError{Provider: e.name, StatusCode: resp.StatusCode, Body: string(body)}
 	}
-	return strings.Join(lines, "\n")
+
+	return body, nil
 }
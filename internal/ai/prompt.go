@@ -17,6 +17,8 @@ return ONLY the existing ID + new evidence quotes.
 
 ACTION B — NEW:
 If this is a truly new problem, return a clean, professional, generalized opportunity.
+If it is a narrower, more specific part of an existing opportunity (a sub-problem, not the
+same problem), keep it NEW and set "parent_opportunity_id" to that existing opportunity's ID.
 
 CRITICAL MATCHING RULES (DO NOT BREAK THESE):
 - Match ONLY if the underlying job-to-be-done is identical
@@ -51,6 +53,7 @@ Schema:
     {
       "type": "match" | "new",
       "existing_opportunity_id": "uuid-or-null",   // only if type=match
+      "parent_opportunity_id": "uuid-or-null",     // only if type=new and it is a sub-problem of an existing one
       "user_segment": "string",                    // only if type=new
       "struggle": "short professional struggle",   // only if type=new
       "why_it_matters": "string",                  // only if type=new
@@ -66,7 +69,7 @@ Schema:
   ]
 }
 
-Existing opportunities (match by struggle + theme ONLY):
+Existing opportunities (match by struggle + theme ONLY; any of them can be a parent):
 {{.Existing}}
 
 You are now processing a new meeting. Respond ONLY with valid JSON.`
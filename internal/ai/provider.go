@@ -2,6 +2,8 @@ package ai
 
 import (
 	"context"
+	"net/http"
+	"time"
 
 	"github.com/pedy4000/noker/internal/models"
 	"github.com/pedy4000/noker/internal/repository"
@@ -18,11 +20,31 @@ func NewExtractor(cfg *config.Config) Provider {
 	switch cfg.AI.Provider {
 	case "openai":
 		p = NewOpenAIProvider(cfg)
+	case "anthropic":
+		p = NewAnthropicProvider(cfg)
+	case "compatible":
+		p = NewCompatibleProvider(cfg)
+	case "fake":
+		p = NewFakeProvider(cfg)
 	default:
 		p = NewOpenAIProvider(cfg)
 	}
 
-	return p
+	if cfg.AI.Cassette.Mode != "" {
+		p = NewCassetteProvider(p, cfg)
+	}
+
+	// Outermost, so each chunk is its own call (and its own cassette).
+	return NewChunkingProvider(p, cfg)
+}
+
+// newHTTPClient is the client providers call their API with. Its transport
+// lets a CassetteProvider record and replay the raw exchanges.
+func newHTTPClient(cfg *config.Config) *http.Client {
+	return &http.Client{
+		Timeout:   time.Duration(cfg.AI.TimeoutSec) * time.Second,
+		Transport: tapeTransport{next: http.DefaultTransport},
+	}
 }
 
 // TODO: we can easily add other ai provider like grok in case of need
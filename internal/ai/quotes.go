@@ -0,0 +1,146 @@
+package ai
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/pedy4000/noker/internal/models"
+)
+
+// minQuoteSimilarity is the share of a quote's words that must appear in one
+// window of the notes for a reworded quote to still count as found.
+const minQuoteSimilarity = 0.8
+
+// Policies for quotes that can't be found in the notes.
+const (
+	UnverifiedFlag = "flag" // keep them, with verified=false
+	UnverifiedDrop = "drop"
+)
+
+type token struct {
+	text       string
+	start, end int // rune offsets in the original text, end exclusive
+}
+
+// tokenize splits text into lowercase words, ignoring punctuation and whitespace,
+// and remembers where each word sits in the original text.
+func tokenize(text string) []token {
+	var tokens []token
+	var b strings.Builder
+	start, pos := -1, 0
+
+	flush := func() {
+		if start >= 0 {
+			tokens = append(tokens, token{text: b.String(), start: start, end: pos})
+			b.Reset()
+			start = -1
+		}
+	}
+
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			if start < 0 {
+				start = pos
+			}
+			b.WriteRune(unicode.ToLower(r))
+		} else {
+			flush()
+		}
+		pos++
+	}
+	flush()
+
+	return tokens
+}
+
+// LocateQuote finds quote in notes, tolerating differences in case, punctuation and
+// whitespace, and light rewording. It returns the matched passage as rune offsets.
+func LocateQuote(notes, quote string) (start, end int, ok bool) {
+	return locateQuote(tokenize(notes), quote)
+}
+
+func locateQuote(notes []token, quote string) (start, end int, ok bool) {
+	q := tokenize(quote)
+	if len(q) == 0 || len(q) > len(notes) {
+		return 0, 0, false
+	}
+
+	// Word-for-word match first.
+	for i := 0; i+len(q) <= len(notes); i++ {
+		j := 0
+		for j < len(q) && notes[i+j].text == q[j].text {
+			j++
+		}
+		if j == len(q) {
+			return notes[i].start, notes[i+len(q)-1].end, true
+		}
+	}
+
+	// Then the window sharing the most words with the quote.
+	need := make(map[string]int, len(q))
+	for _, t := range q {
+		need[t.text]++
+	}
+	have := make(map[string]int, len(q))
+	overlap, best, bestAt := 0, 0, 0
+
+	for i, t := range notes {
+		if have[t.text] < need[t.text] {
+			overlap++
+		}
+		have[t.text]++
+
+		if i >= len(q) {
+			old := notes[i-len(q)].text
+			have[old]--
+			if have[old] < need[old] {
+				overlap--
+			}
+		}
+
+		if i >= len(q)-1 && overlap > best {
+			best, bestAt = overlap, i-len(q)+1
+		}
+	}
+
+	if float64(best)/float64(len(q)) < minQuoteSimilarity {
+		return 0, 0, false
+	}
+	return notes[bestAt].start, notes[bestAt+len(q)-1].end, true
+}
+
+// VerifyQuotes looks up every evidence quote in the meeting notes. Found quotes get
+// Verified and their position; the rest are kept or dropped according to policy.
+// Run it before ValidateExtraction, which drops items left without evidence.
+func VerifyQuotes(notes string, items []models.ExtractedOpportunity, policy string) []ValidationIssue {
+	tokens := tokenize(notes)
+	var issues []ValidationIssue
+
+	for i := range items {
+		kept := items[i].EvidenceQuotes[:0]
+		for _, q := range items[i].EvidenceQuotes {
+			q.QuoteStart, q.QuoteEnd, q.Verified = locateQuote(tokens, q.Quote)
+			if !q.Verified && strings.TrimSpace(q.Quote) != "" {
+				issue := ValidationIssue{Index: i, Field: "evidence_quotes", Problem: "quote not found in notes: " + truncateQuote(q.Quote)}
+				if policy == UnverifiedDrop {
+					issue.Action = "dropped"
+					issues = append(issues, issue)
+					continue
+				}
+				issue.Action = "flagged"
+				issues = append(issues, issue)
+			}
+			kept = append(kept, q)
+		}
+		items[i].EvidenceQuotes = kept
+	}
+
+	return issues
+}
+
+func truncateQuote(quote string) string {
+	if runes := []rune(quote); len(runes) > 80 {
+		return string(runes[:77]) + "..."
+	}
+	return quote
+}
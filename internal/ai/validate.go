@@ -0,0 +1,144 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pedy4000/noker/internal/models"
+	"github.com/pedy4000/noker/internal/repository"
+)
+
+const (
+	maxStruggleWords = 10
+	maxThemeWords    = 3
+)
+
+// ValidationIssue describes a problem found in one extracted item and what was done about it.
+type ValidationIssue struct {
+	Index   int    `json:"index"` // position in the model's results
+	Field   string `json:"field"`
+	Problem string `json:"problem"`
+	Action  string `json:"action"` // "repaired", "dropped" or "flagged" (kept as is)
+}
+
+// ValidateExtraction holds the model's output to the schema the prompt asks for.
+// Items that can be fixed are repaired (e.g. a match with an unknown ID becomes a new
+// opportunity, an unknown parent is cleared); items that can't are dropped.
+// Every decision is returned as an issue.
+func ValidateExtraction(items []models.ExtractedOpportunity, candidates []repository.ListAllOpportunitiesForDeduplicationRow) ([]models.ExtractedOpportunity, []ValidationIssue) {
+	known := make(map[string]bool, len(candidates))
+	for _, c := range candidates {
+		known[strings.ToLower(c.OpportunityID)] = true
+	}
+
+	v := &validator{}
+	valid := make([]models.ExtractedOpportunity, 0, len(items))
+
+	for i, item := range items {
+		v.index = i
+		if item, ok := v.check(item, known); ok {
+			valid = append(valid, item)
+		}
+	}
+
+	return valid, v.issues
+}
+
+type validator struct {
+	index  int
+	issues []ValidationIssue
+}
+
+func (v *validator) repaired(field, problem string, args ...any) {
+	v.issues = append(v.issues, ValidationIssue{Index: v.index, Field: field, Problem: fmt.Sprintf(problem, args...), Action: "repaired"})
+}
+
+func (v *validator) dropped(field, problem string, args ...any) bool {
+	v.issues = append(v.issues, ValidationIssue{Index: v.index, Field: field, Problem: fmt.Sprintf(problem, args...), Action: "dropped"})
+	return false
+}
+
+func (v *validator) check(item models.ExtractedOpportunity, known map[string]bool) (models.ExtractedOpportunity, bool) {
+	item.Struggle = strings.TrimSpace(item.Struggle)
+	item.ExistingOpportunityID = strings.TrimSpace(item.ExistingOpportunityID)
+	if strings.EqualFold(item.ExistingOpportunityID, "null") {
+		item.ExistingOpportunityID = ""
+	}
+	item.ParentOpportunityID = strings.TrimSpace(item.ParentOpportunityID)
+	if strings.EqualFold(item.ParentOpportunityID, "null") {
+		item.ParentOpportunityID = ""
+	}
+
+	// Evidence is what makes an opportunity traceable; without it there is nothing to save.
+	quotes := item.EvidenceQuotes[:0:0]
+	for _, q := range item.EvidenceQuotes {
+		if q.Quote = strings.TrimSpace(q.Quote); q.Quote != "" {
+			quotes = append(quotes, q)
+		}
+	}
+	if len(quotes) == 0 {
+		return item, v.dropped("evidence_quotes", "no evidence quotes")
+	}
+	item.EvidenceQuotes = quotes
+
+	typ := strings.ToLower(strings.TrimSpace(item.Type))
+	switch {
+	case typ == "match" || typ == "new":
+	case item.ExistingOpportunityID != "":
+		v.repaired("type", "unknown type %q, treated as match", item.Type)
+		typ = "match"
+	case item.Struggle != "":
+		v.repaired("type", "unknown type %q, treated as new", item.Type)
+		typ = "new"
+	default:
+		return item, v.dropped("type", "unknown type %q and nothing to infer it from", item.Type)
+	}
+	item.Type = typ
+
+	if item.Type == "match" {
+		if known[strings.ToLower(item.ExistingOpportunityID)] {
+			// The evidence goes to an existing opportunity, which keeps its place.
+			item.ParentOpportunityID = ""
+			return item, true
+		}
+		if item.Struggle == "" {
+			return item, v.dropped("existing_opportunity_id",
+				"match references unknown opportunity %q and has no struggle to create it from", item.ExistingOpportunityID)
+		}
+		v.repaired("existing_opportunity_id",
+			"match references unknown opportunity %q, created as new", item.ExistingOpportunityID)
+		item.Type = "new"
+		item.ExistingOpportunityID = ""
+	}
+
+	return v.checkNew(item, known)
+}
+
+func (v *validator) checkNew(item models.ExtractedOpportunity, known map[string]bool) (models.ExtractedOpportunity, bool) {
+	if item.Struggle == "" {
+		return item, v.dropped("struggle", "new opportunity is missing a struggle")
+	}
+
+	if words := strings.Fields(item.Struggle); len(words) > maxStruggleWords {
+		v.repaired("struggle", "struggle has %d words (max %d), truncated", len(words), maxStruggleWords)
+		item.Struggle = strings.Join(words[:maxStruggleWords], " ")
+	}
+
+	if strings.TrimSpace(item.UserSegment) == "" {
+		v.repaired("user_segment", "user segment is missing, set to \"Unspecified\"")
+		item.UserSegment = "Unspecified"
+	}
+
+	if words := strings.Fields(item.Theme); len(words) > maxThemeWords {
+		v.repaired("theme", "theme has %d words (max %d), truncated", len(words), maxThemeWords)
+		item.Theme = strings.Join(words[:maxThemeWords], " ")
+	}
+
+	if item.ParentOpportunityID != "" && !known[strings.ToLower(item.ParentOpportunityID)] {
+		v.repaired("parent_opportunity_id", "parent %q is not an existing opportunity, created at the top level", item.ParentOpportunityID)
+		item.ParentOpportunityID = ""
+	}
+
+	item.ExistingOpportunityID = ""
+	return item, true
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pedy4000/noker/internal/slack"
+)
+
+// commandReply is what a bot command answers, before it's rendered as plain
+// text (GET /api/cmd) or as Block Kit (the slash command, ?format=blocks).
+// Text uses Slack's mrkdwn, which reads fine as plain text too.
+type commandReply struct {
+	Title  string
+	Text   string
+	Items  []replyItem
+	Footer string // hints, shown last in small print
+}
+
+type replyItem struct {
+	Title string
+	Body  string
+	Meta  []string // theme, counts, IDs
+}
+
+// maxReplyItems keeps a reply under slack.MaxBlocks: two blocks per item.
+const maxReplyItems = 20
+
+// PlainText renders the reply as Slack-flavoured text.
+func (r commandReply) PlainText() string {
+	var b strings.Builder
+	b.WriteString(r.Title)
+	if r.Text != "" {
+		b.WriteString("\n\n" + r.Text)
+	}
+	if len(r.Items) > 0 {
+		b.WriteString("\n")
+	}
+	for _, item := range r.Items {
+		fmt.Fprintf(&b, "\n• *%s*\n", item.Title)
+		if item.Body != "" {
+			b.WriteString("  " + strings.ReplaceAll(item.Body, "\n", "\n  ") + "\n")
+		}
+		if len(item.Meta) > 0 {
+			b.WriteString("  " + strings.Join(item.Meta, " · ") + "\n")
+		}
+	}
+	if r.Footer != "" {
+		b.WriteString("\n" + r.Footer)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// Blocks renders the reply as Block Kit: a section per item, with its meta as context.
+func (r commandReply) Blocks() []slack.Block {
+	head := "*" + r.Title + "*"
+	if r.Text != "" {
+		head += "\n" + r.Text
+	}
+	blocks := []slack.Block{slack.Section(head)}
+
+	for i, item := range r.Items {
+		if i == maxReplyItems {
+			blocks = append(blocks, slack.Context(fmt.Sprintf("…and %d more", len(r.Items)-i)))
+			break
+		}
+		text := "*" + item.Title + "*"
+		if item.Body != "" {
+			text += "\n" + item.Body
+		}
+		blocks = append(blocks, slack.Section(text))
+		if len(item.Meta) > 0 {
+			blocks = append(blocks, slack.Context(item.Meta...))
+		}
+	}
+
+	if r.Footer != "" {
+		blocks = append(blocks, slack.Divider(), slack.Context(r.Footer))
+	}
+	return blocks
+}
+
+// Slack is the reply as an ephemeral slash command answer.
+func (r commandReply) Slack() slack.Message {
+	return slack.Ephemeral(r.Title, r.Blocks()...)
+}
@@ -0,0 +1,623 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/internal/service"
+	"github.com/pedy4000/noker/pkg/logger"
+	"github.com/pedy4000/noker/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// botCommand is one command of the bot behind GET /api/cmd and the /ost slash
+// command. Commands read "<name> <args...>"; args are split on spaces, and
+// quotes keep one together: theme "export issues" 5.
+type botCommand struct {
+	name     string
+	aliases  []string
+	usage    string   // arguments, e.g. "<name> [limit]"
+	summary  string   // one line in the command list
+	help     string   // shown by "help <name>" and when the arguments don't fit
+	examples []string // arguments only
+	// run answers the command; nil for commands handled elsewhere (add).
+	run func(h *Handler, ctx context.Context, args []string) (commandReply, error)
+	// mutates is set for commands that change data; GET /api/cmd refuses them.
+	mutates bool
+}
+
+// usageError means the arguments didn't fit; the reply adds the command's help.
+type usageError string
+
+func (e usageError) Error() string { return string(e) }
+
+var botCommands = []botCommand{
+	{
+		name:    "add",
+		usage:   "<notes>",
+		summary: "Extract opportunities from meeting notes (Slack only)",
+		help: "Saves the notes as a meeting and queues it for extraction, like `POST /api/meetings`. " +
+			"The first line becomes the title. What's found is posted to the channel once the worker is done.",
+		examples: []string{"Call with Acme\nCSV export fails, dates are wrong."},
+	},
+	{
+		name:     "search",
+		usage:    "<query>",
+		summary:  "Find opportunities and quotes",
+		help:     "Full-text search over opportunities and their evidence, best matches first.",
+		examples: []string{"csv export", `"dates are wrong"`},
+		run:      (*Handler).cmdSearch,
+	},
+	{
+		name:     "theme",
+		usage:    "<name> [limit]",
+		summary:  "Top opportunities in a theme",
+		help:     "Lists a theme's opportunities, most evidence first. Limit is 1-50, 10 by default.",
+		examples: []string{"export-issues", "export-issues 3"},
+		run:      (*Handler).cmdTheme,
+	},
+	{
+		name:     "opp",
+		usage:    "<opportunity-id>",
+		summary:  "One opportunity with its evidence",
+		help:     "Shows an opportunity, its theme and its latest quotes. Merged opportunities show the one they were merged into.",
+		examples: []string{"0b7f8f5e-8f2a-4a55-9a49-6f7f0d1c2b3a"},
+		run:      (*Handler).cmdOpportunity,
+	},
+	{
+		name:     "status",
+		usage:    "<meeting-id>",
+		summary:  "Where a meeting's extraction is at",
+		help:     "Shows a meeting's processing status, attempts and last error, and how many opportunities it contributed to.",
+		examples: []string{"5f0c2a8e-1d7b-4c39-9e57-0a4b6f1e2d3c"},
+		run:      (*Handler).cmdStatus,
+	},
+	{
+		name:    "top",
+		usage:   "<n> [since <date>]",
+		summary: "Opportunities with the most new evidence",
+		help: "Ranks opportunities by the evidence they gathered since a date: `2025-06-01`, `7d` or `2w` ago. " +
+			"N is 1-50; the last 7 days by default.",
+		examples: []string{"5", "10 since 2025-06-01", "3 since 2w"},
+		run:      (*Handler).cmdTop,
+	},
+	{
+		name:     "reprocess",
+		usage:    "<meeting-id>",
+		summary:  "Run a meeting's extraction again",
+		help:     "Rolls back everything the meeting contributed and queues it again, like `POST /api/meetings/{id}/reprocess`.",
+		examples: []string{"5f0c2a8e-1d7b-4c39-9e57-0a4b6f1e2d3c"},
+		run:      (*Handler).cmdReprocess,
+		mutates:  true,
+	},
+	{
+		name:    "new",
+		aliases: []string{"show_new_opportunities"},
+		summary: "Latest customer pains",
+		help:    "Opportunities created in the last 24 hours. Also `show_new_opportunities`.",
+		run:     (*Handler).cmdNewOpportunities,
+	},
+	{
+		name:    "themes",
+		aliases: []string{"themes_this_week"},
+		summary: "Trending opportunity themes",
+		help:    "This week's themes with the most opportunities, recent ones counting double. Also `themes_this_week`.",
+		run:     (*Handler).cmdThemesThisWeek,
+	},
+	{
+		name:     "help",
+		usage:    "[command]",
+		summary:  "This message, or how to use a command",
+		examples: []string{"theme"},
+	},
+}
+
+func findCommand(name string) (botCommand, bool) {
+	name = strings.ToLower(name)
+	for _, cmd := range botCommands {
+		if cmd.name == name {
+			return cmd, true
+		}
+		for _, alias := range cmd.aliases {
+			if alias == name {
+				return cmd, true
+			}
+		}
+	}
+	return botCommand{}, false
+}
+
+// runCommand answers a command line. prefix is how the user invokes the bot,
+// "/ost " in Slack and nothing for /api/cmd, and is used in help texts.
+// readOnly refuses commands that change data, for requests that must be safe to repeat.
+func (h *Handler) runCommand(ctx context.Context, prefix, text string, readOnly bool) commandReply {
+	args := splitCommandLine(text)
+	if len(args) == 0 {
+		return commandList(prefix, "")
+	}
+	name, args := strings.ToLower(args[0]), args[1:]
+
+	if name == "help" {
+		if len(args) > 0 {
+			if cmd, ok := findCommand(args[0]); ok {
+				return commandHelp(prefix, cmd)
+			}
+			return commandList(prefix, fmt.Sprintf("Unknown command `%s`.", args[0]))
+		}
+		return commandList(prefix, "")
+	}
+
+	cmd, ok := findCommand(name)
+	if !ok {
+		return commandList(prefix, fmt.Sprintf("Unknown command `%s`.", name))
+	}
+	if len(args) == 1 && strings.EqualFold(args[0], "help") {
+		return commandHelp(prefix, cmd)
+	}
+	if cmd.run == nil {
+		return usageReply(prefix, cmd, "`"+cmd.name+"` only works from the Slack slash command.")
+	}
+	if cmd.mutates && readOnly {
+		return usageReply(prefix, cmd, "`"+cmd.name+"` changes data, send it with `POST /api/cmd`.")
+	}
+
+	reply, err := cmd.run(h, ctx, args)
+	if err != nil {
+		var usage usageError
+		if errors.As(err, &usage) {
+			return usageReply(prefix, cmd, string(usage))
+		}
+		logger.Error("Bot command", cmd.name+":", err)
+		return commandReply{Title: "Something went wrong running `" + cmd.name + "`, please try again."}
+	}
+	return reply
+}
+
+func commandList(prefix, problem string) commandReply {
+	lines := make([]string, len(botCommands))
+	for i, cmd := range botCommands {
+		lines[i] = fmt.Sprintf("• `%s` → %s", strings.TrimSpace(prefix+cmd.name+" "+cmd.usage), cmd.summary)
+	}
+
+	title := "Noker commands"
+	if problem != "" {
+		title = problem + " " + title + ":"
+	}
+	return commandReply{
+		Title:  title,
+		Text:   strings.Join(lines, "\n"),
+		Footer: fmt.Sprintf("`%shelp <command>` explains one of them, e.g. `%shelp top`", prefix, prefix),
+	}
+}
+
+func commandHelp(prefix string, cmd botCommand) commandReply {
+	reply := commandReply{
+		Title: "`" + strings.TrimSpace(prefix+cmd.name+" "+cmd.usage) + "`",
+		Text:  cmd.summary + ".",
+	}
+	if cmd.help != "" {
+		reply.Text += " " + cmd.help
+	}
+	if len(cmd.examples) > 0 {
+		examples := make([]string, len(cmd.examples))
+		for i, example := range cmd.examples {
+			examples[i] = "`" + prefix + cmd.name + " " + strings.ReplaceAll(example, "\n", " ") + "`"
+		}
+		reply.Footer = "Examples: " + strings.Join(examples, ", ")
+	}
+	return reply
+}
+
+func usageReply(prefix string, cmd botCommand, problem string) commandReply {
+	reply := commandHelp(prefix, cmd)
+	reply.Text = "Usage: " + reply.Title + "\n" + reply.Text
+	reply.Title = problem
+	return reply
+}
+
+// splitCommandLine splits on whitespace, keeping quoted parts together. Slack's
+// curly quotes count as quotes.
+func splitCommandLine(text string) []string {
+	var args []string
+	var current strings.Builder
+	inQuotes, quoted := false, false
+
+	flush := func() {
+		if current.Len() > 0 || quoted {
+			args = append(args, current.String())
+		}
+		current.Reset()
+		quoted = false
+	}
+
+	for _, r := range text {
+		switch {
+		case r == '"' || r == '“' || r == '”':
+			inQuotes = !inQuotes
+			quoted = true
+		case unicode.IsSpace(r) && !inQuotes:
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+	return args
+}
+
+// cutCommand splits off the command name, keeping the rest as typed (newlines included).
+func cutCommand(text string) (name, rest string) {
+	text = strings.TrimSpace(text)
+	i := strings.IndexFunc(text, unicode.IsSpace)
+	if i < 0 {
+		return text, ""
+	}
+	return text[:i], strings.TrimSpace(text[i:])
+}
+
+func parseCommandID(arg, what string) (uuid.UUID, error) {
+	id, err := uuid.Parse(arg)
+	if err != nil {
+		return uuid.Nil, usageError(fmt.Sprintf("`%s` is not a valid %s ID.", arg, what))
+	}
+	return id, nil
+}
+
+// parseSince reads a date (2025-06-01) or a time ago (7d, 2w).
+func parseSince(arg string, now time.Time) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", arg, now.Location()); err == nil {
+		return t, nil
+	}
+	if len(arg) > 1 {
+		n, err := strconv.Atoi(arg[:len(arg)-1])
+		if err == nil && n >= 0 {
+			switch arg[len(arg)-1] {
+			case 'd':
+				return now.AddDate(0, 0, -n), nil
+			case 'w':
+				return now.AddDate(0, 0, -7*n), nil
+			}
+		}
+	}
+	return time.Time{}, usageError(fmt.Sprintf("`%s` is not a date. Use 2025-06-01, 7d or 2w.", arg))
+}
+
+func (h *Handler) cmdSearch(ctx context.Context, args []string) (commandReply, error) {
+	query := strings.Join(args, " ")
+	if query == "" {
+		return commandReply{}, usageError("What should I search for?")
+	}
+
+	rows, err := h.q.SearchOpportunities(ctx, repository.SearchOpportunitiesParams{
+		Query:            query,
+		Language:         "simple",
+		MaxOpportunities: 5,
+	})
+	if err != nil {
+		return commandReply{}, err
+	}
+	if len(rows) == 0 {
+		return commandReply{Title: fmt.Sprintf("Nothing matches \"%s\".", query)}, nil
+	}
+
+	// Rows come ordered by score, with each opportunity's quotes together.
+	var items []replyItem
+	var last uuid.UUID
+	quotes := 0
+	for _, row := range rows {
+		if len(items) == 0 || row.OpportunityID != last {
+			items = append(items, replyItem{
+				Title: row.Struggle,
+				Meta:  oppMeta(row.OpportunityID, row.UserSegment, row.ThemeName.String),
+			})
+			last, quotes = row.OpportunityID, 0
+		}
+		if row.Quote.Valid && quotes < 2 {
+			item := &items[len(items)-1]
+			item.Body = strings.TrimPrefix(item.Body+"\n> "+row.Quote.String, "\n")
+			quotes++
+		}
+	}
+
+	return commandReply{
+		Title:  fmt.Sprintf("%d opportunities match \"%s\"", len(items), query),
+		Items:  items,
+		Footer: "`opp <id>` shows one of them",
+	}, nil
+}
+
+func (h *Handler) cmdTheme(ctx context.Context, args []string) (commandReply, error) {
+	if len(args) == 0 {
+		return commandReply{}, usageError("Which theme?")
+	}
+
+	limit := 10
+	if len(args) > 1 {
+		if n, err := strconv.Atoi(args[len(args)-1]); err == nil {
+			if n < 1 || n > 50 {
+				return commandReply{}, usageError("The limit must be between 1 and 50.")
+			}
+			limit, args = n, args[:len(args)-1]
+		}
+	}
+	theme := strings.Join(args, " ")
+
+	opps, err := h.q.ListTopOpportunitiesByTheme(ctx, repository.ListTopOpportunitiesByThemeParams{
+		Lower: theme,
+		Limit: int32(limit),
+	})
+	if err != nil {
+		return commandReply{}, err
+	}
+	if len(opps) == 0 {
+		return commandReply{
+			Title:  fmt.Sprintf("No opportunities in theme \"%s\".", theme),
+			Footer: "`themes` lists this week's themes",
+		}, nil
+	}
+
+	items := make([]replyItem, len(opps))
+	for i, op := range opps {
+		items[i] = replyItem{
+			Title: op.UserSegment,
+			Body:  op.Struggle,
+			Meta:  []string{plural(int(op.EvidenceCount), "quote"), "`" + op.ID.String() + "`"},
+		}
+	}
+	return commandReply{
+		Title: fmt.Sprintf("Top opportunities in %s", theme),
+		Items: items,
+	}, nil
+}
+
+func (h *Handler) cmdOpportunity(ctx context.Context, args []string) (commandReply, error) {
+	if len(args) != 1 {
+		return commandReply{}, usageError("Which opportunity?")
+	}
+	id, err := parseCommandID(args[0], "opportunity")
+	if err != nil {
+		return commandReply{}, err
+	}
+
+	// Opportunities merged into another one answer with the one they were merged into.
+	if id, err = h.opps.ResolveOpportunityID(ctx, id); err != nil {
+		return commandReply{}, err
+	}
+	opp, err := h.q.GetOpportunity(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return commandReply{Title: "Opportunity not found."}, nil
+	}
+	if err != nil {
+		return commandReply{}, err
+	}
+
+	evidence, err := h.q.ListEvidenceByOpportunity(ctx, id)
+	if err != nil {
+		return commandReply{}, err
+	}
+
+	lines := []string{"*Segment:* " + opp.UserSegment}
+	if opp.WhyItMatters.String != "" {
+		lines = append(lines, "*Why it matters:* "+opp.WhyItMatters.String)
+	}
+	if opp.Workaround.String != "" {
+		lines = append(lines, "*Workaround:* "+opp.Workaround.String)
+	}
+	for i, ev := range evidence {
+		if i == 3 {
+			lines = append(lines, fmt.Sprintf("…and %s", plural(len(evidence)-i, "more quote")))
+			break
+		}
+		lines = append(lines, "> "+ev.Quote)
+	}
+
+	footer := oppMeta(opp.ID, "", opp.ThemeName.String)
+	footer = append(footer, plural(int(opp.EvidenceCount), "quote"), "created "+utils.FormatTime(opp.CreatedAt, "never"))
+	return commandReply{
+		Title:  opp.Struggle,
+		Text:   strings.Join(lines, "\n"),
+		Footer: strings.Join(footer, " · "),
+	}, nil
+}
+
+func (h *Handler) cmdStatus(ctx context.Context, args []string) (commandReply, error) {
+	if len(args) != 1 {
+		return commandReply{}, usageError("Which meeting?")
+	}
+	id, err := parseCommandID(args[0], "meeting")
+	if err != nil {
+		return commandReply{}, err
+	}
+
+	meeting, err := h.q.GetMeeting(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return commandReply{Title: "Meeting not found."}, nil
+	}
+	if err != nil {
+		return commandReply{}, err
+	}
+
+	evidence, err := h.q.ListMeetingEvidence(ctx, id)
+	if err != nil {
+		return commandReply{}, err
+	}
+	opps := map[uuid.UUID]bool{}
+	for _, ev := range evidence {
+		opps[ev.OpportunityID] = true
+	}
+
+	lines := []string{
+		fmt.Sprintf("*Found:* %s, %s", plural(len(opps), "opportunity"), plural(len(evidence), "quote")),
+		fmt.Sprintf("*Attempts:* %d", meeting.ProcessingAttempts),
+	}
+	if meeting.ProcessingError.Valid {
+		lines = append(lines, "*Last error:* "+meeting.ProcessingError.String)
+	}
+	if meeting.NextAttemptAt.Valid && meeting.ProcessingStatus == "retrying" {
+		lines = append(lines, "*Next attempt:* "+utils.FormatTime(meeting.NextAttemptAt, ""))
+	}
+	if meeting.ProcessedAt.Valid {
+		lines = append(lines, "*Processed:* "+utils.FormatTime(meeting.ProcessedAt, ""))
+	}
+
+	footer := "Meeting `" + meeting.ID.String() + "`"
+	if meeting.ProcessingStatus == "failed" || meeting.ProcessingStatus == "dead" {
+		footer += " · `reprocess <meeting-id>` tries again"
+	}
+	return commandReply{
+		Title:  fmt.Sprintf("%s: %s", meeting.Title, meeting.ProcessingStatus),
+		Text:   strings.Join(lines, "\n"),
+		Footer: footer,
+	}, nil
+}
+
+func (h *Handler) cmdTop(ctx context.Context, args []string) (commandReply, error) {
+	if len(args) == 0 {
+		return commandReply{}, usageError("How many?")
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 || n > 50 {
+		return commandReply{}, usageError("N must be a number between 1 and 50.")
+	}
+
+	since := time.Now().AddDate(0, 0, -7)
+	switch {
+	case len(args) == 1:
+	case len(args) == 3 && strings.EqualFold(args[1], "since"):
+		if since, err = parseSince(args[2], time.Now()); err != nil {
+			return commandReply{}, err
+		}
+	default:
+		return commandReply{}, usageError("Expected `since <date>` after the number.")
+	}
+
+	rows, err := h.q.ListTopOpportunitiesSince(ctx, repository.ListTopOpportunitiesSinceParams{
+		Since:   since,
+		MaxRows: int32(n),
+	})
+	if err != nil {
+		return commandReply{}, err
+	}
+
+	day := since.Format("Jan 2, 2006")
+	if len(rows) == 0 {
+		return commandReply{Title: "No new evidence since " + day + "."}, nil
+	}
+
+	items := make([]replyItem, len(rows))
+	for i, row := range rows {
+		meta := []string{plural(int(row.EvidenceCount), "quote") + " from " + plural(int(row.MeetingCount), "meeting")}
+		items[i] = replyItem{
+			Title: row.Struggle,
+			Meta:  append(meta, oppMeta(row.ID, row.UserSegment, row.ThemeName.String)...),
+		}
+	}
+	return commandReply{
+		Title: fmt.Sprintf("Top %d opportunities since %s", len(rows), day),
+		Items: items,
+	}, nil
+}
+
+func (h *Handler) cmdReprocess(ctx context.Context, args []string) (commandReply, error) {
+	if len(args) != 1 {
+		return commandReply{}, usageError("Which meeting?")
+	}
+	id, err := parseCommandID(args[0], "meeting")
+	if err != nil {
+		return commandReply{}, err
+	}
+
+	result, err := h.opps.ResetMeeting(ctx, id, h.enqueueInTx())
+	switch {
+	case errors.Is(err, service.ErrMeetingNotFound):
+		return commandReply{Title: "Meeting not found."}, nil
+	case errors.Is(err, service.ErrMeetingBusy):
+		return commandReply{Title: "The meeting is being processed, try again later."}, nil
+	case err != nil:
+		return commandReply{}, err
+	}
+
+	h.enqueueAfterCommit(result.MeetingIDs)
+
+	reply := commandReply{
+		Title:  "Meeting queued for extraction again.",
+		Footer: "`status " + id.String() + "` follows it",
+	}
+	if result.DeletedOpportunities > 0 {
+		reply.Text = fmt.Sprintf("Removed %s only this meeting had found.", plural(result.DeletedOpportunities, "opportunity"))
+	}
+	return reply, nil
+}
+
+func (h *Handler) cmdNewOpportunities(ctx context.Context, args []string) (commandReply, error) {
+	ops, err := h.q.ListRecentOpportunities(ctx)
+	if err != nil {
+		return commandReply{}, err
+	}
+	if len(ops) == 0 {
+		return commandReply{Title: "No new opportunities in the last 24 hours. Keep discovering!"}, nil
+	}
+
+	items := make([]replyItem, len(ops))
+	for i, op := range ops {
+		items[i] = replyItem{
+			Title: op.UserSegment,
+			Body:  op.Struggle,
+			Meta:  oppMeta(op.ID, "", op.ThemeName.String),
+		}
+	}
+	return commandReply{
+		Title: fmt.Sprintf("Found %d new opportunities in the last 24h", len(ops)),
+		Items: items,
+	}, nil
+}
+
+func (h *Handler) cmdThemesThisWeek(ctx context.Context, args []string) (commandReply, error) {
+	themes, err := h.q.ListTopThemesThisWeek(ctx)
+	if err != nil {
+		return commandReply{}, err
+	}
+	if len(themes) == 0 {
+		return commandReply{Title: "No opportunities this week yet. Keep discovering!"}, nil
+	}
+
+	lines := make([]string, len(themes))
+	for i, t := range themes {
+		lines[i] = fmt.Sprintf("%d. *%s* — %s", i+1, t.ThemeName, plural(int(t.OpportunityCount), "opportunity"))
+	}
+	return commandReply{
+		Title:  "Top opportunity themes this week",
+		Text:   strings.Join(lines, "\n"),
+		Footer: "`theme <name>` shows a theme's opportunities, `new` the latest pains",
+	}, nil
+}
+
+// oppMeta is the small print under an opportunity; empty parts are left out.
+func oppMeta(id uuid.UUID, segment, theme string) []string {
+	var meta []string
+	if segment != "" {
+		meta = append(meta, segment)
+	}
+	if theme != "" {
+		meta = append(meta, "Theme: "+theme)
+	}
+	return append(meta, "`"+id.String()+"`")
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	if strings.HasSuffix(noun, "y") {
+		return fmt.Sprintf("%d %sies", n, strings.TrimSuffix(noun, "y"))
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,236 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/pedy4000/noker/internal/api/response"
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/internal/service"
+	"github.com/pedy4000/noker/pkg/logger"
+	"github.com/pedy4000/noker/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// POST /api/opportunities/{id}/merge
+func (h *Handler) MergeOpportunities(w http.ResponseWriter, r *http.Request, idStr string) {
+	input := r.Context().Value("Body").(MergeOpportunitiesRequest)
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(w, "Invalid opportunity ID", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.opps.MergeOpportunities(r.Context(), id, input.SourceIDs, input.Actor)
+	if err != nil {
+		curationError(w, "MergeOpportunities:", err)
+		return
+	}
+
+	resp := MergeOpportunitiesResponse{
+		OpportunityID:     result.TargetID,
+		MergedIDs:         result.MergedIDs,
+		MovedEvidence:     result.MovedEvidence,
+		DroppedDuplicates: result.DroppedDuplicates,
+	}
+	response.JSON(w, http.StatusOK, resp)
+}
+
+// POST /api/opportunities/{id}/split
+func (h *Handler) SplitOpportunity(w http.ResponseWriter, r *http.Request, idStr string) {
+	input := r.Context().Value("Body").(SplitOpportunityRequest)
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(w, "Invalid opportunity ID", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.opps.SplitOpportunity(r.Context(), id, service.SplitRequest{
+		EvidenceIDs:  input.EvidenceIDs,
+		UserSegment:  input.UserSegment,
+		Struggle:     input.Struggle,
+		WhyItMatters: input.WhyItMatters,
+		Workaround:   input.Workaround,
+		Theme:        input.Theme,
+	}, input.Actor)
+	if err != nil {
+		curationError(w, "SplitOpportunity:", err)
+		return
+	}
+
+	resp := SplitOpportunityResponse{
+		SourceID:      result.SourceID,
+		OpportunityID: result.OpportunityID,
+		MovedEvidence: result.MovedEvidence,
+	}
+	response.JSON(w, http.StatusCreated, resp)
+}
+
+// GET /api/opportunities/{id}/audit
+func (h *Handler) OpportunityAudit(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(w, "Invalid opportunity ID", http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.q.ListAuditEntries(r.Context(), repository.ListAuditEntriesParams{
+		EntityType: "opportunity",
+		EntityID:   id,
+	})
+	if err != nil {
+		logger.Error("ListAuditEntries:", err)
+		response.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	result := make([]AuditEntryResponse, len(entries))
+	for i, e := range entries {
+		result[i] = AuditEntryResponse{
+			ID:      e.ID,
+			Action:  e.Action,
+			Actor:   e.Actor.String,
+			Details: e.Details,
+			Created: e.CreatedAt.Format(time.RFC3339),
+		}
+	}
+
+	response.JSON(w, http.StatusOK, result)
+}
+
+// PATCH /api/opportunities/{id}
+func (h *Handler) UpdateOpportunity(w http.ResponseWriter, r *http.Request, idStr string) {
+	input := r.Context().Value("Body").(UpdateOpportunityRequest)
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(w, "Invalid opportunity ID", http.StatusBadRequest)
+		return
+	}
+
+	upd := service.OpportunityUpdate{
+		UserSegment:  input.UserSegment,
+		Struggle:     input.Struggle,
+		WhyItMatters: input.WhyItMatters,
+		Workaround:   input.Workaround,
+		Theme:        input.Theme,
+		Impact:       input.Impact,
+		Confidence:   input.Confidence,
+		Effort:       input.Effort,
+		OutcomeID:    input.OutcomeID,
+		ParentID:     input.ParentID,
+	}
+	if upd == (service.OpportunityUpdate{}) {
+		response.Error(w, "Nothing to update", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.opps.UpdateOpportunity(r.Context(), id, upd, input.Actor); err != nil {
+		curationError(w, "UpdateOpportunity:", err)
+		return
+	}
+
+	h.GetOpportunity(w, r, id.String())
+}
+
+// DELETE /api/opportunities/{id}?actor=
+func (h *Handler) DeleteOpportunity(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(w, "Invalid opportunity ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.opps.DeleteOpportunity(r.Context(), id, r.URL.Query().Get("actor")); err != nil {
+		curationError(w, "DeleteOpportunity:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DELETE /api/evidence/{id}?actor=
+func (h *Handler) DeleteEvidence(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(w, "Invalid evidence ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.opps.DeleteEvidence(r.Context(), id, r.URL.Query().Get("actor")); err != nil {
+		if errors.Is(err, service.ErrEvidenceNotFound) {
+			response.Error(w, "Evidence not found", http.StatusNotFound)
+			return
+		}
+		curationError(w, "DeleteEvidence:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PATCH /api/themes/{theme}
+func (h *Handler) UpdateTheme(w http.ResponseWriter, r *http.Request, theme string) {
+	input := r.Context().Value("Body").(UpdateThemeRequest)
+
+	if err := h.opps.RenameTheme(r.Context(), theme, input.Name, input.Actor); err != nil {
+		curationError(w, "RenameTheme:", err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]string{"theme_name": utils.FormatTheme(input.Name)})
+}
+
+// POST /api/themes/{theme}/merge
+func (h *Handler) MergeThemes(w http.ResponseWriter, r *http.Request, theme string) {
+	input := r.Context().Value("Body").(MergeThemesRequest)
+
+	moved, err := h.opps.MergeThemes(r.Context(), theme, input.Into, input.Actor)
+	if err != nil {
+		curationError(w, "MergeThemes:", err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, MergeThemesResponse{
+		Theme:              utils.FormatTheme(input.Into),
+		MovedOpportunities: moved,
+	})
+}
+
+// DELETE /api/themes/{theme}?actor=
+func (h *Handler) DeleteTheme(w http.ResponseWriter, r *http.Request, theme string) {
+	if err := h.opps.DeleteTheme(r.Context(), theme, r.URL.Query().Get("actor")); err != nil {
+		curationError(w, "DeleteTheme:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func curationError(w http.ResponseWriter, op string, err error) {
+	var merged *service.MergedError
+	switch {
+	case errors.As(err, &merged):
+		response.JSON(w, http.StatusConflict, map[string]string{
+			"error":       merged.Error(),
+			"merged_into": merged.Into.String(),
+		})
+	case errors.Is(err, service.ErrOpportunityNotFound), errors.Is(err, service.ErrThemeNotFound),
+		errors.Is(err, service.ErrOutcomeNotFound), errors.Is(err, service.ErrSolutionNotFound),
+		errors.Is(err, service.ErrExperimentNotFound):
+		response.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrThemeExists):
+		response.Error(w, err.Error()+", merge the themes instead", http.StatusConflict)
+	case errors.Is(err, service.ErrHierarchyCycle):
+		response.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrEvidenceNotFound), errors.Is(err, service.ErrInvalidCuration):
+		response.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		logger.Error(op, err)
+		response.Error(w, "Database error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pedy4000/noker/internal/api/response"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+var (
+	errInvalidCursor  = errors.New("invalid cursor")
+	errCursorMismatch = errors.New("cursor belongs to a different sort or filter")
+)
+
+// cursor marks where the previous page ended: the sort key of its last row, and
+// that row's ID to break ties. It also remembers the sort and a hash of the filters
+// it was issued for, since its position means nothing in another listing. Clients
+// treat it as an opaque string.
+type cursor struct {
+	Sort   string    `json:"s,omitempty"`
+	Filter string    `json:"f,omitempty"`
+	Time   time.Time `json:"t,omitzero"`
+	Count  int64     `json:"n,omitempty"`
+	ID     uuid.UUID `json:"id"`
+}
+
+func (c cursor) String() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// parseCursor decodes s and checks it was issued for the same sort and filter.
+func parseCursor(s, sort, filter string) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, errInvalidCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == uuid.Nil {
+		return c, errInvalidCursor
+	}
+	if c.Sort != sort || c.Filter != filter {
+		return c, errCursorMismatch
+	}
+	return c, nil
+}
+
+// filterHash condenses the filter values of a listing into a short, stable string.
+func filterHash(values ...string) string {
+	h := sha256.New()
+	for _, v := range values {
+		h.Write([]byte(v))
+		h.Write([]byte{0})
+	}
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil)[:9])
+}
+
+func cursorError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errCursorMismatch) {
+		response.Error(w, "Cursor belongs to a different sort or filter, start again without it", http.StatusBadRequest)
+		return
+	}
+	response.Error(w, "Invalid cursor", http.StatusBadRequest)
+}
+
+// pageSize reads ?limit=, falling back to the default for missing or out-of-range values.
+func pageSize(limit string) int {
+	if n, err := strconv.Atoi(limit); err == nil && n > 0 && n <= maxPageSize {
+		return n
+	}
+	return defaultPageSize
+}
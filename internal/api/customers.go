@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/pedy4000/noker/internal/api/response"
+	"github.com/pedy4000/noker/pkg/logger"
+	"github.com/pedy4000/noker/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// GET /api/customers
+func (h *Handler) ListCustomers(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.q.ListCustomers(r.Context())
+	if err != nil {
+		logger.Error("ListCustomers:", err)
+		response.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	result := make([]CustomerResponse, len(rows))
+	for i, c := range rows {
+		result[i] = CustomerResponse{
+			ID:           c.ID,
+			Name:         c.Name,
+			Plan:         c.Plan.String,
+			ARR:          utils.NullFloat64ToPtr(c.Arr),
+			MeetingCount: int(c.MeetingCount),
+		}
+	}
+
+	response.JSON(w, http.StatusOK, result)
+}
+
+// GET /api/customers/{id}/opportunities
+func (h *Handler) CustomerOpportunities(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(w, "Invalid customer ID", http.StatusBadRequest)
+		return
+	}
+
+	customer, err := h.q.GetCustomer(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			response.Error(w, "Customer not found", http.StatusNotFound)
+		} else {
+			response.Error(w, "Database error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	rows, err := h.q.ListCustomerOpportunities(r.Context(), id)
+	if err != nil {
+		logger.Error("ListCustomerOpportunities:", err)
+		response.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := CustomerOpportunitiesResponse{
+		Customer: CustomerResponse{
+			ID:   customer.ID,
+			Name: customer.Name,
+			Plan: customer.Plan.String,
+			ARR:  utils.NullFloat64ToPtr(customer.Arr),
+		},
+		Opportunities: make([]OpportunityResponse, len(rows)),
+	}
+	for i, op := range rows {
+		resp.Opportunities[i] = OpportunityResponse{
+			ID:            op.ID,
+			UserSegment:   op.UserSegment,
+			Struggle:      op.Struggle,
+			Why:           op.WhyItMatters.String,
+			Workaround:    op.Workaround.String,
+			Theme:         op.ThemeName.String,
+			EvidenceCount: int(op.EvidenceCount), // from this customer only
+			Created:       utils.FormatTime(op.CreatedAt, "never"),
+		}
+	}
+
+	response.JSON(w, http.StatusOK, resp)
+}
+
+// customersByOpportunity summarizes the customers behind each of the given opportunities
+// in a single query. Opportunities without known customers have no entry.
+func (h *Handler) customersByOpportunity(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*CustomerSummary, error) {
+	result := make(map[uuid.UUID]*CustomerSummary, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	rows, err := h.q.ListCustomersForOpportunities(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range rows {
+		summary := result[c.OpportunityID]
+		if summary == nil {
+			summary = &CustomerSummary{Plans: map[string]int{}}
+			result[c.OpportunityID] = summary
+		}
+
+		summary.Count++
+		summary.ARR += c.Arr.Float64
+		if c.Plan.Valid {
+			summary.Plans[c.Plan.String]++
+		}
+		summary.Customers = append(summary.Customers, CustomerRef{ID: c.ID, Name: c.Name})
+	}
+	return result, nil
+}
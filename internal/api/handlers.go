@@ -1,18 +1,20 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
-	"strings"
 
 	"github.com/pedy4000/noker/internal/api/response"
 	"github.com/pedy4000/noker/internal/models"
 	"github.com/pedy4000/noker/internal/queue"
 	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/internal/service"
+	"github.com/pedy4000/noker/pkg/config"
 	"github.com/pedy4000/noker/pkg/logger"
 	"github.com/pedy4000/noker/pkg/utils"
 
@@ -21,12 +23,23 @@ import (
 )
 
 type Handler struct {
-	q      *repository.Queries
-	worker queue.Processor
+	q            *repository.Store
+	worker       queue.Processor
+	opps         *service.OpportunityService
+	customerKeys service.CustomerKeys
 }
 
-func NewHandler(q *repository.Queries, worker queue.Processor) *Handler {
-	return &Handler{q: q, worker: worker}
+func NewHandler(q *repository.Store, worker queue.Processor, cfg *config.Config) *Handler {
+	return &Handler{
+		q:      q,
+		worker: worker,
+		opps:   service.NewOpportunityService(q),
+		customerKeys: service.CustomerKeys{
+			Name: cfg.Customers.NameKeys,
+			Plan: cfg.Customers.PlanKey,
+			ARR:  cfg.Customers.ARRKey,
+		},
+	}
 }
 
 // POST /api/meetings
@@ -45,23 +58,23 @@ func (h *Handler) CreateMeeting(w http.ResponseWriter, r *http.Request) {
 		// TODO: we can read from all other sources (zoom, upload, notion, etc.)
 		Notes = input.Notes
 	}
+	input.Notes = Notes
 
-	metadataJSON, err := json.Marshal(input.Metadata)
-	if err != nil {
-		response.Error(w, "Invalid metadata", http.StatusBadRequest)
+	if input.Language != "" && !searchLanguages[input.Language] {
+		response.Error(w, "Unsupported language", http.StatusBadRequest)
 		return
 	}
 
-	result, err := h.q.CreateMeeting(r.Context(), repository.CreateMeetingParams{
-		Title:    input.Title,
-		RawNotes: Notes,
-		Source:   string(input.Source),
-		Metadata: pqtype.NullRawMessage{
-			RawMessage: json.RawMessage(metadataJSON),
-			Valid:      len(metadataJSON) > 0 && string(metadataJSON) != "null",
-		},
-	})
+	result, err := h.saveMeeting(r.Context(), input, "")
 	if err != nil {
+		if errors.Is(err, errInvalidMetadata) {
+			response.Error(w, "Invalid metadata", http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, service.ErrInvalidAmount) {
+			response.Error(w, "Invalid metadata: "+h.customerKeys.ARR+" must be a non-negative amount", http.StatusBadRequest)
+			return
+		}
 		logger.Error("CreateMeeting DB error:", err)
 		response.Error(w, "Failed to save meeting", http.StatusInternalServerError)
 		return
@@ -79,6 +92,44 @@ func (h *Handler) CreateMeeting(w http.ResponseWriter, r *http.Request) {
 	response.JSON(w, http.StatusAccepted, resp)
 }
 
+var errInvalidMetadata = errors.New("invalid metadata")
+
+// saveMeeting stores a meeting and links its customer; the caller enqueues it.
+// slackResponseURL is set for meetings added with /ost add.
+func (h *Handler) saveMeeting(ctx context.Context, input CreateMeetingRequest, slackResponseURL string) (repository.CreateMeetingRow, error) {
+	var result repository.CreateMeetingRow
+
+	metadataJSON, err := json.Marshal(input.Metadata)
+	if err != nil {
+		return result, errInvalidMetadata
+	}
+	if err := service.ValidateCustomerMetadata(input.Metadata, h.customerKeys); err != nil {
+		return result, err
+	}
+
+	err = h.q.ExecTx(ctx, func(q *repository.Queries) error {
+		customerID, err := service.LinkCustomer(ctx, q, input.Metadata, h.customerKeys)
+		if err != nil {
+			return err
+		}
+
+		result, err = q.CreateMeeting(ctx, repository.CreateMeetingParams{
+			Title:    input.Title,
+			RawNotes: input.Notes,
+			Source:   string(input.Source),
+			Metadata: pqtype.NullRawMessage{
+				RawMessage: json.RawMessage(metadataJSON),
+				Valid:      len(metadataJSON) > 0 && string(metadataJSON) != "null",
+			},
+			Language:         utils.ToNullString(input.Language),
+			CustomerID:       customerID,
+			SlackResponseUrl: utils.ToNullString(slackResponseURL),
+		})
+		return err
+	})
+	return result, err
+}
+
 // GET /api/meetings/{id}/status
 func (h *Handler) MeetingStatus(w http.ResponseWriter, r *http.Request, idStr string) {
 	id, err := uuid.Parse(idStr)
@@ -98,20 +149,116 @@ func (h *Handler) MeetingStatus(w http.ResponseWriter, r *http.Request, idStr st
 	}
 
 	resp := MeetingResponse{
-		ID:        meeting.ID,
-		Title:     meeting.Title,
-		Source:    meeting.Source,
-		Metadata:  string(meeting.Metadata.RawMessage),
-		Status:    meeting.ProcessingStatus,
-		Message:   meeting.ProcessingError.String,
-		Created:   utils.FormatTime(meeting.CreatedAt, "never"),
-		Processed: utils.FormatTime(meeting.ProcessedAt, "never"),
-		Updated:   utils.FormatTime(meeting.UpdatedAt, "never"),
+		ID:               meeting.ID,
+		Title:            meeting.Title,
+		Source:           meeting.Source,
+		Metadata:         string(meeting.Metadata.RawMessage),
+		Status:           meeting.ProcessingStatus,
+		Message:          meeting.ProcessingError.String,
+		Attempts:         int(meeting.ProcessingAttempts),
+		ErrorHistory:     meeting.ErrorHistory,
+		ValidationIssues: meeting.ValidationIssues,
+		NextAttempt:      utils.FormatTime(meeting.NextAttemptAt, ""),
+		Created:          utils.FormatTime(meeting.CreatedAt, "never"),
+		Processed:        utils.FormatTime(meeting.ProcessedAt, "never"),
+		Updated:          utils.FormatTime(meeting.UpdatedAt, "never"),
 	}
 
 	response.JSON(w, http.StatusOK, resp)
 }
 
+// POST /api/meetings/{id}/reprocess
+func (h *Handler) ReprocessMeeting(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(w, "Invalid meeting ID", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.opps.ResetMeeting(r.Context(), id, h.enqueueInTx())
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrMeetingNotFound):
+			response.Error(w, "Meeting not found", http.StatusNotFound)
+		case errors.Is(err, service.ErrMeetingBusy):
+			response.Error(w, "Meeting is being processed, try again later", http.StatusConflict)
+		default:
+			logger.Error("ResetMeeting:", err)
+			response.Error(w, "Database error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.enqueueReprocessed(w, result)
+}
+
+// POST /api/meetings/reprocess
+func (h *Handler) ReprocessMeetings(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value("Body").(ReprocessMeetingsRequest)
+
+	filter := repository.ListMeetingsForReprocessParams{
+		Status:      utils.ToNullString(input.Status),
+		Source:      utils.ToNullString(input.Source),
+		MaxMeetings: 100,
+	}
+	if input.CreatedAfter != nil {
+		filter.CreatedAfter = sql.NullTime{Time: *input.CreatedAfter, Valid: true}
+	}
+	if input.CreatedBefore != nil {
+		filter.CreatedBefore = sql.NullTime{Time: *input.CreatedBefore, Valid: true}
+	}
+	if input.Limit > 0 {
+		filter.MaxMeetings = int32(input.Limit)
+	}
+
+	result, err := h.opps.ResetMeetings(r.Context(), filter, h.enqueueInTx())
+	if err != nil {
+		logger.Error("ResetMeetings:", err)
+		response.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	h.enqueueReprocessed(w, result)
+}
+
+// enqueueInTx lets a queue that keeps jobs in the database insert them in the
+// rollback's transaction. Other queues get nil and are enqueued after commit.
+func (h *Handler) enqueueInTx() service.EnqueueFunc {
+	if q, ok := h.worker.(queue.TxEnqueuer); ok {
+		return q.EnqueueTx
+	}
+	return nil
+}
+
+// enqueueAfterCommit queues reset meetings that enqueueInTx didn't. It runs after
+// the rollback is committed, so workers never see a meeting whose old evidence
+// is still around.
+func (h *Handler) enqueueAfterCommit(ids []uuid.UUID) {
+	if _, ok := h.worker.(queue.TxEnqueuer); ok {
+		return
+	}
+	for _, id := range ids {
+		h.worker.Enqueue(id)
+	}
+}
+
+// enqueueReprocessed queues the reset meetings and answers with what was rolled back.
+func (h *Handler) enqueueReprocessed(w http.ResponseWriter, result service.ReprocessResult) {
+	h.enqueueAfterCommit(result.MeetingIDs)
+
+	resp := ReprocessResponse{
+		Status:               "queued",
+		MeetingIDs:           result.MeetingIDs,
+		DeletedOpportunities: result.DeletedOpportunities,
+		Message:              fmt.Sprintf("%d meetings rolled back and queued for extraction", len(result.MeetingIDs)),
+	}
+	if resp.MeetingIDs == nil {
+		resp.MeetingIDs = []uuid.UUID{}
+	}
+
+	response.JSON(w, http.StatusAccepted, resp)
+}
+
 // GET /api/opportunities/{id}
 func (h *Handler) GetOpportunity(w http.ResponseWriter, r *http.Request, idStr string) {
 	includeEvidence := r.URL.Query().Get("include_evidence") == "true"
@@ -122,6 +269,13 @@ func (h *Handler) GetOpportunity(w http.ResponseWriter, r *http.Request, idStr s
 		return
 	}
 
+	// Opportunities merged into another one answer with the one they were merged into.
+	id, err = h.opps.ResolveOpportunityID(r.Context(), id)
+	if err != nil {
+		response.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
 	opp, err := h.q.GetOpportunity(r.Context(), id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -142,6 +296,20 @@ func (h *Handler) GetOpportunity(w http.ResponseWriter, r *http.Request, idStr s
 		}
 	}
 
+	customers, err := h.customersByOpportunity(r.Context(), []uuid.UUID{opp.ID})
+	if err != nil {
+		logger.Error("ListCustomersForOpportunities:", err)
+		response.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	solutions, err := h.solutionsByOpportunity(r.Context(), []uuid.UUID{opp.ID})
+	if err != nil {
+		logger.Error("ListSolutionsForOpportunities:", err)
+		response.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
 	resp := OpportunityResponse{
 		ID:            opp.ID,
 		UserSegment:   opp.UserSegment,
@@ -152,6 +320,13 @@ func (h *Handler) GetOpportunity(w http.ResponseWriter, r *http.Request, idStr s
 		EvidenceCount: int(opp.EvidenceCount),
 		Evidences:     evidences,
 		Created:       utils.FormatTime(opp.CreatedAt, "never"),
+		Impact:        utils.NullFloat64ToPtr(opp.Impact),
+		Confidence:    utils.NullFloat64ToPtr(opp.Confidence),
+		Effort:        utils.NullFloat64ToPtr(opp.Effort),
+		Customers:     customers[opp.ID],
+		OutcomeID:     nullUUIDToPtr(opp.OutcomeID),
+		Solutions:     solutions[opp.ID],
+		ParentID:      nullUUIDToPtr(opp.ParentID),
 	}
 
 	response.JSON(w, http.StatusOK, resp)
@@ -168,18 +343,21 @@ func (h *Handler) RecentOpportunities(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result := make([]OpportunityResponse, len(ops))
-	for i, op := range ops {
-		var evidences []EvidenceResponse
-		if includeEvidence {
-			evidences, err = h.getEvidences(r, op.ID)
-			if err != nil {
-				logger.Error("ListEvidenceByOpportunity", err)
-				response.Error(w, "Database error", http.StatusInternalServerError)
-				return
-			}
+	var evidences map[uuid.UUID][]EvidenceResponse
+	if includeEvidence {
+		ids := make([]uuid.UUID, len(ops))
+		for i, op := range ops {
+			ids[i] = op.ID
 		}
+		if evidences, err = h.evidencesByOpportunity(r.Context(), ids); err != nil {
+			logger.Error("ListEvidenceForOpportunities:", err)
+			response.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+	}
 
+	result := make([]OpportunityResponse, len(ops))
+	for i, op := range ops {
 		result[i] = OpportunityResponse{
 			ID:            op.ID,
 			UserSegment:   op.UserSegment,
@@ -188,7 +366,7 @@ func (h *Handler) RecentOpportunities(w http.ResponseWriter, r *http.Request) {
 			Workaround:    op.Workaround.String,
 			Theme:         op.ThemeName.String,
 			EvidenceCount: int(op.EvidenceCount),
-			Evidences:     evidences,
+			Evidences:     evidences[op.ID],
 			Created:       utils.FormatTime(op.CreatedAt, "never"),
 		}
 	}
@@ -227,18 +405,21 @@ func (h *Handler) TopOpportunitiesByTheme(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	ops := make([]OpportunityResponse, len(opps))
-	for i, op := range opps {
-		var evidences []EvidenceResponse
-		if includeEvidence {
-			evidences, err = h.getEvidences(r, op.ID)
-			if err != nil {
-				logger.Error("ListEvidenceByOpportunity", err)
-				response.Error(w, "Database error", http.StatusInternalServerError)
-				return
-			}
+	var evidences map[uuid.UUID][]EvidenceResponse
+	if includeEvidence {
+		ids := make([]uuid.UUID, len(opps))
+		for i, op := range opps {
+			ids[i] = op.ID
+		}
+		if evidences, err = h.evidencesByOpportunity(r.Context(), ids); err != nil {
+			logger.Error("ListEvidenceForOpportunities:", err)
+			response.Error(w, "Database error", http.StatusInternalServerError)
+			return
 		}
+	}
 
+	ops := make([]OpportunityResponse, len(opps))
+	for i, op := range opps {
 		ops[i] = OpportunityResponse{
 			ID:            op.ID,
 			UserSegment:   op.UserSegment,
@@ -246,7 +427,7 @@ func (h *Handler) TopOpportunitiesByTheme(w http.ResponseWriter, r *http.Request
 			Why:           op.WhyItMatters.String,
 			Workaround:    op.WhyItMatters.String,
 			EvidenceCount: int(op.EvidenceCount),
-			Evidences:     evidences,
+			Evidences:     evidences[op.ID],
 			Created:       utils.FormatTime(op.CreatedAt, "never"),
 		}
 	}
@@ -258,65 +439,18 @@ func (h *Handler) TopOpportunitiesByTheme(w http.ResponseWriter, r *http.Request
 	response.JSON(w, http.StatusOK, result)
 }
 
-// GET /api/cmd
+// GET /api/cmd?text=&format=text|blocks
+// POST /api/cmd, the same as a query or form; only POST runs commands that change data.
 func (h *Handler) SlackCommand(w http.ResponseWriter, r *http.Request) {
-	cmd := strings.TrimSpace(r.URL.Query().Get("text"))
-	if cmd == "" {
-		cmd = "help"
+	reply := h.runCommand(r.Context(), "", r.FormValue("text"), r.Method != http.MethodPost)
+
+	if r.FormValue("format") == "blocks" {
+		response.JSON(w, http.StatusOK, reply.Slack())
+		return
 	}
 
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-
-	switch strings.ToLower(cmd) {
-	case "show_new_opportunities":
-		ops, err := h.q.ListRecentOpportunities(r.Context())
-		if err != nil {
-			w.Write([]byte("Error fetching opportunities"))
-			return
-		}
-		if len(ops) == 0 {
-			w.Write([]byte("No new opportunities in the last 24 hours. Keep discovering!"))
-			return
-		}
-		fmt.Fprintf(w, "Found %d new opportunities in the last 24h:\n\n", len(ops))
-		for _, op := range ops {
-			theme := ""
-			if op.ThemeName.Valid && op.ThemeName.String != "" {
-				theme = fmt.Sprintf(" [%s]", op.ThemeName.String)
-			}
-			fmt.Fprintf(w, "• *%s*\n  %s%s\n\n", op.UserSegment, op.Struggle, theme)
-		}
-	case "themes_this_week":
-		themes, err := h.q.ListTopThemesThisWeek(r.Context())
-		if err != nil {
-			w.Write([]byte("Error fetching themes"))
-			return
-		}
-
-		if len(themes) == 0 {
-			w.Write([]byte("No opportunities this week yet. Keep discovering!"))
-			return
-		}
-
-		w.Write([]byte("*Top opportunity themes this week:*\n\n"))
-
-		for i, t := range themes {
-			rank := i + 1
-			fmt.Fprintf(w, "%d. *%s* — %d opportunities\n", rank, t.ThemeName, t.OpportunityCount)
-		}
-
-		w.Write([]byte("\nUse `/cmd?text=show_new_opportunities` to see the latest pains"))
-	case "help":
-		fallthrough
-	default:
-		w.Write([]byte(`Noker Commands
-
-• show_new_opportunities → Latest customer pains
-• themes_this_week       → Trending opportunity themes
-• help                   → This message
-
-Example: /cmd?text=show_new_opportunities`))
-	}
+	w.Write([]byte(reply.PlainText()))
 }
 
 func (h *Handler) ServeGraph(w http.ResponseWriter, r *http.Request) {
@@ -336,6 +470,11 @@ func (h *Handler) getEvidences(r *http.Request, id uuid.UUID) ([]EvidenceRespons
 			Quote:        ev.Quote,
 			Context:      ev.Context.String,
 			Created:      utils.FormatTime(ev.CreatedAt, "never"),
+			ChunkIndex:   int(ev.ChunkIndex),
+			ChunkOffset:  int(ev.ChunkOffset),
+			Verified:     ev.Verified,
+			QuoteStart:   utils.NullInt32ToPtr(ev.QuoteStart),
+			QuoteEnd:     utils.NullInt32ToPtr(ev.QuoteEnd),
 			MeetingID:    ev.MeetingID,
 			MeetingTitle: ev.MeetingTitle,
 			MeetingDate:  utils.FormatTime(ev.MeetingDate, "never"),
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/pedy4000/noker/internal/api/response"
+	"github.com/pedy4000/noker/pkg/logger"
+	"github.com/pedy4000/noker/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// GET /api/opportunities/{id}/subtree
+func (h *Handler) OpportunitySubtree(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, ok := h.parseOpportunityID(w, r, idStr)
+	if !ok {
+		return
+	}
+
+	rows, err := h.q.ListOpportunitySubtree(r.Context(), id)
+	if err != nil {
+		logger.Error("ListOpportunitySubtree:", err)
+		response.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if len(rows) == 0 {
+		response.Error(w, "Opportunity not found", http.StatusNotFound)
+		return
+	}
+
+	ids := make([]uuid.UUID, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	solutions, err := h.solutionsByOpportunity(r.Context(), ids)
+	if err != nil {
+		logger.Error("ListSolutionsForOpportunities:", err)
+		response.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	// Rows come parents first, so children keep the order they were listed in.
+	nodes := make(map[uuid.UUID]OpportunityResponse, len(rows))
+	children := map[uuid.UUID][]uuid.UUID{}
+	for _, row := range rows {
+		nodes[row.ID] = OpportunityResponse{
+			ID:            row.ID,
+			UserSegment:   row.UserSegment,
+			Struggle:      row.Struggle,
+			Theme:         row.ThemeName.String,
+			EvidenceCount: int(row.EvidenceCount),
+			Created:       utils.FormatTime(row.CreatedAt, "never"),
+			Solutions:     solutions[row.ID],
+			ParentID:      nullUUIDToPtr(row.ParentID),
+		}
+		if row.Depth > 0 {
+			children[row.ParentID.UUID] = append(children[row.ParentID.UUID], row.ID)
+		}
+	}
+
+	var build func(id uuid.UUID) OpportunityResponse
+	build = func(id uuid.UUID) OpportunityResponse {
+		node := nodes[id]
+		for _, child := range children[id] {
+			node.Children = append(node.Children, build(child))
+		}
+		return node
+	}
+
+	response.JSON(w, http.StatusOK, build(rows[0].ID))
+}
+
+// GET /api/opportunities/{id}/ancestors
+// Returns the path from the top-level opportunity down to the direct parent.
+func (h *Handler) OpportunityAncestors(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, ok := h.parseOpportunityID(w, r, idStr)
+	if !ok {
+		return
+	}
+
+	if _, err := h.q.GetOpportunity(r.Context(), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			response.Error(w, "Opportunity not found", http.StatusNotFound)
+		} else {
+			response.Error(w, "Database error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	rows, err := h.q.ListOpportunityAncestors(r.Context(), id)
+	if err != nil {
+		logger.Error("ListOpportunityAncestors:", err)
+		response.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	result := make([]OpportunityResponse, len(rows))
+	for i, row := range rows {
+		result[len(rows)-1-i] = OpportunityResponse{
+			ID:            row.ID,
+			UserSegment:   row.UserSegment,
+			Struggle:      row.Struggle,
+			Theme:         row.ThemeName.String,
+			EvidenceCount: int(row.EvidenceCount),
+			Created:       utils.FormatTime(row.CreatedAt, "never"),
+			ParentID:      nullUUIDToPtr(row.ParentID),
+		}
+	}
+
+	response.JSON(w, http.StatusOK, result)
+}
+
+// parseOpportunityID parses idStr and follows merge redirects, writing the error response if that fails.
+func (h *Handler) parseOpportunityID(w http.ResponseWriter, r *http.Request, idStr string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(w, "Invalid opportunity ID", http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+
+	id, err = h.opps.ResolveOpportunityID(r.Context(), id)
+	if err != nil {
+		response.Error(w, "Database error", http.StatusInternalServerError)
+		return uuid.Nil, false
+	}
+	return id, true
+}
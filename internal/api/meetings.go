@@ -0,0 +1,199 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pedy4000/noker/internal/api/response"
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/pkg/logger"
+	"github.com/pedy4000/noker/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+var meetingStatuses = map[string]bool{
+	"pending": true, "processing": true, "retrying": true, "done": true, "failed": true, "dead": true,
+}
+
+// GET /api/meetings?status=&source=&metadata=key:value&q=&created_after=&created_before=&limit=&cursor=
+func (h *Handler) ListMeetings(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit := pageSize(query.Get("limit"))
+
+	params := repository.ListMeetingsParams{
+		Status:  utils.ToNullString(query.Get("status")),
+		Source:  utils.ToNullString(query.Get("source")),
+		MaxRows: int32(limit + 1), // one extra row tells whether there's a next page
+	}
+	if params.Status.Valid && !meetingStatuses[params.Status.String] {
+		response.Error(w, "Invalid status", http.StatusBadRequest)
+		return
+	}
+
+	if m := query.Get("metadata"); m != "" {
+		key, value, hasValue := strings.Cut(m, ":")
+		params.MetadataKey = utils.ToNullString(key)
+		if hasValue {
+			params.MetadataValue = sql.NullString{String: value, Valid: true}
+		}
+	}
+	if q := strings.TrimSpace(query.Get("q")); q != "" {
+		params.Search = utils.ToNullString(escapeLike(q))
+	}
+
+	var err error
+	if params.CreatedAfter, err = parseTimeParam(query.Get("created_after")); err != nil {
+		response.Error(w, "Invalid created_after, expected RFC 3339", http.StatusBadRequest)
+		return
+	}
+	if params.CreatedBefore, err = parseTimeParam(query.Get("created_before")); err != nil {
+		response.Error(w, "Invalid created_before, expected RFC 3339", http.StatusBadRequest)
+		return
+	}
+
+	filter := filterHash(query.Get("status"), query.Get("source"), query.Get("metadata"), query.Get("q"),
+		query.Get("created_after"), query.Get("created_before"))
+	if c := query.Get("cursor"); c != "" {
+		cur, err := parseCursor(c, "", filter)
+		if err != nil {
+			cursorError(w, err)
+			return
+		}
+		params.CursorCreated = sql.NullTime{Time: cur.Time, Valid: true}
+		params.CursorID = utils.ToNullUUID(cur.ID)
+	}
+
+	rows, err := h.q.ListMeetings(r.Context(), params)
+	if err != nil {
+		logger.Error("ListMeetings:", err)
+		response.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := MeetingListResponse{Meetings: make([]MeetingResponse, 0, len(rows))}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		resp.NextCursor = cursor{Filter: filter, Time: last.CreatedAt.Time, ID: last.ID}.String()
+	}
+
+	for _, m := range rows {
+		resp.Meetings = append(resp.Meetings, MeetingResponse{
+			ID:            m.ID,
+			Title:         m.Title,
+			Source:        m.Source,
+			Metadata:      string(m.Metadata.RawMessage),
+			Status:        m.ProcessingStatus,
+			Message:       m.ProcessingError.String,
+			Attempts:      int(m.ProcessingAttempts),
+			EvidenceCount: int(m.EvidenceCount),
+			Created:       utils.FormatTime(m.CreatedAt, "never"),
+			Processed:     utils.FormatTime(m.ProcessedAt, "never"),
+			Updated:       utils.FormatTime(m.UpdatedAt, "never"),
+		})
+	}
+
+	response.JSON(w, http.StatusOK, resp)
+}
+
+// GET /api/meetings/{id}
+func (h *Handler) GetMeeting(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(w, "Invalid meeting ID", http.StatusBadRequest)
+		return
+	}
+
+	meeting, err := h.q.GetMeeting(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			response.Error(w, "Meeting not found", http.StatusNotFound)
+		} else {
+			response.Error(w, "Database error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	rows, err := h.q.ListMeetingEvidence(r.Context(), id)
+	if err != nil {
+		logger.Error("ListMeetingEvidence:", err)
+		response.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	// Rows come ordered by opportunity, so each opportunity's quotes are contiguous.
+	opps := []OpportunityResponse{}
+	for _, row := range rows {
+		if len(opps) == 0 || opps[len(opps)-1].ID != row.OpportunityID {
+			opps = append(opps, OpportunityResponse{
+				ID:          row.OpportunityID,
+				UserSegment: row.UserSegment,
+				Struggle:    row.Struggle,
+				Why:         row.WhyItMatters.String,
+				Workaround:  row.Workaround.String,
+				Theme:       row.ThemeName.String,
+				Created:     utils.FormatTime(row.OpportunityCreatedAt, "never"),
+			})
+		}
+
+		opp := &opps[len(opps)-1]
+		opp.Evidences = append(opp.Evidences, EvidenceResponse{
+			ID:           row.ID,
+			Quote:        row.Quote,
+			Context:      row.Context.String,
+			Created:      utils.FormatTime(row.CreatedAt, "never"),
+			ChunkIndex:   int(row.ChunkIndex),
+			ChunkOffset:  int(row.ChunkOffset),
+			Verified:     row.Verified,
+			QuoteStart:   utils.NullInt32ToPtr(row.QuoteStart),
+			QuoteEnd:     utils.NullInt32ToPtr(row.QuoteEnd),
+			MeetingID:    meeting.ID,
+			MeetingTitle: meeting.Title,
+			MeetingDate:  utils.FormatTime(meeting.CreatedAt, "never"),
+		})
+		opp.EvidenceCount = len(opp.Evidences)
+	}
+
+	resp := MeetingDetailResponse{
+		MeetingResponse: MeetingResponse{
+			ID:               meeting.ID,
+			Title:            meeting.Title,
+			Source:           meeting.Source,
+			Metadata:         string(meeting.Metadata.RawMessage),
+			Status:           meeting.ProcessingStatus,
+			Message:          meeting.ProcessingError.String,
+			Attempts:         int(meeting.ProcessingAttempts),
+			ErrorHistory:     meeting.ErrorHistory,
+			ValidationIssues: meeting.ValidationIssues,
+			NextAttempt:      utils.FormatTime(meeting.NextAttemptAt, ""),
+			EvidenceCount:    len(rows),
+			Created:          utils.FormatTime(meeting.CreatedAt, "never"),
+			Processed:        utils.FormatTime(meeting.ProcessedAt, "never"),
+			Updated:          utils.FormatTime(meeting.UpdatedAt, "never"),
+		},
+		RawNotes:      meeting.RawNotes,
+		Opportunities: opps,
+	}
+
+	response.JSON(w, http.StatusOK, resp)
+}
+
+func parseTimeParam(s string) (sql.NullTime, error) {
+	if s == "" {
+		return sql.NullTime{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return sql.NullTime{}, err
+	}
+	return sql.NullTime{Time: t, Valid: true}, nil
+}
+
+// escapeLike makes s match literally inside an ILIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pedy4000/noker/internal/api/response"
+	"github.com/pedy4000/noker/internal/slack"
+	"github.com/pedy4000/noker/pkg/config"
+	"github.com/pedy4000/noker/pkg/logger"
+)
+
+// SlackSignature lets through requests signed with the Slack app's signing secret.
+// Slack can't send X-API-Key, so its routes use this instead of Auth.
+func SlackSignature(cfg *config.Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if cfg.Slack.SigningSecret == "" {
+				response.Error(w, "Slack integration is not configured", http.StatusNotFound)
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+			if err != nil {
+				response.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+
+			maxSkew := time.Duration(cfg.Slack.MaxSkewSec) * time.Second
+			if err := slack.Verify(cfg.Slack.SigningSecret, r.Header, body, time.Now(), maxSkew); err != nil {
+				logger.Debug("Rejected Slack request:", err)
+				response.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			// The handler parses the body the signature was checked against.
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
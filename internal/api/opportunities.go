@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pedy4000/noker/internal/api/response"
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/pkg/logger"
+	"github.com/pedy4000/noker/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+var opportunitySorts = map[string]bool{"recent": true, "evidence": true, "last_evidence": true}
+
+// GET /api/opportunities?since=&until=&theme=&segment=&min_evidence=&sort=&include_evidence=&limit=&cursor=
+func (h *Handler) ListOpportunities(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit := pageSize(query.Get("limit"))
+
+	params := repository.ListOpportunitiesParams{
+		Theme:   utils.ToNullString(strings.TrimSpace(query.Get("theme"))),
+		Segment: utils.ToNullString(strings.TrimSpace(query.Get("segment"))),
+		SortBy:  query.Get("sort"),
+		MaxRows: int32(limit + 1), // one extra row tells whether there's a next page
+	}
+	if params.SortBy == "" {
+		params.SortBy = "recent"
+	}
+	if !opportunitySorts[params.SortBy] {
+		response.Error(w, "Invalid sort, expected recent, evidence or last_evidence", http.StatusBadRequest)
+		return
+	}
+
+	if m := query.Get("min_evidence"); m != "" {
+		n, err := strconv.Atoi(m)
+		if err != nil || n < 0 {
+			response.Error(w, "Invalid min_evidence", http.StatusBadRequest)
+			return
+		}
+		params.MinEvidence = int64(n)
+	}
+
+	var err error
+	if params.Since, err = parseTimeParam(query.Get("since")); err != nil {
+		response.Error(w, "Invalid since, expected RFC 3339", http.StatusBadRequest)
+		return
+	}
+	if params.Until, err = parseTimeParam(query.Get("until")); err != nil {
+		response.Error(w, "Invalid until, expected RFC 3339", http.StatusBadRequest)
+		return
+	}
+
+	filter := filterHash(params.Theme.String, params.Segment.String, strconv.FormatInt(params.MinEvidence, 10),
+		query.Get("since"), query.Get("until"))
+	if c := query.Get("cursor"); c != "" {
+		cur, err := parseCursor(c, params.SortBy, filter)
+		if err != nil {
+			cursorError(w, err)
+			return
+		}
+		params.CursorTime = sql.NullTime{Time: cur.Time, Valid: true}
+		params.CursorCount = sql.NullInt64{Int64: cur.Count, Valid: true}
+		params.CursorID = utils.ToNullUUID(cur.ID)
+	}
+
+	rows, err := h.q.ListOpportunities(r.Context(), params)
+	if err != nil {
+		logger.Error("ListOpportunities:", err)
+		response.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := OpportunityListResponse{Opportunities: make([]OpportunityResponse, 0, len(rows))}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		resp.NextCursor = cursor{Sort: params.SortBy, Filter: filter, Time: last.SortTime, Count: last.SortCount, ID: last.ID}.String()
+	}
+
+	ids := make([]uuid.UUID, len(rows))
+	for i, op := range rows {
+		ids[i] = op.ID
+	}
+
+	var evidences map[uuid.UUID][]EvidenceResponse
+	if query.Get("include_evidence") == "true" {
+		if evidences, err = h.evidencesByOpportunity(r.Context(), ids); err != nil {
+			logger.Error("ListEvidenceForOpportunities:", err)
+			response.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	customers, err := h.customersByOpportunity(r.Context(), ids)
+	if err != nil {
+		logger.Error("ListCustomersForOpportunities:", err)
+		response.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	solutions, err := h.solutionsByOpportunity(r.Context(), ids)
+	if err != nil {
+		logger.Error("ListSolutionsForOpportunities:", err)
+		response.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	for _, op := range rows {
+		resp.Opportunities = append(resp.Opportunities, OpportunityResponse{
+			ID:            op.ID,
+			UserSegment:   op.UserSegment,
+			Struggle:      op.Struggle,
+			Why:           op.WhyItMatters.String,
+			Workaround:    op.Workaround.String,
+			Theme:         op.ThemeName.String,
+			EvidenceCount: int(op.EvidenceCount),
+			Evidences:     evidences[op.ID],
+			Created:       utils.FormatTime(op.CreatedAt, "never"),
+			Customers:     customers[op.ID],
+			OutcomeID:     nullUUIDToPtr(op.OutcomeID),
+			ParentID:      nullUUIDToPtr(op.ParentID),
+			Solutions:     solutions[op.ID],
+		})
+	}
+
+	response.JSON(w, http.StatusOK, resp)
+}
+
+// evidencesByOpportunity loads the evidence of all the given opportunities in a single query.
+func (h *Handler) evidencesByOpportunity(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]EvidenceResponse, error) {
+	result := make(map[uuid.UUID][]EvidenceResponse, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	rows, err := h.q.ListEvidenceForOpportunities(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ev := range rows {
+		result[ev.OpportunityID] = append(result[ev.OpportunityID], EvidenceResponse{
+			ID:           ev.ID,
+			Quote:        ev.Quote,
+			Context:      ev.Context.String,
+			Created:      utils.FormatTime(ev.CreatedAt, "never"),
+			ChunkIndex:   int(ev.ChunkIndex),
+			ChunkOffset:  int(ev.ChunkOffset),
+			Verified:     ev.Verified,
+			QuoteStart:   utils.NullInt32ToPtr(ev.QuoteStart),
+			QuoteEnd:     utils.NullInt32ToPtr(ev.QuoteEnd),
+			MeetingID:    ev.MeetingID,
+			MeetingTitle: ev.MeetingTitle,
+			MeetingDate:  utils.FormatTime(ev.MeetingDate, "never"),
+		})
+	}
+	return result, nil
+}
@@ -32,28 +32,144 @@ func NewRouter(h *Handler, cfg *config.Config) http.Handler {
 	r.Get("/graph", h.ServeGraph)
 	r.Handle("/static/*", http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
 
+	// Slack signs its requests instead of sending the API key
+	r.With(middleware.SlackSignature(cfg)).Post("/api/slack/commands", h.SlackSlashCommand)
+
 	// Protected API routes
 	r.Group(func(r chi.Router) {
 		r.Use(middleware.Auth(cfg))
 
 		// Meetings
 		r.Post("/api/meetings", middleware.Validate[CreateMeetingRequest](h.CreateMeeting))
+		r.Get("/api/meetings", h.ListMeetings)
+		r.Get("/api/meetings/{id}", func(rw http.ResponseWriter, r *http.Request) {
+			h.GetMeeting(rw, r, chi.URLParam(r, "id"))
+		})
 		r.Get("/api/meetings/{id}/status", func(rw http.ResponseWriter, r *http.Request) {
 			h.MeetingStatus(rw, r, chi.URLParam(r, "id"))
 		})
+		r.Post("/api/meetings/reprocess", middleware.Validate[ReprocessMeetingsRequest](h.ReprocessMeetings))
+		r.Post("/api/meetings/{id}/reprocess", func(rw http.ResponseWriter, r *http.Request) {
+			h.ReprocessMeeting(rw, r, chi.URLParam(r, "id"))
+		})
 
 		// Opportunities
+		r.Get("/api/opportunities", h.ListOpportunities)
 		r.Get("/api/opportunities/recent", h.RecentOpportunities)
+		r.Get("/api/opportunities/prioritized", h.PrioritizedOpportunities)
 		r.Get("/api/opportunities/{id}", func(rw http.ResponseWriter, r *http.Request) {
 			h.GetOpportunity(rw, r, chi.URLParam(r, "id"))
 		})
+		r.Patch("/api/opportunities/{id}", middleware.Validate[UpdateOpportunityRequest](func(rw http.ResponseWriter, r *http.Request) {
+			h.UpdateOpportunity(rw, r, chi.URLParam(r, "id"))
+		}))
+		r.Delete("/api/opportunities/{id}", func(rw http.ResponseWriter, r *http.Request) {
+			h.DeleteOpportunity(rw, r, chi.URLParam(r, "id"))
+		})
+		r.Post("/api/opportunities/{id}/merge", middleware.Validate[MergeOpportunitiesRequest](func(rw http.ResponseWriter, r *http.Request) {
+			h.MergeOpportunities(rw, r, chi.URLParam(r, "id"))
+		}))
+		r.Post("/api/opportunities/{id}/split", middleware.Validate[SplitOpportunityRequest](func(rw http.ResponseWriter, r *http.Request) {
+			h.SplitOpportunity(rw, r, chi.URLParam(r, "id"))
+		}))
+		r.Get("/api/opportunities/{id}/audit", func(rw http.ResponseWriter, r *http.Request) {
+			h.OpportunityAudit(rw, r, chi.URLParam(r, "id"))
+		})
+		r.Get("/api/opportunities/{id}/subtree", func(rw http.ResponseWriter, r *http.Request) {
+			h.OpportunitySubtree(rw, r, chi.URLParam(r, "id"))
+		})
+		r.Get("/api/opportunities/{id}/ancestors", func(rw http.ResponseWriter, r *http.Request) {
+			h.OpportunityAncestors(rw, r, chi.URLParam(r, "id"))
+		})
+
+		// Webhooks
+		r.Get("/api/webhooks", h.ListWebhooks)
+		r.Post("/api/webhooks", middleware.Validate[CreateWebhookRequest](h.CreateWebhook))
+		r.Get("/api/webhooks/{id}", func(rw http.ResponseWriter, r *http.Request) {
+			h.GetWebhook(rw, r, chi.URLParam(r, "id"))
+		})
+		r.Patch("/api/webhooks/{id}", middleware.Validate[UpdateWebhookRequest](func(rw http.ResponseWriter, r *http.Request) {
+			h.UpdateWebhook(rw, r, chi.URLParam(r, "id"))
+		}))
+		r.Delete("/api/webhooks/{id}", func(rw http.ResponseWriter, r *http.Request) {
+			h.DeleteWebhook(rw, r, chi.URLParam(r, "id"))
+		})
+		r.Get("/api/webhooks/{id}/deliveries", func(rw http.ResponseWriter, r *http.Request) {
+			h.ListWebhookDeliveries(rw, r, chi.URLParam(r, "id"))
+		})
+		r.Post("/api/webhooks/deliveries/{id}/retry", func(rw http.ResponseWriter, r *http.Request) {
+			h.RetryWebhookDelivery(rw, r, chi.URLParam(r, "id"))
+		})
+
+		// Customers
+		r.Get("/api/customers", h.ListCustomers)
+		r.Get("/api/customers/{id}/opportunities", func(rw http.ResponseWriter, r *http.Request) {
+			h.CustomerOpportunities(rw, r, chi.URLParam(r, "id"))
+		})
+
+		r.Post("/api/opportunities/{id}/solutions", middleware.Validate[CreateSolutionRequest](func(rw http.ResponseWriter, r *http.Request) {
+			h.CreateSolution(rw, r, chi.URLParam(r, "id"))
+		}))
+
+		// Opportunity Solution Tree
+		r.Get("/api/tree", h.SolutionTree)
+		r.Get("/api/outcomes", h.ListOutcomes)
+		r.Post("/api/outcomes", middleware.Validate[CreateOutcomeRequest](h.CreateOutcome))
+		r.Patch("/api/outcomes/{id}", middleware.Validate[UpdateOutcomeRequest](func(rw http.ResponseWriter, r *http.Request) {
+			h.UpdateOutcome(rw, r, chi.URLParam(r, "id"))
+		}))
+		r.Delete("/api/outcomes/{id}", func(rw http.ResponseWriter, r *http.Request) {
+			h.DeleteOutcome(rw, r, chi.URLParam(r, "id"))
+		})
+		r.Patch("/api/solutions/{id}", middleware.Validate[UpdateSolutionRequest](func(rw http.ResponseWriter, r *http.Request) {
+			h.UpdateSolution(rw, r, chi.URLParam(r, "id"))
+		}))
+		r.Delete("/api/solutions/{id}", func(rw http.ResponseWriter, r *http.Request) {
+			h.DeleteSolution(rw, r, chi.URLParam(r, "id"))
+		})
+		r.Post("/api/solutions/{id}/experiments", middleware.Validate[CreateExperimentRequest](func(rw http.ResponseWriter, r *http.Request) {
+			h.CreateExperiment(rw, r, chi.URLParam(r, "id"))
+		}))
+		r.Patch("/api/experiments/{id}", middleware.Validate[UpdateExperimentRequest](func(rw http.ResponseWriter, r *http.Request) {
+			h.UpdateExperiment(rw, r, chi.URLParam(r, "id"))
+		}))
+		r.Delete("/api/experiments/{id}", func(rw http.ResponseWriter, r *http.Request) {
+			h.DeleteExperiment(rw, r, chi.URLParam(r, "id"))
+		})
+
+		// Evidence
+		r.Delete("/api/evidence/{id}", func(rw http.ResponseWriter, r *http.Request) {
+			h.DeleteEvidence(rw, r, chi.URLParam(r, "id"))
+		})
 
 		// Themes
+		r.Patch("/api/themes/{theme}", middleware.Validate[UpdateThemeRequest](func(rw http.ResponseWriter, r *http.Request) {
+			h.UpdateTheme(rw, r, chi.URLParam(r, "theme"))
+		}))
+		r.Post("/api/themes/{theme}/merge", middleware.Validate[MergeThemesRequest](func(rw http.ResponseWriter, r *http.Request) {
+			h.MergeThemes(rw, r, chi.URLParam(r, "theme"))
+		}))
+		r.Delete("/api/themes/{theme}", func(rw http.ResponseWriter, r *http.Request) {
+			h.DeleteTheme(rw, r, chi.URLParam(r, "theme"))
+		})
 		r.Get("/api/themes/{theme}/top-opportunities", func(rw http.ResponseWriter, r *http.Request) {
 			h.TopOpportunitiesByTheme(rw, r, chi.URLParam(r, "theme"))
 		})
+
+		// Scoring
+		r.Get("/api/workspaces/{workspace}/scoring", func(rw http.ResponseWriter, r *http.Request) {
+			h.GetScoringWeights(rw, r, chi.URLParam(r, "workspace"))
+		})
+		r.Put("/api/workspaces/{workspace}/scoring", middleware.Validate[ScoringWeightsRequest](func(rw http.ResponseWriter, r *http.Request) {
+			h.SetScoringWeights(rw, r, chi.URLParam(r, "workspace"))
+		}))
+
+		// Search
+		r.Get("/api/search", h.Search)
+
 		// Slack command
 		r.Get("/api/cmd", h.SlackCommand)
+		r.Post("/api/cmd", h.SlackCommand)
 	})
 
 	return r
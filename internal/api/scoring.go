@@ -0,0 +1,103 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/pedy4000/noker/internal/api/response"
+	"github.com/pedy4000/noker/internal/service"
+	"github.com/pedy4000/noker/pkg/logger"
+	"github.com/pedy4000/noker/pkg/utils"
+)
+
+const defaultWorkspace = "default"
+
+// GET /api/opportunities/prioritized?framework=rice|ice&workspace=&limit=
+func (h *Handler) PrioritizedOpportunities(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	framework := query.Get("framework")
+	if framework == "" {
+		framework = service.FrameworkRICE
+	}
+	if framework != service.FrameworkRICE && framework != service.FrameworkICE {
+		response.Error(w, "Invalid framework, expected rice or ice", http.StatusBadRequest)
+		return
+	}
+
+	workspace := query.Get("workspace")
+	if workspace == "" {
+		workspace = defaultWorkspace
+	}
+
+	opps, weights, err := h.opps.PrioritizedOpportunities(r.Context(), workspace, framework, pageSize(query.Get("limit")))
+	if err != nil {
+		logger.Error("PrioritizedOpportunities:", err)
+		response.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := PrioritizedOpportunitiesResponse{
+		Framework:     framework,
+		Weights:       weights,
+		Opportunities: make([]PrioritizedOpportunityResponse, len(opps)),
+	}
+	for i, op := range opps {
+		score := op.Score.RICE
+		if framework == service.FrameworkICE {
+			score = op.Score.ICE
+		}
+		resp.Opportunities[i] = PrioritizedOpportunityResponse{
+			Rank:          i + 1,
+			ID:            op.ID,
+			UserSegment:   op.UserSegment,
+			Struggle:      op.Struggle,
+			Theme:         op.Theme,
+			Score:         score,
+			Factors:       op.Score,
+			EvidenceCount: int(op.EvidenceCount),
+			CustomerCount: int(op.CustomerCount),
+			Created:       utils.FormatTime(op.Created, "never"),
+		}
+	}
+
+	response.JSON(w, http.StatusOK, resp)
+}
+
+// GET /api/workspaces/{workspace}/scoring
+func (h *Handler) GetScoringWeights(w http.ResponseWriter, r *http.Request, workspace string) {
+	weights, err := h.opps.ScoringWeights(r.Context(), workspace)
+	if err != nil {
+		logger.Error("GetScoringWeights:", err)
+		response.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, weights)
+}
+
+// PUT /api/workspaces/{workspace}/scoring
+func (h *Handler) SetScoringWeights(w http.ResponseWriter, r *http.Request, workspace string) {
+	input := r.Context().Value("Body").(ScoringWeightsRequest)
+
+	if workspace == "" || len(workspace) > 100 {
+		response.Error(w, "Invalid workspace", http.StatusBadRequest)
+		return
+	}
+
+	weights := service.ScoringWeights{
+		Workspace:        workspace,
+		ReachPerCustomer: input.ReachPerCustomer,
+		ReachPerEvidence: input.ReachPerEvidence,
+		ReachWeight:      input.ReachWeight,
+		ImpactWeight:     input.ImpactWeight,
+		ConfidenceWeight: input.ConfidenceWeight,
+		EffortWeight:     input.EffortWeight,
+	}
+	if err := h.opps.SetScoringWeights(r.Context(), weights); err != nil {
+		logger.Error("SetScoringWeights:", err)
+		response.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, weights)
+}
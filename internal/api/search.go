@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pedy4000/noker/internal/api/response"
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/pkg/logger"
+)
+
+// searchLanguages are the text search configs shipped with Postgres.
+// "simple" doesn't stem and works for any language, including ones without a config.
+var searchLanguages = map[string]bool{
+	"simple": true, "arabic": true, "armenian": true, "basque": true, "catalan": true,
+	"danish": true, "dutch": true, "english": true, "finnish": true, "french": true,
+	"german": true, "greek": true, "hindi": true, "hungarian": true, "indonesian": true,
+	"irish": true, "italian": true, "lithuanian": true, "nepali": true, "norwegian": true,
+	"portuguese": true, "romanian": true, "russian": true, "serbian": true, "spanish": true,
+	"swedish": true, "tamil": true, "turkish": true, "yiddish": true,
+}
+
+// GET /api/search?q=&lang=&limit=
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		response.Error(w, "Query parameter q is required", http.StatusBadRequest)
+		return
+	}
+
+	lang := r.URL.Query().Get("lang")
+	if lang == "" {
+		lang = "simple"
+	}
+	if !searchLanguages[lang] {
+		response.Error(w, "Unsupported language", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := h.q.SearchOpportunities(r.Context(), repository.SearchOpportunitiesParams{
+		Query:            query,
+		Language:         lang,
+		MaxOpportunities: int32(pageSize(r.URL.Query().Get("limit"))),
+	})
+	if err != nil {
+		logger.Error("SearchOpportunities:", err)
+		response.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	// Rows come ordered by opportunity score, with each opportunity's quotes together.
+	resp := SearchResponse{Query: query, Results: []SearchResult{}}
+	for _, row := range rows {
+		if n := len(resp.Results); n == 0 || resp.Results[n-1].OpportunityID != row.OpportunityID {
+			resp.Results = append(resp.Results, SearchResult{
+				OpportunityID:     row.OpportunityID,
+				Struggle:          row.Struggle,
+				StruggleHighlight: row.StruggleHighlight,
+				UserSegment:       row.UserSegment,
+				Theme:             row.ThemeName.String,
+				Score:             row.Score,
+				Matches:           []SearchMatch{},
+			})
+		}
+
+		if row.EvidenceID.Valid {
+			result := &resp.Results[len(resp.Results)-1]
+			result.Matches = append(result.Matches, SearchMatch{
+				EvidenceID: row.EvidenceID.UUID,
+				MeetingID:  row.MeetingID.UUID,
+				Quote:      row.Quote.String,
+				Highlight:  row.QuoteHighlight,
+				Rank:       row.QuoteRank,
+			})
+		}
+	}
+
+	response.JSON(w, http.StatusOK, resp)
+}
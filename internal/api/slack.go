@@ -0,0 +1,97 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pedy4000/noker/internal/api/response"
+	"github.com/pedy4000/noker/internal/models"
+	"github.com/pedy4000/noker/internal/slack"
+	"github.com/pedy4000/noker/pkg/logger"
+)
+
+// Notes shorter than this are rejected, as in POST /api/meetings.
+const minSlackNotes = 10
+
+// POST /api/slack/commands
+// Slack sends the slash command form-encoded and shows whatever we answer within
+// 3 seconds, so problems are answered with a 200 and an ephemeral message too.
+func (h *Handler) SlackSlashCommand(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		response.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	if r.PostForm.Get("ssl_check") == "1" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	command := r.PostForm.Get("command")
+	if command == "" {
+		command = "/ost"
+	}
+	text := r.PostForm.Get("text")
+
+	if name, notes := cutCommand(text); strings.EqualFold(name, "add") {
+		h.slackAddMeeting(w, r, command, notes)
+		return
+	}
+	response.JSON(w, http.StatusOK, h.runCommand(r.Context(), command+" ", text, false).Slack())
+}
+
+// slackAddMeeting handles /ost add <notes>. The meeting is created as POST /api/meetings
+// would, and the worker posts what it extracted to the command's response_url.
+func (h *Handler) slackAddMeeting(w http.ResponseWriter, r *http.Request, command, notes string) {
+	if len([]rune(notes)) < minSlackNotes {
+		text := fmt.Sprintf("Paste the meeting notes after the command, e.g. `%s add Call with Acme: exports keep failing...`", command)
+		response.JSON(w, http.StatusOK, slack.Ephemeral(text, slack.Section(text)))
+		return
+	}
+
+	input := CreateMeetingRequest{
+		Title:  slackMeetingTitle(notes),
+		Notes:  notes,
+		Source: models.SourceSlack,
+		Metadata: map[string]any{
+			"slack_user":       r.PostForm.Get("user_name"),
+			"slack_user_id":    r.PostForm.Get("user_id"),
+			"slack_channel":    r.PostForm.Get("channel_name"),
+			"slack_channel_id": r.PostForm.Get("channel_id"),
+			"slack_team_id":    r.PostForm.Get("team_id"),
+		},
+	}
+	for key, value := range input.Metadata {
+		if value == "" {
+			delete(input.Metadata, key)
+		}
+	}
+
+	result, err := h.saveMeeting(r.Context(), input, r.PostForm.Get("response_url"))
+	if err != nil {
+		logger.Error("SlackAddMeeting DB error:", err)
+		text := "Sorry, the notes couldn't be saved. Please try again."
+		response.JSON(w, http.StatusOK, slack.Ephemeral(text, slack.Section(":x: "+text)))
+		return
+	}
+
+	h.worker.Enqueue(result.ID)
+
+	text := fmt.Sprintf("Got it! Extracting opportunities from \"%s\"…", input.Title)
+	response.JSON(w, http.StatusOK, slack.Ephemeral(text,
+		slack.Section(":hourglass_flowing_sand: "+text+"\nI'll post them here when they're ready."),
+		slack.Context("Meeting `"+result.ID.String()+"`")))
+}
+
+// slackMeetingTitle is the first line of the notes, shortened to fit CreateMeetingRequest.Title.
+func slackMeetingTitle(notes string) string {
+	title, _, _ := strings.Cut(notes, "\n")
+	title = strings.TrimSpace(title)
+	if r := []rune(title); len(r) > 80 {
+		title = strings.TrimSpace(string(r[:79])) + "…"
+	}
+	if len([]rune(title)) < 3 {
+		title = "Notes from Slack"
+	}
+	return title
+}
@@ -0,0 +1,404 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/pedy4000/noker/internal/api/response"
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/internal/service"
+	"github.com/pedy4000/noker/pkg/logger"
+	"github.com/pedy4000/noker/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// GET /api/tree?outcome=
+func (h *Handler) SolutionTree(w http.ResponseWriter, r *http.Request) {
+	var filter uuid.NullUUID
+	if o := r.URL.Query().Get("outcome"); o != "" {
+		id, err := uuid.Parse(o)
+		if err != nil {
+			response.Error(w, "Invalid outcome ID", http.StatusBadRequest)
+			return
+		}
+		filter = utils.ToNullUUID(id)
+	}
+
+	outcomes, err := h.q.ListOutcomes(r.Context())
+	if err != nil {
+		logger.Error("ListOutcomes:", err)
+		response.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	opps, err := h.q.ListTreeOpportunities(r.Context(), filter)
+	if err != nil {
+		logger.Error("ListTreeOpportunities:", err)
+		response.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	ids := make([]uuid.UUID, len(opps))
+	for i, op := range opps {
+		ids[i] = op.ID
+	}
+	solutions, err := h.solutionsByOpportunity(r.Context(), ids)
+	if err != nil {
+		logger.Error("ListSolutionsForOpportunities:", err)
+		response.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := TreeResponse{Outcomes: []OutcomeResponse{}, Unassigned: []OpportunityResponse{}}
+	index := map[uuid.UUID]int{}
+	for _, o := range outcomes {
+		if filter.Valid && o.ID != filter.UUID {
+			continue
+		}
+		index[o.ID] = len(resp.Outcomes)
+		resp.Outcomes = append(resp.Outcomes, outcomeResponse(o))
+	}
+
+	// An opportunity sits under its parent when both roll up to the same outcome;
+	// otherwise it starts a branch of its own under its outcome (or unassigned).
+	nodes := make(map[uuid.UUID]OpportunityResponse, len(opps))
+	placed := make(map[uuid.UUID]uuid.NullUUID, len(opps))
+	for _, op := range opps {
+		nodes[op.ID] = OpportunityResponse{
+			ID:            op.ID,
+			UserSegment:   op.UserSegment,
+			Struggle:      op.Struggle,
+			Theme:         op.ThemeName.String,
+			EvidenceCount: int(op.EvidenceCount),
+			Created:       utils.FormatTime(op.CreatedAt, "never"),
+			Solutions:     solutions[op.ID],
+			OutcomeID:     nullUUIDToPtr(op.OutcomeID),
+			ParentID:      nullUUIDToPtr(op.ParentID),
+		}
+		placed[op.ID] = op.TreeOutcomeID
+	}
+
+	children := map[uuid.UUID][]uuid.UUID{}
+	var roots []uuid.UUID
+	for _, op := range opps {
+		if parent, ok := placed[op.ParentID.UUID]; ok && op.ParentID.Valid && parent == op.TreeOutcomeID {
+			children[op.ParentID.UUID] = append(children[op.ParentID.UUID], op.ID)
+		} else {
+			roots = append(roots, op.ID)
+		}
+	}
+
+	var build func(id uuid.UUID) OpportunityResponse
+	build = func(id uuid.UUID) OpportunityResponse {
+		node := nodes[id]
+		for _, child := range children[id] {
+			node.Children = append(node.Children, build(child))
+		}
+		return node
+	}
+
+	for _, id := range roots {
+		outcome := placed[id]
+		if i, ok := index[outcome.UUID]; ok && outcome.Valid {
+			resp.Outcomes[i].Opportunities = append(resp.Outcomes[i].Opportunities, build(id))
+		} else {
+			resp.Unassigned = append(resp.Unassigned, build(id))
+		}
+	}
+
+	response.JSON(w, http.StatusOK, resp)
+}
+
+// GET /api/outcomes
+func (h *Handler) ListOutcomes(w http.ResponseWriter, r *http.Request) {
+	outcomes, err := h.q.ListOutcomes(r.Context())
+	if err != nil {
+		logger.Error("ListOutcomes:", err)
+		response.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	result := make([]OutcomeResponse, len(outcomes))
+	for i, o := range outcomes {
+		result[i] = outcomeResponse(o)
+	}
+
+	response.JSON(w, http.StatusOK, result)
+}
+
+// POST /api/outcomes
+func (h *Handler) CreateOutcome(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value("Body").(CreateOutcomeRequest)
+
+	outcome, err := h.opps.CreateOutcome(r.Context(), service.OutcomeChange{
+		Title:       &input.Title,
+		Description: &input.Description,
+		Metric:      &input.Metric,
+	}, input.Actor)
+	if err != nil {
+		curationError(w, "CreateOutcome:", err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, outcomeResponse(outcome))
+}
+
+// PATCH /api/outcomes/{id}
+func (h *Handler) UpdateOutcome(w http.ResponseWriter, r *http.Request, idStr string) {
+	input := r.Context().Value("Body").(UpdateOutcomeRequest)
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(w, "Invalid outcome ID", http.StatusBadRequest)
+		return
+	}
+
+	change := service.OutcomeChange{Title: input.Title, Description: input.Description, Metric: input.Metric}
+	if change == (service.OutcomeChange{}) {
+		response.Error(w, "Nothing to update", http.StatusBadRequest)
+		return
+	}
+
+	outcome, err := h.opps.UpdateOutcome(r.Context(), id, change, input.Actor)
+	if err != nil {
+		curationError(w, "UpdateOutcome:", err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, outcomeResponse(outcome))
+}
+
+// DELETE /api/outcomes/{id}?actor=
+func (h *Handler) DeleteOutcome(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(w, "Invalid outcome ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.opps.DeleteOutcome(r.Context(), id, r.URL.Query().Get("actor")); err != nil {
+		curationError(w, "DeleteOutcome:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /api/opportunities/{id}/solutions
+func (h *Handler) CreateSolution(w http.ResponseWriter, r *http.Request, idStr string) {
+	input := r.Context().Value("Body").(CreateSolutionRequest)
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(w, "Invalid opportunity ID", http.StatusBadRequest)
+		return
+	}
+
+	change := service.SolutionChange{Title: &input.Title, Description: &input.Description}
+	if input.Status != "" {
+		change.Status = &input.Status
+	}
+
+	solution, err := h.opps.CreateSolution(r.Context(), id, change, input.Actor)
+	if err != nil {
+		curationError(w, "CreateSolution:", err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, solutionResponse(solution))
+}
+
+// PATCH /api/solutions/{id}
+func (h *Handler) UpdateSolution(w http.ResponseWriter, r *http.Request, idStr string) {
+	input := r.Context().Value("Body").(UpdateSolutionRequest)
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(w, "Invalid solution ID", http.StatusBadRequest)
+		return
+	}
+
+	change := service.SolutionChange{Title: input.Title, Description: input.Description, Status: input.Status}
+	if change == (service.SolutionChange{}) {
+		response.Error(w, "Nothing to update", http.StatusBadRequest)
+		return
+	}
+
+	solution, err := h.opps.UpdateSolution(r.Context(), id, change, input.Actor)
+	if err != nil {
+		curationError(w, "UpdateSolution:", err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, solutionResponse(solution))
+}
+
+// DELETE /api/solutions/{id}?actor=
+func (h *Handler) DeleteSolution(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(w, "Invalid solution ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.opps.DeleteSolution(r.Context(), id, r.URL.Query().Get("actor")); err != nil {
+		curationError(w, "DeleteSolution:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /api/solutions/{id}/experiments
+func (h *Handler) CreateExperiment(w http.ResponseWriter, r *http.Request, idStr string) {
+	input := r.Context().Value("Body").(CreateExperimentRequest)
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(w, "Invalid solution ID", http.StatusBadRequest)
+		return
+	}
+
+	change := service.ExperimentChange{Assumption: &input.Assumption, Method: &input.Method, Result: &input.Result}
+	if input.Status != "" {
+		change.Status = &input.Status
+	}
+
+	experiment, err := h.opps.CreateExperiment(r.Context(), id, change, input.Actor)
+	if err != nil {
+		curationError(w, "CreateExperiment:", err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, experimentResponse(experiment))
+}
+
+// PATCH /api/experiments/{id}
+func (h *Handler) UpdateExperiment(w http.ResponseWriter, r *http.Request, idStr string) {
+	input := r.Context().Value("Body").(UpdateExperimentRequest)
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(w, "Invalid experiment ID", http.StatusBadRequest)
+		return
+	}
+
+	change := service.ExperimentChange{
+		Assumption: input.Assumption,
+		Method:     input.Method,
+		Status:     input.Status,
+		Result:     input.Result,
+	}
+	if change == (service.ExperimentChange{}) {
+		response.Error(w, "Nothing to update", http.StatusBadRequest)
+		return
+	}
+
+	experiment, err := h.opps.UpdateExperiment(r.Context(), id, change, input.Actor)
+	if err != nil {
+		curationError(w, "UpdateExperiment:", err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, experimentResponse(experiment))
+}
+
+// DELETE /api/experiments/{id}?actor=
+func (h *Handler) DeleteExperiment(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(w, "Invalid experiment ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.opps.DeleteExperiment(r.Context(), id, r.URL.Query().Get("actor")); err != nil {
+		curationError(w, "DeleteExperiment:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// solutionsByOpportunity loads the solutions and experiments of all the given
+// opportunities in a single query.
+func (h *Handler) solutionsByOpportunity(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]SolutionResponse, error) {
+	result := make(map[uuid.UUID][]SolutionResponse, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	rows, err := h.q.ListSolutionsForOpportunities(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rows come ordered by solution, so each solution's experiments are contiguous.
+	for _, row := range rows {
+		solutions := result[row.OpportunityID]
+		if n := len(solutions); n == 0 || solutions[n-1].ID != row.ID {
+			solutions = append(solutions, SolutionResponse{
+				ID:          row.ID,
+				Title:       row.Title,
+				Description: row.Description.String,
+				Status:      row.Status,
+				Created:     row.CreatedAt.Format(time.RFC3339),
+				Experiments: []ExperimentResponse{},
+			})
+		}
+
+		if row.ExperimentID.Valid {
+			s := &solutions[len(solutions)-1]
+			s.Experiments = append(s.Experiments, ExperimentResponse{
+				ID:         row.ExperimentID.UUID,
+				Assumption: row.Assumption.String,
+				Method:     row.Method.String,
+				Status:     row.ExperimentStatus.String,
+				Result:     row.Result.String,
+				Created:    row.ExperimentCreatedAt.Time.Format(time.RFC3339),
+			})
+		}
+		result[row.OpportunityID] = solutions
+	}
+	return result, nil
+}
+
+func outcomeResponse(o repository.Outcome) OutcomeResponse {
+	return OutcomeResponse{
+		ID:          o.ID,
+		Title:       o.Title,
+		Description: o.Description.String,
+		Metric:      o.Metric.String,
+		Created:     o.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func solutionResponse(s repository.Solution) SolutionResponse {
+	return SolutionResponse{
+		ID:          s.ID,
+		Title:       s.Title,
+		Description: s.Description.String,
+		Status:      s.Status,
+		Created:     s.CreatedAt.Format(time.RFC3339),
+		Experiments: []ExperimentResponse{},
+	}
+}
+
+func experimentResponse(e repository.Experiment) ExperimentResponse {
+	return ExperimentResponse{
+		ID:         e.ID,
+		Assumption: e.Assumption,
+		Method:     e.Method.String,
+		Status:     e.Status,
+		Result:     e.Result.String,
+		Created:    e.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func nullUUIDToPtr(id uuid.NullUUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	return &id.UUID
+}
@@ -1,7 +1,11 @@
 package api
 
 import (
+	"encoding/json"
+	"time"
+
 	"github.com/pedy4000/noker/internal/models"
+	"github.com/pedy4000/noker/internal/service"
 
 	"github.com/google/uuid"
 )
@@ -9,9 +13,129 @@ import (
 // Request models
 type CreateMeetingRequest struct {
 	Title    string               `json:"title" validate:"required,min=3,max=100"`
-	Notes    string               `json:"notes" validate:"required,min=10,max=10000"`
+	Notes    string               `json:"notes" validate:"required,min=10,max=100000"`
 	Source   models.MeetingSource `json:"source,omitempty" validate:"omitempty,oneof=manual notion file upload"`
 	Metadata map[string]any       `json:"metadata,omitempty"`
+	Language string               `json:"language,omitempty"` // Postgres text search config, e.g. "english"; default "simple"
+}
+
+type ReprocessMeetingsRequest struct {
+	Status        string     `json:"status,omitempty" validate:"omitempty,oneof=pending retrying done failed dead"`
+	Source        string     `json:"source,omitempty" validate:"omitempty,oneof=manual notion file slack"`
+	CreatedAfter  *time.Time `json:"created_after,omitempty"`
+	CreatedBefore *time.Time `json:"created_before,omitempty"`
+	Limit         int        `json:"limit,omitempty" validate:"omitempty,min=1,max=500"`
+}
+
+type MergeOpportunitiesRequest struct {
+	SourceIDs []uuid.UUID `json:"source_ids" validate:"required,min=1,max=50"`
+	Actor     string      `json:"actor,omitempty" validate:"max=100"` // recorded in the audit log
+}
+
+type SplitOpportunityRequest struct {
+	EvidenceIDs  []uuid.UUID `json:"evidence_ids" validate:"required,min=1,max=500"`
+	Struggle     string      `json:"struggle" validate:"required,min=3,max=200"`
+	UserSegment  string      `json:"user_segment,omitempty" validate:"max=200"` // defaults to the source's
+	WhyItMatters string      `json:"why_it_matters,omitempty" validate:"max=2000"`
+	Workaround   string      `json:"workaround,omitempty" validate:"max=2000"`
+	Theme        string      `json:"theme,omitempty" validate:"max=100"` // defaults to the source's
+	Actor        string      `json:"actor,omitempty" validate:"max=100"`
+}
+
+type UpdateOpportunityRequest struct {
+	UserSegment  *string `json:"user_segment,omitempty" validate:"omitempty,min=1,max=200"`
+	Struggle     *string `json:"struggle,omitempty" validate:"omitempty,min=3,max=200"`
+	WhyItMatters *string `json:"why_it_matters,omitempty" validate:"omitempty,max=2000"` // "" clears it
+	Workaround   *string `json:"workaround,omitempty" validate:"omitempty,max=2000"`     // "" clears it
+	Theme        *string `json:"theme,omitempty" validate:"omitempty,max=100"`           // "" removes the theme
+
+	// Scoring estimates. Impact uses the RICE scale (0.25 minimal … 3 massive), effort is in person-months.
+	Impact     *float64 `json:"impact,omitempty" validate:"omitempty,gt=0,lte=10"`
+	Confidence *float64 `json:"confidence,omitempty" validate:"omitempty,gt=0,lte=1"`
+	Effort     *float64 `json:"effort,omitempty" validate:"omitempty,gt=0,lte=1000"`
+
+	OutcomeID *string `json:"outcome_id,omitempty"` // "" detaches it from its outcome
+	ParentID  *string `json:"parent_id,omitempty"`  // "" makes it a top-level opportunity
+
+	Actor string `json:"actor,omitempty" validate:"max=100"`
+}
+
+type CreateOutcomeRequest struct {
+	Title       string `json:"title" validate:"required,min=3,max=200"`
+	Description string `json:"description,omitempty" validate:"max=2000"`
+	Metric      string `json:"metric,omitempty" validate:"max=200"` // how progress is measured
+	Actor       string `json:"actor,omitempty" validate:"max=100"`
+}
+
+type UpdateOutcomeRequest struct {
+	Title       *string `json:"title,omitempty" validate:"omitempty,min=3,max=200"`
+	Description *string `json:"description,omitempty" validate:"omitempty,max=2000"` // "" clears it
+	Metric      *string `json:"metric,omitempty" validate:"omitempty,max=200"`       // "" clears it
+	Actor       string  `json:"actor,omitempty" validate:"max=100"`
+}
+
+type CreateSolutionRequest struct {
+	Title       string `json:"title" validate:"required,min=3,max=200"`
+	Description string `json:"description,omitempty" validate:"max=2000"`
+	Status      string `json:"status,omitempty" validate:"omitempty,oneof=idea exploring building shipped discarded"`
+	Actor       string `json:"actor,omitempty" validate:"max=100"`
+}
+
+type UpdateSolutionRequest struct {
+	Title       *string `json:"title,omitempty" validate:"omitempty,min=3,max=200"`
+	Description *string `json:"description,omitempty" validate:"omitempty,max=2000"` // "" clears it
+	Status      *string `json:"status,omitempty" validate:"omitempty,oneof=idea exploring building shipped discarded"`
+	Actor       string  `json:"actor,omitempty" validate:"max=100"`
+}
+
+type CreateExperimentRequest struct {
+	Assumption string `json:"assumption" validate:"required,min=3,max=500"`
+	Method     string `json:"method,omitempty" validate:"max=500"` // e.g. "fake door test", "5 customer interviews"
+	Status     string `json:"status,omitempty" validate:"omitempty,oneof=planned running validated invalidated"`
+	Result     string `json:"result,omitempty" validate:"max=2000"`
+	Actor      string `json:"actor,omitempty" validate:"max=100"`
+}
+
+type UpdateExperimentRequest struct {
+	Assumption *string `json:"assumption,omitempty" validate:"omitempty,min=3,max=500"`
+	Method     *string `json:"method,omitempty" validate:"omitempty,max=500"` // "" clears it
+	Status     *string `json:"status,omitempty" validate:"omitempty,oneof=planned running validated invalidated"`
+	Result     *string `json:"result,omitempty" validate:"omitempty,max=2000"` // "" clears it
+	Actor      string  `json:"actor,omitempty" validate:"max=100"`
+}
+
+type ScoringWeightsRequest struct {
+	ReachPerCustomer float64 `json:"reach_per_customer" validate:"gte=0,lte=100"`
+	ReachPerEvidence float64 `json:"reach_per_evidence" validate:"gte=0,lte=100"`
+	ReachWeight      float64 `json:"reach_weight" validate:"gte=0,lte=5"`
+	ImpactWeight     float64 `json:"impact_weight" validate:"gte=0,lte=5"`
+	ConfidenceWeight float64 `json:"confidence_weight" validate:"gte=0,lte=5"`
+	EffortWeight     float64 `json:"effort_weight" validate:"gte=0,lte=5"`
+}
+
+type UpdateThemeRequest struct {
+	Name  string `json:"name" validate:"required,min=2,max=100"`
+	Actor string `json:"actor,omitempty" validate:"max=100"`
+}
+
+type MergeThemesRequest struct {
+	Into  string `json:"into" validate:"required,min=2,max=100"`
+	Actor string `json:"actor,omitempty" validate:"max=100"`
+}
+
+// CreateWebhookRequest subscribes URL to events, see the webhook package; "*" means all of them.
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" validate:"required,http_url,max=2000"`
+	Events      []string `json:"events" validate:"required,min=1,dive,oneof=* meeting.processed meeting.failed opportunity.created opportunity.evidence_added theme.created"`
+	Secret      string   `json:"secret,omitempty" validate:"omitempty,min=16,max=200"` // generated when empty
+	Description string   `json:"description,omitempty" validate:"max=500"`
+}
+
+type UpdateWebhookRequest struct {
+	URL         *string  `json:"url,omitempty" validate:"omitempty,http_url,max=2000"`
+	Events      []string `json:"events,omitempty" validate:"omitempty,min=1,dive,oneof=* meeting.processed meeting.failed opportunity.created opportunity.evidence_added theme.created"`
+	Description *string  `json:"description,omitempty" validate:"omitempty,max=500"` // "" clears it
+	Active      *bool    `json:"active,omitempty"`
 }
 
 // Response models
@@ -22,16 +146,28 @@ type CreateMeetingResponse struct {
 	ProcessedIn string    `json:"processed_in"`
 }
 
+type ReprocessResponse struct {
+	Status               string      `json:"status"`
+	MeetingIDs           []uuid.UUID `json:"meeting_ids"`
+	DeletedOpportunities int         `json:"deleted_opportunities"`
+	Message              string      `json:"message"`
+}
+
 type MeetingResponse struct {
-	ID        uuid.UUID `json:"id"`
-	Title     string    `json:"title"`
-	Source    string    `json:"source"`
-	Metadata  string    `json:"metadata,omitempty"`
-	Status    string    `json:"status"`
-	Message   string    `json:"message"`
-	Created   string    `json:"created"`
-	Processed string    `json:"processed"`
-	Updated   string    `json:"updated"`
+	ID               uuid.UUID       `json:"id"`
+	Title            string          `json:"title"`
+	Source           string          `json:"source"`
+	Metadata         string          `json:"metadata,omitempty"`
+	Status           string          `json:"status"`
+	Message          string          `json:"message"`
+	Attempts         int             `json:"attempts"`
+	ErrorHistory     json.RawMessage `json:"error_history,omitempty"`
+	ValidationIssues json.RawMessage `json:"validation_issues,omitempty"`
+	NextAttempt      string          `json:"next_attempt,omitempty"`
+	EvidenceCount    int             `json:"evidence_count,omitempty"`
+	Created          string          `json:"created"`
+	Processed        string          `json:"processed"`
+	Updated          string          `json:"updated"`
 }
 
 type OpportunityResponse struct {
@@ -44,6 +180,79 @@ type OpportunityResponse struct {
 	EvidenceCount int                `json:"evidence_count"`
 	Evidences     []EvidenceResponse `json:"evidence,omitempty"`
 	Created       string             `json:"created"`
+
+	Impact     *float64 `json:"impact,omitempty"`
+	Confidence *float64 `json:"confidence,omitempty"`
+	Effort     *float64 `json:"effort,omitempty"`
+
+	Customers *CustomerSummary `json:"customers,omitempty"`
+
+	OutcomeID *uuid.UUID         `json:"outcome_id,omitempty"`
+	Solutions []SolutionResponse `json:"solutions,omitempty"`
+
+	ParentID *uuid.UUID            `json:"parent_id,omitempty"`
+	Children []OpportunityResponse `json:"children,omitempty"` // only in the subtree and the tree
+}
+
+type OutcomeResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	Metric      string    `json:"metric,omitempty"`
+	Created     string    `json:"created"`
+
+	Opportunities []OpportunityResponse `json:"opportunities,omitempty"` // only in the tree
+}
+
+type SolutionResponse struct {
+	ID          uuid.UUID            `json:"id"`
+	Title       string               `json:"title"`
+	Description string               `json:"description,omitempty"`
+	Status      string               `json:"status"`
+	Created     string               `json:"created"`
+	Experiments []ExperimentResponse `json:"experiments"`
+}
+
+type ExperimentResponse struct {
+	ID         uuid.UUID `json:"id"`
+	Assumption string    `json:"assumption"`
+	Method     string    `json:"method,omitempty"`
+	Status     string    `json:"status"`
+	Result     string    `json:"result,omitempty"`
+	Created    string    `json:"created"`
+}
+
+// TreeResponse is the whole Opportunity Solution Tree: outcomes, the opportunities
+// rolling up to them, and their solutions and experiments.
+type TreeResponse struct {
+	Outcomes   []OutcomeResponse     `json:"outcomes"`
+	Unassigned []OpportunityResponse `json:"unassigned"` // opportunities not linked to an outcome yet, nested like the outcomes
+}
+
+// CustomerSummary describes the customers an opportunity came up with.
+type CustomerSummary struct {
+	Count     int            `json:"count"`
+	ARR       float64        `json:"arr"`   // total ARR of those customers, where known
+	Plans     map[string]int `json:"plans"` // customers per plan
+	Customers []CustomerRef  `json:"list"`
+}
+
+type CustomerRef struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+type CustomerResponse struct {
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	Plan         string    `json:"plan,omitempty"`
+	ARR          *float64  `json:"arr,omitempty"`
+	MeetingCount int       `json:"meeting_count,omitempty"`
+}
+
+type CustomerOpportunitiesResponse struct {
+	Customer      CustomerResponse      `json:"customer"`
+	Opportunities []OpportunityResponse `json:"opportunities"`
 }
 
 type EvidenceResponse struct {
@@ -52,6 +261,14 @@ type EvidenceResponse struct {
 	Context string    `json:"context"`
 	Created string    `json:"created"`
 
+	ChunkIndex  int `json:"chunk_index"`
+	ChunkOffset int `json:"chunk_offset"` // where the quote starts in the meeting notes, in characters
+
+	// Whether the quote was found in the meeting notes, and where (characters, end exclusive).
+	Verified   bool `json:"verified"`
+	QuoteStart *int `json:"quote_start,omitempty"`
+	QuoteEnd   *int `json:"quote_end,omitempty"`
+
 	MeetingID    uuid.UUID `json:"meeting_id"`
 	MeetingTitle string    `json:"meeting_title"`
 	MeetingDate  string    `json:"meeting_date"`
@@ -61,3 +278,119 @@ type ThemeResponse struct {
 	Name          string                `json:"theme_name"`
 	Opportunities []OpportunityResponse `json:"opportunities"`
 }
+
+type MergeOpportunitiesResponse struct {
+	OpportunityID     uuid.UUID   `json:"opportunity_id"`
+	MergedIDs         []uuid.UUID `json:"merged_ids"`
+	MovedEvidence     int         `json:"moved_evidence"`
+	DroppedDuplicates int         `json:"dropped_duplicates"`
+}
+
+type SplitOpportunityResponse struct {
+	SourceID      uuid.UUID `json:"source_id"`
+	OpportunityID uuid.UUID `json:"opportunity_id"`
+	MovedEvidence int       `json:"moved_evidence"`
+}
+
+type AuditEntryResponse struct {
+	ID      uuid.UUID       `json:"id"`
+	Action  string          `json:"action"`
+	Actor   string          `json:"actor,omitempty"`
+	Details json.RawMessage `json:"details"`
+	Created string          `json:"created"`
+}
+
+type MergeThemesResponse struct {
+	Theme              string `json:"theme_name"`
+	MovedOpportunities int    `json:"moved_opportunities"`
+}
+
+type OpportunityListResponse struct {
+	Opportunities []OpportunityResponse `json:"opportunities"`
+	NextCursor    string                `json:"next_cursor,omitempty"`
+}
+
+type PrioritizedOpportunitiesResponse struct {
+	Framework     string                           `json:"framework"`
+	Weights       service.ScoringWeights           `json:"weights"`
+	Opportunities []PrioritizedOpportunityResponse `json:"opportunities"`
+}
+
+type PrioritizedOpportunityResponse struct {
+	Rank          int           `json:"rank"`
+	ID            uuid.UUID     `json:"id"`
+	UserSegment   string        `json:"user_segment"`
+	Struggle      string        `json:"struggle"`
+	Theme         string        `json:"theme,omitempty"`
+	Score         float64       `json:"score"`
+	Factors       service.Score `json:"factors"`
+	EvidenceCount int           `json:"evidence_count"`
+	CustomerCount int           `json:"customer_count"`
+	Created       string        `json:"created"`
+}
+
+type MeetingListResponse struct {
+	Meetings   []MeetingResponse `json:"meetings"`
+	NextCursor string            `json:"next_cursor,omitempty"` // pass as ?cursor= for the next page
+}
+
+type MeetingDetailResponse struct {
+	MeetingResponse
+	RawNotes      string                `json:"raw_notes"`
+	Opportunities []OpportunityResponse `json:"opportunities"` // evidence limited to this meeting
+}
+
+type SearchResponse struct {
+	Query   string         `json:"query"`
+	Results []SearchResult `json:"results"`
+}
+
+// SearchResult is one matching opportunity with the quotes that matched, best first.
+// Highlights wrap matched words in <mark></mark>.
+type SearchResult struct {
+	OpportunityID     uuid.UUID     `json:"opportunity_id"`
+	Struggle          string        `json:"struggle"`
+	StruggleHighlight string        `json:"struggle_highlight"`
+	UserSegment       string        `json:"user_segment"`
+	Theme             string        `json:"theme,omitempty"`
+	Score             float64       `json:"score"`
+	Matches           []SearchMatch `json:"matches"`
+}
+
+type SearchMatch struct {
+	EvidenceID uuid.UUID `json:"evidence_id"`
+	MeetingID  uuid.UUID `json:"meeting_id"`
+	Quote      string    `json:"quote"`
+	Highlight  string    `json:"highlight"`
+	Rank       float64   `json:"rank"`
+}
+
+type WebhookResponse struct {
+	ID          uuid.UUID `json:"id"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events"`
+	Description string    `json:"description,omitempty"`
+	Active      bool      `json:"active"`
+	Secret      string    `json:"secret,omitempty"` // only returned when the webhook is created
+	Created     string    `json:"created"`
+	Updated     string    `json:"updated"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID             uuid.UUID       `json:"id"`
+	WebhookID      uuid.UUID       `json:"webhook_id"`
+	Event          string          `json:"event"`
+	Status         string          `json:"status"` // pending, delivered or failed
+	Attempts       int             `json:"attempts"`
+	ResponseStatus *int32          `json:"response_status,omitempty"`
+	Error          string          `json:"error,omitempty"`
+	NextAttempt    string          `json:"next_attempt,omitempty"` // only while pending
+	Delivered      string          `json:"delivered,omitempty"`
+	Created        string          `json:"created"`
+	Payload        json.RawMessage `json:"payload"`
+}
+
+type WebhookDeliveryListResponse struct {
+	Deliveries []WebhookDeliveryResponse `json:"deliveries"`
+	NextCursor string                    `json:"next_cursor,omitempty"`
+}
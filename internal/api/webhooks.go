@@ -0,0 +1,268 @@
+package api
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pedy4000/noker/internal/api/response"
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/pkg/logger"
+	"github.com/pedy4000/noker/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+var deliveryStatuses = map[string]bool{"pending": true, "delivered": true, "failed": true}
+
+// GET /api/webhooks
+func (h *Handler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	hooks, err := h.q.ListWebhooks(r.Context())
+	if err != nil {
+		logger.Error("ListWebhooks:", err)
+		response.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	result := make([]WebhookResponse, len(hooks))
+	for i, hook := range hooks {
+		result[i] = webhookResponse(repository.GetWebhookRow(hook))
+	}
+
+	response.JSON(w, http.StatusOK, result)
+}
+
+// POST /api/webhooks
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	input := r.Context().Value("Body").(CreateWebhookRequest)
+
+	secret := input.Secret
+	if secret == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			logger.Error("CreateWebhook:", err)
+			response.Error(w, "Failed to generate secret", http.StatusInternalServerError)
+			return
+		}
+		secret = "whsec_" + hex.EncodeToString(buf)
+	}
+
+	hook, err := h.q.CreateWebhook(r.Context(), repository.CreateWebhookParams{
+		Url:         input.URL,
+		Secret:      secret,
+		Events:      strings.Join(input.Events, ","),
+		Description: utils.ToNullString(input.Description),
+	})
+	if err != nil {
+		logger.Error("CreateWebhook:", err)
+		response.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	// The only time the secret is shown.
+	resp := webhookResponse(repository.GetWebhookRow(hook))
+	resp.Secret = hook.Secret
+	response.JSON(w, http.StatusCreated, resp)
+}
+
+// GET /api/webhooks/{id}
+func (h *Handler) GetWebhook(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	hook, err := h.q.GetWebhook(r.Context(), id)
+	if err != nil {
+		webhookError(w, "GetWebhook:", err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, webhookResponse(hook))
+}
+
+// PATCH /api/webhooks/{id}
+func (h *Handler) UpdateWebhook(w http.ResponseWriter, r *http.Request, idStr string) {
+	input := r.Context().Value("Body").(UpdateWebhookRequest)
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	if input.URL == nil && input.Events == nil && input.Description == nil && input.Active == nil {
+		response.Error(w, "Nothing to update", http.StatusBadRequest)
+		return
+	}
+
+	params := repository.UpdateWebhookParams{
+		ID:          id,
+		Url:         utils.PtrToNullString(input.URL),
+		Description: utils.PtrToNullString(input.Description),
+	}
+	if len(input.Events) > 0 {
+		params.Events = sql.NullString{String: strings.Join(input.Events, ","), Valid: true}
+	}
+	if input.Active != nil {
+		params.Active = sql.NullBool{Bool: *input.Active, Valid: true}
+	}
+
+	hook, err := h.q.UpdateWebhook(r.Context(), params)
+	if err != nil {
+		webhookError(w, "UpdateWebhook:", err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, webhookResponse(repository.GetWebhookRow(hook)))
+}
+
+// DELETE /api/webhooks/{id}
+// Its delivery log goes with it.
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	n, err := h.q.DeleteWebhook(r.Context(), id)
+	if err != nil {
+		webhookError(w, "DeleteWebhook:", err)
+		return
+	}
+	if n == 0 {
+		response.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /api/webhooks/{id}/deliveries?status=&event=&limit=&cursor=
+func (h *Handler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	limit := pageSize(query.Get("limit"))
+	params := repository.ListWebhookDeliveriesParams{
+		WebhookID: id,
+		Status:    utils.ToNullString(query.Get("status")),
+		Event:     utils.ToNullString(query.Get("event")),
+		MaxRows:   int32(limit + 1),
+	}
+	if params.Status.Valid && !deliveryStatuses[params.Status.String] {
+		response.Error(w, "Invalid status, expected pending, delivered or failed", http.StatusBadRequest)
+		return
+	}
+	filter := filterHash(id.String(), query.Get("status"), query.Get("event"))
+	if c := query.Get("cursor"); c != "" {
+		cur, err := parseCursor(c, "", filter)
+		if err != nil {
+			cursorError(w, err)
+			return
+		}
+		params.CursorCreated = sql.NullTime{Time: cur.Time, Valid: true}
+		params.CursorID = utils.ToNullUUID(cur.ID)
+	}
+
+	if _, err := h.q.GetWebhook(r.Context(), id); err != nil {
+		webhookError(w, "GetWebhook:", err)
+		return
+	}
+
+	rows, err := h.q.ListWebhookDeliveries(r.Context(), params)
+	if err != nil {
+		logger.Error("ListWebhookDeliveries:", err)
+		response.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := WebhookDeliveryListResponse{Deliveries: make([]WebhookDeliveryResponse, 0, len(rows))}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		resp.NextCursor = cursor{Filter: filter, Time: last.CreatedAt, ID: last.ID}.String()
+	}
+	for _, d := range rows {
+		resp.Deliveries = append(resp.Deliveries, deliveryResponse(d))
+	}
+
+	response.JSON(w, http.StatusOK, resp)
+}
+
+// POST /api/webhooks/deliveries/{id}/retry
+// Sends a delivery again on the dispatcher's next poll, e.g. once the receiver is fixed.
+func (h *Handler) RetryWebhookDelivery(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(w, "Invalid delivery ID", http.StatusBadRequest)
+		return
+	}
+
+	d, err := h.q.RetryWebhookDelivery(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			response.Error(w, "Delivery not found", http.StatusNotFound)
+		} else {
+			logger.Error("RetryWebhookDelivery:", err)
+			response.Error(w, "Database error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	response.JSON(w, http.StatusAccepted, deliveryResponse(repository.ListWebhookDeliveriesRow(d)))
+}
+
+func webhookError(w http.ResponseWriter, op string, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		response.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+	logger.Error(op, err)
+	response.Error(w, "Database error", http.StatusInternalServerError)
+}
+
+func webhookResponse(hook repository.GetWebhookRow) WebhookResponse {
+	return WebhookResponse{
+		ID:          hook.ID,
+		URL:         hook.Url,
+		Events:      strings.Split(hook.Events, ","),
+		Description: hook.Description.String,
+		Active:      hook.Active,
+		Created:     hook.CreatedAt.Format(time.RFC3339),
+		Updated:     hook.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func deliveryResponse(d repository.ListWebhookDeliveriesRow) WebhookDeliveryResponse {
+	resp := WebhookDeliveryResponse{
+		ID:        d.ID,
+		WebhookID: d.WebhookID,
+		Event:     d.Event,
+		Status:    d.Status,
+		Attempts:  int(d.Attempts),
+		Error:     d.LastError.String,
+		Created:   d.CreatedAt.Format(time.RFC3339),
+		Payload:   d.Payload,
+	}
+	if d.ResponseStatus.Valid {
+		resp.ResponseStatus = &d.ResponseStatus.Int32
+	}
+	if d.Status == "pending" {
+		resp.NextAttempt = d.NextAttemptAt.Format(time.RFC3339)
+	}
+	if d.DeliveredAt.Valid {
+		resp.Delivered = d.DeliveredAt.Time.Format(time.RFC3339)
+	}
+	return resp
+}
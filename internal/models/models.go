@@ -13,6 +13,7 @@ const (
 	SourceManual MeetingSource = "manual"
 	SourceNotion MeetingSource = "notion"
 	SourceFile   MeetingSource = "file"
+	SourceSlack  MeetingSource = "slack" // added with the /ost add slash command
 )
 
 // Meeting represents a raw discovery/demo call
@@ -60,6 +61,7 @@ type Theme struct {
 type ExtractedOpportunity struct {
 	Type                  string          `json:"type"`
 	ExistingOpportunityID string          `json:"existing_opportunity_id"`
+	ParentOpportunityID   string          `json:"parent_opportunity_id,omitempty"` // new only: the broader opportunity it belongs under
 	UserSegment           string          `json:"user_segment"`
 	Struggle              string          `json:"struggle"`
 	WhyItMatters          string          `json:"why_it_matters,omitempty"`
@@ -71,4 +73,16 @@ type ExtractedOpportunity struct {
 type EvidenceQuote struct {
 	Quote   string `json:"quote"`
 	Context string `json:"context,omitempty"`
+
+	// Set by chunked extraction: the chunk the quote was found in and the
+	// quote's character offset in the raw notes (the chunk's start when the
+	// quote can't be located in it).
+	ChunkIndex  int `json:"chunk_index,omitempty"`
+	ChunkOffset int `json:"chunk_offset,omitempty"`
+
+	// Set by quote verification: whether the quote was found in the raw notes,
+	// and where (character offsets, end exclusive).
+	Verified   bool `json:"verified,omitempty"`
+	QuoteStart int  `json:"quote_start,omitempty"`
+	QuoteEnd   int  `json:"quote_end,omitempty"`
 }
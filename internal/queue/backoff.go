@@ -0,0 +1,29 @@
+package queue
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// backoff returns the delay before the given retry attempt (1-based).
+// It doubles from base up to max and keeps half of it random, so meetings that
+// failed together (e.g. during a provider outage) don't all retry at once.
+func backoff(attempt int, base, max time.Duration) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	delay := base
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + rand.N(half)
+}
@@ -0,0 +1,120 @@
+package queue
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/pedy4000/noker/internal/models"
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/pkg/logger"
+)
+
+const embeddingBatchSize = 100
+
+// candidates returns the existing opportunities the model should consider matching
+// against: the nearest top_k by embedding, or all of them when embeddings are off
+// or fail (a bigger prompt beats a failed job).
+func (w *runner) candidates(ctx context.Context, meeting *models.Meeting) ([]repository.ListAllOpportunitiesForDeduplicationRow, error) {
+	if w.embedder == nil {
+		return w.queries.ListAllOpportunitiesForDeduplication(ctx)
+	}
+
+	nearest, err := w.nearestOpportunities(ctx, meeting)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		logger.Error("Embedding retrieval failed, sending all opportunities:", meeting.ID, err)
+		return w.queries.ListAllOpportunitiesForDeduplication(ctx)
+	}
+
+	return nearest, nil
+}
+
+func (w *runner) nearestOpportunities(ctx context.Context, meeting *models.Meeting) ([]repository.ListAllOpportunitiesForDeduplicationRow, error) {
+	if err := w.backfillEmbeddings(ctx); err != nil {
+		return nil, err
+	}
+
+	text := meeting.Title + "\n\n" + meeting.RawNotes
+	if limit := w.cfg.AI.Embedding.MaxInputChars; limit > 0 {
+		if runes := []rune(text); len(runes) > limit {
+			text = string(runes[:limit])
+		}
+	}
+
+	vectors, err := w.embedder.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := w.queries.ListNearestOpportunities(ctx, repository.ListNearestOpportunitiesParams{
+		Model:     w.cfg.AI.Embedding.Model,
+		Embedding: formatVector(vectors[0]),
+		TopK:      int32(w.cfg.AI.Embedding.TopK),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	opps := make([]repository.ListAllOpportunitiesForDeduplicationRow, len(rows))
+	for i, r := range rows {
+		opps[i] = repository.ListAllOpportunitiesForDeduplicationRow(r)
+	}
+	return opps, nil
+}
+
+// backfillEmbeddings embeds opportunities that are new, edited, or were embedded by
+// another model. Opportunities created by the previous job are picked up here.
+func (w *runner) backfillEmbeddings(ctx context.Context) error {
+	for {
+		missing, err := w.queries.ListOpportunitiesMissingEmbedding(ctx, repository.ListOpportunitiesMissingEmbeddingParams{
+			Model:   w.cfg.AI.Embedding.Model,
+			MaxRows: embeddingBatchSize,
+		})
+		if err != nil || len(missing) == 0 {
+			return err
+		}
+
+		texts := make([]string, len(missing))
+		for i, o := range missing {
+			texts[i] = o.Struggle + " | " + o.ThemeName
+		}
+
+		vectors, err := w.embedder.Embed(ctx, texts)
+		if err != nil {
+			return err
+		}
+
+		for i, o := range missing {
+			if err := w.queries.UpsertOpportunityEmbedding(ctx, repository.UpsertOpportunityEmbeddingParams{
+				OpportunityID: o.ID,
+				Embedding:     formatVector(vectors[i]),
+				Model:         w.cfg.AI.Embedding.Model,
+				ContentHash:   o.ContentHash,
+			}); err != nil {
+				return err
+			}
+		}
+		logger.Debug("Embedded", len(missing), "opportunities")
+
+		if len(missing) < embeddingBatchSize {
+			return nil
+		}
+	}
+}
+
+// formatVector renders v in pgvector's text form, e.g. [0.1,0.2].
+func formatVector(v []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, x := range v {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(x), 'f', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
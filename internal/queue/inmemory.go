@@ -1,15 +1,10 @@
 package queue
 
 import (
-	"context"
-	"fmt"
 	"sync"
 	"time"
 
-	"github.com/pedy4000/noker/internal/ai"
-	"github.com/pedy4000/noker/internal/models"
 	"github.com/pedy4000/noker/internal/repository"
-	"github.com/pedy4000/noker/internal/service"
 	"github.com/pedy4000/noker/pkg/config"
 	"github.com/pedy4000/noker/pkg/logger"
 
@@ -17,36 +12,64 @@ import (
 )
 
 type InMemoryWorker struct {
-	queries      *repository.Queries
-	extractor    ai.Provider
-	service      *service.OpportunityService
-	cfg          *config.Config
+	runner
 	jobs         chan Job
 	wg           sync.WaitGroup
 	shutdown     chan struct{}
 	shutdownOnce sync.Once
+
+	// mu guards stopped so Enqueue never races with Stop.
+	mu      sync.RWMutex
+	stopped bool
+
+	// queued holds the meetings waiting in jobs, so the reaper can't fill the
+	// buffer with meetings that are already on their way to a worker.
+	queuedMu sync.Mutex
+	queued   map[uuid.UUID]bool
 }
 
-func NewInMemoryWorker(queries *repository.Queries, cfg *config.Config) *InMemoryWorker {
+func NewInMemoryWorker(queries *repository.Store, cfg *config.Config) *InMemoryWorker {
 	return &InMemoryWorker{
-		queries:   queries,
-		extractor: ai.NewExtractor(cfg),
-		service:   service.NewOpportunityService(queries),
-		cfg:       cfg,
-		jobs:      make(chan Job, cfg.Queue.BufferSize),
-		shutdown:  make(chan struct{}),
+		runner:   newRunner(queries, cfg),
+		jobs:     make(chan Job, cfg.Queue.BufferSize),
+		shutdown: make(chan struct{}),
+		queued:   map[uuid.UUID]bool{},
 	}
 }
 
 func (w *InMemoryWorker) Enqueue(meetingID uuid.UUID) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.stopped {
+		logger.Info("Queue is stopping, meeting", meetingID, "stays pending until next start")
+		return
+	}
+
+	w.queuedMu.Lock()
+	defer w.queuedMu.Unlock()
+	if w.queued[meetingID] {
+		logger.Debug("Meeting", meetingID, "is already queued")
+		return
+	}
+
 	select {
 	case w.jobs <- Job{MeetingID: meetingID}:
+		w.queued[meetingID] = true
 		logger.Debug("Job enqueued for meeting:", meetingID)
 	default:
 		logger.Error("Job queue is full! Dropping job for meeting:", meetingID)
 	}
 }
 
+// dequeued is called once a worker takes a meeting off the queue; from then on
+// it can be queued again, e.g. to reprocess it.
+func (w *InMemoryWorker) dequeued(meetingID uuid.UUID) {
+	w.queuedMu.Lock()
+	delete(w.queued, meetingID)
+	w.queuedMu.Unlock()
+}
+
 func (w *InMemoryWorker) Start() {
 	for i := 0; i < w.cfg.Queue.WorkerCount; i++ {
 		w.wg.Add(1)
@@ -59,14 +82,23 @@ func (w *InMemoryWorker) Start() {
 					logger.Info("Worker", id, "shutting down")
 					return
 				case job := <-w.jobs:
-					w.processJob(job)
+					w.dequeued(job.MeetingID)
+					if delay, retry := w.processJob(job); retry {
+						w.retryLater(job, delay)
+					}
 				}
 			}
 		}(i)
 	}
 
-	// Health ping
+	// Recovery: pick up everything a previous run left behind, then keep
+	// reaping meetings that got stuck (lost jobs, expired leases). It's tracked in wg
+	// like the workers, so Stop doesn't return while it can still enqueue.
+	w.wg.Add(1)
 	go func() {
+		defer w.wg.Done()
+		w.recoverMeetings(time.Now(), w.Enqueue)
+
 		ticker := time.NewTicker(time.Duration(w.cfg.Queue.PollIntervalMs) * time.Millisecond)
 		defer ticker.Stop()
 		for {
@@ -74,81 +106,50 @@ func (w *InMemoryWorker) Start() {
 			case <-w.shutdown:
 				return
 			case <-ticker.C:
-				// Keep connection alive
+				w.recoverMeetings(time.Now().Add(-w.leaseDuration()), w.Enqueue)
 			}
 		}
 	}()
 }
 
-func (w *InMemoryWorker) processJob(job Job) {
-	ctx := context.Background()
-	meeting, err := w.queries.GetMeeting(ctx, job.MeetingID)
-	if err != nil {
-		logger.Error("Failed to fetch meeting:", job.MeetingID, err)
-		return
-	}
-
-	w.setMeetingStatus(meeting.ID, "processing")
-
-	m := &models.Meeting{
-		ID:       meeting.ID,
-		Title:    meeting.Title,
-		RawNotes: meeting.RawNotes,
-		Source:   models.MeetingSource(meeting.Source),
-	}
-
-	opps, err := w.queries.ListAllOpportunitiesForDeduplication(ctx)
-	if err != nil {
-		logger.Error("Failed to fetch opportunities:", job.MeetingID, err)
-		return
-	}
-
-	extracted, err := w.extractor.Extract(ctx, m, opps)
-	if err != nil {
-		w.setMeetingStatus(meeting.ID, "failed",
-			fmt.Sprintf("AI extraction failed: %v", err))
-		logger.Error("AI extraction failed for meeting", job.MeetingID, err)
-		return
-	}
-
-	if err := w.service.ProcessExtractedOpportunities(ctx, job.MeetingID, extracted); err != nil {
-		w.setMeetingStatus(meeting.ID, "failed",
-			fmt.Sprintf("Failed to save opportunities: %v", err))
-		logger.Error("Failed to save opportunities:", err)
-		return
-	}
-
-	w.setMeetingStatus(meeting.ID, "done")
-	logger.Debug("Successfully processed meeting:", job.MeetingID, "→", len(extracted), "opportunities")
+// retryLater puts the job back on the queue once its backoff delay has passed.
+func (w *InMemoryWorker) retryLater(job Job, delay time.Duration) {
+	time.AfterFunc(delay, func() {
+		select {
+		case <-w.shutdown:
+			logger.Info("Worker stopped, meeting", job.MeetingID, "will be picked up on next start")
+		default:
+			w.Enqueue(job.MeetingID)
+		}
+	})
 }
 
+// Stop drains the queue in stages: stop accepting jobs, let in-flight jobs finish
+// (or interrupt them after shutdown_timeout_sec), then leave whatever is still
+// queued in the DB for the next start's recovery sweep.
 func (w *InMemoryWorker) Stop() {
 	w.shutdownOnce.Do(func() {
+		w.mu.Lock()
+		w.stopped = true
+		w.mu.Unlock()
+		logger.Info("Queue stopped accepting new jobs")
+
 		close(w.shutdown)
-		close(w.jobs)
-		w.wg.Wait()
+		w.waitForWorkers(&w.wg)
 		logger.Info("All workers stopped")
-	})
-}
-
-func (w *InMemoryWorker) setMeetingStatus(meetingID uuid.UUID, status string, errMsg ...string) {
-	ctx := context.Background()
 
-	params := repository.UpdateMeetingStatusParams{
-		ID:               meetingID,
-		ProcessingStatus: status,
-		Column3:          "",
-	}
-
-	if len(errMsg) > 0 {
-		message := errMsg[0]
-		if len(message) > 200 {
-			message = message[:197] + "..." // Database limitation
+		// The jobs channel is never closed, so a late Enqueue can't panic.
+		// Queued meetings are still "pending" in the DB; recovery picks them up.
+		left := 0
+		for drained := false; !drained; {
+			select {
+			case job := <-w.jobs:
+				left++
+				logger.Debug("Meeting", job.MeetingID, "left pending for next start")
+			default:
+				drained = true
+			}
 		}
-		params.Column3 = message
-	}
-
-	if err := w.queries.UpdateMeetingStatus(ctx, params); err != nil {
-		logger.Error("Failed to update meeting status", "meeting_id", meetingID, "error", err)
-	}
+		logger.Info(left, "queued meetings persisted for next start")
+	})
 }
@@ -0,0 +1,167 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/pkg/config"
+	"github.com/pedy4000/noker/pkg/logger"
+	"github.com/pedy4000/noker/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// PostgresWorker keeps jobs in the jobs table so they survive restarts and can be
+// shared by several worker-only instances. Jobs are claimed with FOR UPDATE SKIP LOCKED,
+// so two workers never pick the same meeting.
+type PostgresWorker struct {
+	runner
+	instanceID   string
+	wg           sync.WaitGroup
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+}
+
+func NewPostgresWorker(queries *repository.Store, cfg *config.Config) *PostgresWorker {
+	host, _ := os.Hostname()
+
+	return &PostgresWorker{
+		runner:     newRunner(queries, cfg),
+		instanceID: fmt.Sprintf("%s-%d", host, os.Getpid()),
+		shutdown:   make(chan struct{}),
+	}
+}
+
+func (w *PostgresWorker) Enqueue(meetingID uuid.UUID) {
+	if err := w.queries.EnqueueJob(context.Background(), meetingID); err != nil {
+		logger.Error("Failed to enqueue job for meeting:", meetingID, err)
+		return
+	}
+	logger.Debug("Job enqueued for meeting:", meetingID)
+}
+
+// EnqueueTx inserts the job with q, usually inside the caller's transaction.
+func (w *PostgresWorker) EnqueueTx(ctx context.Context, q *repository.Queries, meetingID uuid.UUID) error {
+	return q.EnqueueJob(ctx, meetingID)
+}
+
+func (w *PostgresWorker) Start() {
+	interval := time.Duration(w.cfg.Queue.PollIntervalMs) * time.Millisecond
+
+	// Recovery: meetings that never got a job row (e.g. created while running the
+	// in-memory queue) are enqueued once, then the reaper keeps freeing jobs and
+	// meetings whose worker died mid-way.
+	w.recoverMeetings(time.Now(), w.Enqueue)
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.shutdown:
+				return
+			case <-ticker.C:
+				w.reap()
+			}
+		}
+	}()
+
+	for i := 0; i < w.cfg.Queue.WorkerCount; i++ {
+		w.wg.Add(1)
+		go func(id int) {
+			defer w.wg.Done()
+			workerID := fmt.Sprintf("%s/%d", w.instanceID, id)
+			logger.Info("Worker", workerID, "started")
+
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-w.shutdown:
+					logger.Info("Worker", workerID, "shutting down")
+					return
+				case <-ticker.C:
+					w.drain(workerID)
+				}
+			}
+		}(i)
+	}
+}
+
+// reap requeues jobs held longer than the lease and recovers stuck meetings.
+func (w *PostgresWorker) reap() {
+	staleBefore := time.Now().Add(-w.leaseDuration())
+
+	n, err := w.queries.RequeueStaleJobs(context.Background(), sql.NullTime{Time: staleBefore, Valid: true})
+	if err != nil {
+		logger.Error("Failed to requeue stale jobs:", err)
+	} else if n > 0 {
+		logger.Info("Requeued", n, "stale jobs")
+	}
+
+	w.recoverMeetings(staleBefore, w.Enqueue)
+}
+
+// drain keeps claiming jobs until the queue is empty or shutdown is requested.
+func (w *PostgresWorker) drain(workerID string) {
+	ctx := context.Background()
+	for {
+		select {
+		case <-w.shutdown:
+			return
+		default:
+		}
+
+		job, err := w.queries.ClaimNextJob(ctx, utils.ToNullString(workerID))
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				logger.Error("Failed to claim job:", err)
+			}
+			return
+		}
+
+		delay, retry := w.processJob(Job{MeetingID: job.MeetingID})
+		if retry {
+			err = w.queries.RetryJob(ctx, repository.RetryJobParams{
+				ID:    job.ID,
+				RunAt: time.Now().Add(delay),
+			})
+		} else {
+			err = w.complete(ctx, job.ID)
+		}
+		if err != nil {
+			logger.Error("Failed to update job", job.ID, "for meeting", job.MeetingID, err)
+		}
+	}
+}
+
+// complete deletes a finished job, or queues it again if its meeting was
+// enqueued while it ran, so that enqueue isn't lost.
+func (w *PostgresWorker) complete(ctx context.Context, jobID uuid.UUID) error {
+	deleted, err := w.queries.CompleteJob(ctx, jobID)
+	if err != nil || deleted > 0 {
+		return err
+	}
+	return w.queries.RerunJob(ctx, jobID)
+}
+
+// Stop stops claiming new jobs and lets in-flight ones finish (or interrupts them
+// after shutdown_timeout_sec). Unclaimed and interrupted jobs stay in the jobs
+// table, so the next start picks them up.
+func (w *PostgresWorker) Stop() {
+	w.shutdownOnce.Do(func() {
+		close(w.shutdown)
+		logger.Info("Queue stopped claiming new jobs")
+
+		w.waitForWorkers(&w.wg)
+		logger.Info("All workers stopped, queued jobs remain in the database")
+	})
+}
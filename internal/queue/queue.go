@@ -1,6 +1,8 @@
 package queue
 
 import (
+	"context"
+
 	"github.com/pedy4000/noker/internal/repository"
 	"github.com/pedy4000/noker/pkg/config"
 
@@ -17,12 +19,21 @@ type Processor interface {
 	Stop()
 }
 
-func NewProcessor(queries *repository.Queries, cfg *config.Config) Processor {
+// TxEnqueuer is implemented by processors that keep jobs in the database. The job
+// can then be inserted in the same transaction as the change that calls for it,
+// so it's neither lost if the caller crashes after commit nor seen before commit.
+type TxEnqueuer interface {
+	EnqueueTx(ctx context.Context, q *repository.Queries, meetingID uuid.UUID) error
+}
+
+func NewProcessor(queries *repository.Store, cfg *config.Config) Processor {
 	var p Processor
 
 	switch cfg.Queue.Type {
 	case "inmemory":
 		p = NewInMemoryWorker(queries, cfg)
+	case "postgres":
+		p = NewPostgresWorker(queries, cfg)
 	default:
 		p = NewInMemoryWorker(queries, cfg)
 	}
@@ -0,0 +1,334 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pedy4000/noker/internal/ai"
+	"github.com/pedy4000/noker/internal/models"
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/internal/service"
+	"github.com/pedy4000/noker/internal/webhook"
+	"github.com/pedy4000/noker/pkg/config"
+	"github.com/pedy4000/noker/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// runner holds the extraction pipeline shared by every queue backend.
+// Backends only decide where jobs come from; runner decides what to do with them.
+type runner struct {
+	queries   *repository.Store
+	extractor ai.Provider
+	embedder  ai.Embedder // nil sends every opportunity to the model
+	service   *service.OpportunityService
+	slack     *http.Client // posts results of /ost add back to Slack
+	cfg       *config.Config
+
+	// ctx is cancelled when shutdown runs out of time, interrupting in-flight jobs.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newRunner(queries *repository.Store, cfg *config.Config) runner {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return runner{
+		queries:   queries,
+		extractor: ai.NewExtractor(cfg),
+		embedder:  ai.NewEmbedder(cfg),
+		service:   service.NewOpportunityService(queries),
+		slack:     &http.Client{Timeout: time.Duration(cfg.Slack.TimeoutSec) * time.Second},
+		cfg:       cfg,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// processJob runs one attempt for the job's meeting. When the attempt failed with a
+// transient error and attempts are left, it returns retry=true and the delay the
+// backend should wait before enqueueing the meeting again.
+func (w *runner) processJob(job Job) (retryAfter time.Duration, retry bool) {
+	ctx := w.ctx
+	meeting, err := w.queries.GetMeeting(ctx, job.MeetingID)
+	if err != nil {
+		logger.Error("Failed to fetch meeting:", job.MeetingID, err)
+		return 0, ctx.Err() != nil
+	}
+
+	attempt, err := w.queries.StartMeetingAttempt(ctx, repository.StartMeetingAttemptParams{
+		ID:           meeting.ID,
+		LeaseSeconds: int32(w.cfg.Queue.LeaseSeconds),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logger.Debug("Meeting", job.MeetingID, "is finished or owned by another worker, skipping")
+		} else {
+			logger.Error("Failed to start attempt for meeting:", job.MeetingID, err)
+		}
+		return 0, ctx.Err() != nil
+	}
+	defer w.keepLease(meeting.ID, attempt)()
+
+	m := &models.Meeting{
+		ID:       meeting.ID,
+		Title:    meeting.Title,
+		RawNotes: meeting.RawNotes,
+		Source:   models.MeetingSource(meeting.Source),
+	}
+
+	opps, err := w.candidates(ctx, m)
+	if err != nil {
+		logger.Error("Failed to fetch opportunities:", job.MeetingID, err)
+		return w.fail(meeting.ID, int(attempt), true,
+			fmt.Sprintf("Failed to fetch opportunities: %v", err))
+	}
+
+	extracted, err := w.extractor.Extract(ctx, m, opps)
+	if err != nil {
+		logger.Error("AI extraction failed for meeting", job.MeetingID, err)
+		return w.fail(meeting.ID, int(attempt), ai.IsRetryable(err),
+			fmt.Sprintf("AI extraction failed: %v", err))
+	}
+
+	issues := ai.VerifyQuotes(m.RawNotes, extracted, w.cfg.AI.UnverifiedQuotes)
+	valid, invalid := ai.ValidateExtraction(extracted, opps)
+	w.recordValidationIssues(meeting.ID, append(issues, invalid...))
+	if len(valid) == 0 && len(extracted) > 0 {
+		// The model answered, just not in a shape we can use; asking again rarely helps.
+		return w.fail(meeting.ID, int(attempt), false,
+			fmt.Sprintf("All %d extracted items failed validation", len(extracted)))
+	}
+	extracted = valid
+
+	if err := w.service.ProcessExtractedOpportunities(ctx, job.MeetingID, extracted); err != nil {
+		logger.Error("Failed to save opportunities:", err)
+		return w.fail(meeting.ID, int(attempt), !errors.Is(err, service.ErrInvalidExtraction),
+			fmt.Sprintf("Failed to save opportunities: %v", err))
+	}
+
+	n, err := w.queries.CompleteMeetingAttempt(context.Background(), repository.CompleteMeetingAttemptParams{
+		ID:      meeting.ID,
+		Attempt: attempt,
+	})
+	if err != nil {
+		logger.Error("Failed to mark meeting done", "meeting_id", meeting.ID, "error", err)
+		return 0, false
+	}
+	if n == 0 {
+		logger.Info("Meeting", meeting.ID, "was taken over by a newer attempt, leaving its status to that one")
+		return 0, false
+	}
+	w.emit(webhook.EventMeetingProcessed, webhook.MeetingData{
+		MeetingID:     meeting.ID,
+		Title:         meeting.Title,
+		Status:        "done",
+		Opportunities: len(extracted),
+		Attempts:      int(attempt),
+	})
+	w.replyToSlack(meeting.ID, meeting.Title, "")
+	logger.Debug("Successfully processed meeting:", job.MeetingID, "→", len(extracted), "opportunities")
+	return 0, false
+}
+
+// keepLease renews the meeting's lease, and its job's on the postgres queue, every
+// third of lease_seconds until the returned stop is called. A chunked extraction
+// can outlast a single lease, and the reaper would otherwise hand the meeting to
+// another worker halfway through.
+func (w *runner) keepLease(meetingID uuid.UUID, attempt int32) (stop func()) {
+	interval := w.leaseDuration() / 3
+	if interval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-w.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			ctx := context.Background()
+			n, err := w.queries.ExtendMeetingLease(ctx, repository.ExtendMeetingLeaseParams{
+				ID:           meetingID,
+				LeaseSeconds: int32(w.cfg.Queue.LeaseSeconds),
+				Attempt:      attempt,
+			})
+			if err != nil {
+				logger.Error("Failed to extend lease", "meeting_id", meetingID, "error", err)
+				continue
+			}
+			if n == 0 {
+				// Finished in the meantime, or taken over after a missed renewal.
+				logger.Debug("Meeting", meetingID, "is no longer held by this attempt, lease not renewed")
+				return
+			}
+			if err := w.queries.ExtendJobLease(ctx, meetingID); err != nil {
+				logger.Error("Failed to extend job lease", "meeting_id", meetingID, "error", err)
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// recordValidationIssues stores what validation found in this attempt's output,
+// replacing whatever an earlier attempt left behind.
+func (w *runner) recordValidationIssues(meetingID uuid.UUID, issues []ai.ValidationIssue) {
+	if issues == nil {
+		issues = []ai.ValidationIssue{}
+	}
+	for _, issue := range issues {
+		logger.Debug("Meeting", meetingID, "item", issue.Index, issue.Action+":", issue.Problem)
+	}
+
+	data, err := json.Marshal(issues)
+	if err != nil {
+		logger.Error("Failed to encode validation issues", "meeting_id", meetingID, "error", err)
+		return
+	}
+	if err := w.queries.SetMeetingValidationIssues(context.Background(), repository.SetMeetingValidationIssuesParams{
+		ID:     meetingID,
+		Issues: data,
+	}); err != nil {
+		logger.Error("Failed to record validation issues", "meeting_id", meetingID, "error", err)
+	}
+}
+
+// fail records a failed attempt. Transient failures are scheduled for another try
+// until max_attempts is reached, then the meeting is marked "dead".
+// Permanent failures are marked "failed" right away.
+func (w *runner) fail(meetingID uuid.UUID, attempt int, transient bool, message string) (retryAfter time.Duration, retry bool) {
+	if w.ctx.Err() != nil {
+		// Shutdown interrupted the attempt, it's not the meeting's fault.
+		if err := w.queries.ReleaseMeeting(context.Background(), repository.ReleaseMeetingParams{
+			ID:      meetingID,
+			Attempt: int32(attempt),
+		}); err != nil {
+			logger.Error("Failed to release meeting", "meeting_id", meetingID, "error", err)
+		}
+		logger.Info("Meeting", meetingID, "interrupted by shutdown, released for next start")
+		return 0, true
+	}
+
+	params := repository.RecordMeetingFailureParams{
+		ID:               meetingID,
+		ProcessingStatus: "failed",
+		Error:            truncateError(message),
+		Attempt:          int32(attempt),
+	}
+
+	switch {
+	case !transient:
+		logger.Error("Meeting", meetingID, "failed permanently on attempt", attempt)
+	case attempt >= w.cfg.Queue.MaxAttempts:
+		params.ProcessingStatus = "dead"
+		logger.Error("Meeting", meetingID, "is dead after", attempt, "attempts")
+	default:
+		retryAfter = backoff(attempt,
+			time.Duration(w.cfg.Queue.RetryBaseMs)*time.Millisecond,
+			time.Duration(w.cfg.Queue.RetryMaxMs)*time.Millisecond)
+		params.ProcessingStatus = "retrying"
+		params.NextAttemptAt = sql.NullTime{Time: time.Now().Add(retryAfter), Valid: true}
+		logger.Info("Meeting", meetingID, "will be retried in", retryAfter.Round(time.Millisecond))
+	}
+
+	n, err := w.queries.RecordMeetingFailure(context.Background(), params)
+	if err != nil {
+		logger.Error("Failed to record meeting failure", "meeting_id", meetingID, "error", err)
+	} else if n == 0 {
+		logger.Info("Meeting", meetingID, "was taken over by a newer attempt, leaving its status to that one")
+		return 0, false
+	}
+	if params.ProcessingStatus != "retrying" {
+		w.emit(webhook.EventMeetingFailed, webhook.MeetingData{
+			MeetingID: meetingID,
+			Status:    params.ProcessingStatus,
+			Attempts:  attempt,
+			Error:     params.Error,
+		})
+		w.replyToSlack(meetingID, "", params.Error)
+	}
+
+	return retryAfter, params.ProcessingStatus == "retrying"
+}
+
+// emit queues a webhook event. A failure is logged, not retried: the meeting itself was handled.
+func (w *runner) emit(event string, data any) {
+	if err := webhook.Enqueue(context.Background(), w.queries.Queries, event, data); err != nil {
+		logger.Error("Failed to queue webhook event", "event", event, "error", err)
+	}
+}
+
+// recoverMeetings enqueues meetings that no worker is looking after: pending or
+// retrying meetings left behind since before staleBefore, and meetings whose
+// processing lease has expired.
+func (w *runner) recoverMeetings(staleBefore time.Time, enqueue func(uuid.UUID)) {
+	ids, err := w.queries.ListRecoverableMeetings(context.Background(), repository.ListRecoverableMeetingsParams{
+		Limit:       int32(w.cfg.Queue.BufferSize),
+		StaleBefore: sql.NullTime{Time: staleBefore, Valid: true},
+	})
+	if err != nil {
+		logger.Error("Failed to list recoverable meetings:", err)
+		return
+	}
+
+	for _, id := range ids {
+		enqueue(id)
+	}
+	if len(ids) > 0 {
+		logger.Info("Recovered", len(ids), "unfinished meetings")
+	}
+}
+
+// waitForWorkers gives in-flight jobs shutdown_timeout_sec to finish. After that
+// they are interrupted, and their meetings are released for the next start.
+func (w *runner) waitForWorkers(wg *sync.WaitGroup) {
+	timeout := time.Duration(w.cfg.Queue.ShutdownTimeoutSec) * time.Second
+	logger.Info("Waiting up to", timeout, "for in-flight jobs to finish...")
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		logger.Info("In-flight jobs finished")
+	case <-time.After(timeout):
+		logger.Error("Shutdown deadline exceeded, interrupting in-flight jobs")
+		w.cancel()
+		<-done
+	}
+	w.cancel()
+}
+
+// leaseDuration is how long a worker owns a meeting it started processing.
+func (w *runner) leaseDuration() time.Duration {
+	return time.Duration(w.cfg.Queue.LeaseSeconds) * time.Second
+}
+
+func truncateError(message string) string {
+	if len(message) > 200 {
+		message = message[:197] + "..." // Database limitation
+	}
+	return message
+}
@@ -0,0 +1,52 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/pedy4000/noker/internal/slack"
+	"github.com/pedy4000/noker/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// replyToSlack posts the outcome of a meeting added with /ost add to its command's
+// response_url: what was extracted, or failure when it's given. The URL is cleared
+// first, so the result is posted once however many times the meeting is processed.
+// Like webhook events, a failed post is logged and not retried.
+func (w *runner) replyToSlack(meetingID uuid.UUID, title, failure string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(w.cfg.Slack.TimeoutSec)*time.Second)
+	defer cancel()
+
+	responseURL, err := w.queries.TakeMeetingSlackResponseURL(ctx, meetingID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			logger.Error("Failed to read Slack response_url", "meeting_id", meetingID, "error", err)
+		}
+		return
+	}
+
+	if title == "" {
+		if meeting, err := w.queries.GetMeeting(ctx, meetingID); err == nil {
+			title = meeting.Title
+		}
+	}
+
+	var msg slack.Message
+	if failure != "" {
+		msg = slack.MeetingFailed(meetingID, title, failure)
+	} else {
+		evidence, err := w.queries.ListMeetingEvidence(ctx, meetingID)
+		if err != nil {
+			logger.Error("Failed to list meeting evidence for Slack", "meeting_id", meetingID, "error", err)
+			return
+		}
+		msg = slack.MeetingProcessed(meetingID, title, evidence)
+	}
+
+	if err := slack.Post(ctx, w.slack, responseURL, msg); err != nil {
+		logger.Error("Failed to post meeting result to Slack", "meeting_id", meetingID, "error", err)
+	}
+}
@@ -0,0 +1,166 @@
+package queue
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/internal/webhook"
+	"github.com/pedy4000/noker/pkg/config"
+	"github.com/pedy4000/noker/pkg/logger"
+)
+
+// WebhookDispatcher sends queued webhook deliveries. Deliveries live in the
+// webhook_deliveries table, so several instances can share the work and nothing
+// is lost on restart: a claimed delivery whose sender died becomes due again
+// once its lease runs out.
+type WebhookDispatcher struct {
+	queries *repository.Store
+	client  *http.Client
+	cfg     *config.Config
+
+	wg           sync.WaitGroup
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+}
+
+func NewWebhookDispatcher(queries *repository.Store, cfg *config.Config) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		queries:  queries,
+		client:   &http.Client{Timeout: time.Duration(cfg.Webhooks.TimeoutSec) * time.Second},
+		cfg:      cfg,
+		shutdown: make(chan struct{}),
+	}
+}
+
+func (d *WebhookDispatcher) Start() {
+	interval := time.Duration(d.cfg.Webhooks.PollIntervalMs) * time.Millisecond
+
+	for i := 0; i < d.cfg.Webhooks.WorkerCount; i++ {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-d.shutdown:
+					return
+				case <-ticker.C:
+					d.drain()
+				}
+			}
+		}()
+	}
+	logger.Info("Webhook dispatcher started with", d.cfg.Webhooks.WorkerCount, "workers")
+}
+
+// Stop waits for the batches being sent to finish. Whatever is still due stays
+// in the database for the next start.
+func (d *WebhookDispatcher) Stop() {
+	d.shutdownOnce.Do(func() {
+		close(d.shutdown)
+		d.wg.Wait()
+		logger.Info("Webhook dispatcher stopped")
+	})
+}
+
+// drain sends due deliveries batch by batch until none are left or shutdown is requested.
+func (d *WebhookDispatcher) drain() {
+	ctx := context.Background()
+	// Long enough to send the whole batch even if every request times out.
+	lease := d.cfg.Webhooks.TimeoutSec * (d.cfg.Webhooks.BatchSize + 1)
+
+	for {
+		select {
+		case <-d.shutdown:
+			return
+		default:
+		}
+
+		batch, err := d.queries.ClaimWebhookDeliveries(ctx, repository.ClaimWebhookDeliveriesParams{
+			LeaseSeconds: int32(lease),
+			MaxRows:      int32(d.cfg.Webhooks.BatchSize),
+		})
+		if err != nil {
+			logger.Error("Failed to claim webhook deliveries:", err)
+			return
+		}
+		if len(batch) == 0 {
+			return
+		}
+
+		for _, delivery := range batch {
+			d.deliver(ctx, delivery)
+		}
+	}
+}
+
+// deliver makes one attempt and records its outcome. Any 2xx answer counts as delivered;
+// anything else is retried with backoff until max_attempts, then marked "failed".
+func (d *WebhookDispatcher) deliver(ctx context.Context, delivery repository.ClaimWebhookDeliveriesRow) {
+	status, err := d.send(ctx, delivery)
+
+	params := repository.RecordWebhookAttemptParams{
+		ID:     delivery.ID,
+		Status: "delivered",
+	}
+	if status > 0 {
+		params.ResponseStatus = sql.NullInt32{Int32: int32(status), Valid: true}
+	}
+
+	if err != nil {
+		params.LastError = sql.NullString{String: truncateError(err.Error()), Valid: true}
+		if int(delivery.Attempts) >= d.cfg.Webhooks.MaxAttempts {
+			params.Status = "failed"
+			logger.Error("Webhook delivery", delivery.ID, "failed after", delivery.Attempts, "attempts:", err)
+		} else {
+			delay := backoff(int(delivery.Attempts),
+				time.Duration(d.cfg.Webhooks.RetryBaseMs)*time.Millisecond,
+				time.Duration(d.cfg.Webhooks.RetryMaxMs)*time.Millisecond)
+			params.Status = "pending"
+			params.NextAttemptAt = sql.NullTime{Time: time.Now().Add(delay), Valid: true}
+			logger.Info("Webhook delivery", delivery.ID, "failed, retrying in", delay.Round(time.Second), ":", err)
+		}
+	}
+
+	if err := d.queries.RecordWebhookAttempt(ctx, params); err != nil {
+		logger.Error("Failed to record webhook attempt", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+// send posts the payload and returns the response status (0 if there was no response).
+func (d *WebhookDispatcher) send(ctx context.Context, delivery repository.ClaimWebhookDeliveriesRow) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Noker-Webhooks/1.0")
+	req.Header.Set(webhook.HeaderEvent, delivery.Event)
+	req.Header.Set(webhook.HeaderDelivery, delivery.ID.String())
+	req.Header.Set(webhook.HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(webhook.HeaderSignature, webhook.Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode, fmt.Errorf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
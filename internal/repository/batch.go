@@ -0,0 +1,204 @@
+package repository
+
+// Batch loaders for a page of opportunities. They live outside queries.sql because
+// sqlc wraps slice parameters in lib/pq's pq.Array for database/sql; pgx encodes
+// a []uuid.UUID as uuid[] on its own, so these pass the IDs straight through.
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const listCustomersForOpportunities = `
+SELECT DISTINCT oe.opportunity_id, c.id, c.name, c.plan, c.arr
+FROM opportunity_evidence oe
+JOIN meetings m ON m.id = oe.meeting_id
+JOIN customers c ON c.id = m.customer_id
+WHERE oe.opportunity_id = ANY($1::uuid[])
+ORDER BY oe.opportunity_id, c.name
+`
+
+type ListCustomersForOpportunitiesRow struct {
+	OpportunityID uuid.UUID       `db:"opportunity_id" json:"opportunity_id"`
+	ID            uuid.UUID       `db:"id" json:"id"`
+	Name          string          `db:"name" json:"name"`
+	Plan          sql.NullString  `db:"plan" json:"plan"`
+	Arr           sql.NullFloat64 `db:"arr" json:"arr"`
+}
+
+// The distinct customers behind several opportunities.
+func (q *Queries) ListCustomersForOpportunities(ctx context.Context, opportunityIds []uuid.UUID) ([]ListCustomersForOpportunitiesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCustomersForOpportunities, opportunityIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCustomersForOpportunitiesRow
+	for rows.Next() {
+		var i ListCustomersForOpportunitiesRow
+		if err := rows.Scan(
+			&i.OpportunityID,
+			&i.ID,
+			&i.Name,
+			&i.Plan,
+			&i.Arr,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEvidenceForOpportunities = `
+SELECT
+    oe.opportunity_id,
+    oe.id,
+    oe.quote,
+    oe.context,
+    oe.chunk_index,
+    oe.chunk_offset,
+    oe.verified,
+    oe.quote_start,
+    oe.quote_end,
+    oe.created_at,
+
+    m.id            AS meeting_id,
+    m.title         AS meeting_title,
+    m.created_at    AS meeting_date
+FROM opportunity_evidence oe
+JOIN meetings m ON oe.meeting_id = m.id
+WHERE oe.opportunity_id = ANY($1::uuid[])
+ORDER BY oe.opportunity_id, oe.created_at DESC
+`
+
+type ListEvidenceForOpportunitiesRow struct {
+	OpportunityID uuid.UUID      `db:"opportunity_id" json:"opportunity_id"`
+	ID            uuid.UUID      `db:"id" json:"id"`
+	Quote         string         `db:"quote" json:"quote"`
+	Context       sql.NullString `db:"context" json:"context"`
+	ChunkIndex    int32          `db:"chunk_index" json:"chunk_index"`
+	ChunkOffset   int32          `db:"chunk_offset" json:"chunk_offset"`
+	Verified      bool           `db:"verified" json:"verified"`
+	QuoteStart    sql.NullInt32  `db:"quote_start" json:"quote_start"`
+	QuoteEnd      sql.NullInt32  `db:"quote_end" json:"quote_end"`
+	CreatedAt     sql.NullTime   `db:"created_at" json:"created_at"`
+	MeetingID     uuid.UUID      `db:"meeting_id" json:"meeting_id"`
+	MeetingTitle  string         `db:"meeting_title" json:"meeting_title"`
+	MeetingDate   sql.NullTime   `db:"meeting_date" json:"meeting_date"`
+}
+
+// Evidence of several opportunities in one round trip.
+func (q *Queries) ListEvidenceForOpportunities(ctx context.Context, opportunityIds []uuid.UUID) ([]ListEvidenceForOpportunitiesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listEvidenceForOpportunities, opportunityIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListEvidenceForOpportunitiesRow
+	for rows.Next() {
+		var i ListEvidenceForOpportunitiesRow
+		if err := rows.Scan(
+			&i.OpportunityID,
+			&i.ID,
+			&i.Quote,
+			&i.Context,
+			&i.ChunkIndex,
+			&i.ChunkOffset,
+			&i.Verified,
+			&i.QuoteStart,
+			&i.QuoteEnd,
+			&i.CreatedAt,
+			&i.MeetingID,
+			&i.MeetingTitle,
+			&i.MeetingDate,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSolutionsForOpportunities = `
+SELECT
+    s.id, s.opportunity_id, s.title, s.description, s.status, s.created_at,
+    e.id AS experiment_id,
+    e.assumption,
+    e.method,
+    e.status AS experiment_status,
+    e.result,
+    e.created_at AS experiment_created_at
+FROM solutions s
+LEFT JOIN experiments e ON e.solution_id = s.id
+WHERE s.opportunity_id = ANY($1::uuid[])
+ORDER BY s.opportunity_id, s.created_at, s.id, e.created_at
+`
+
+type ListSolutionsForOpportunitiesRow struct {
+	ID                  uuid.UUID      `db:"id" json:"id"`
+	OpportunityID       uuid.UUID      `db:"opportunity_id" json:"opportunity_id"`
+	Title               string         `db:"title" json:"title"`
+	Description         sql.NullString `db:"description" json:"description"`
+	Status              string         `db:"status" json:"status"`
+	CreatedAt           time.Time      `db:"created_at" json:"created_at"`
+	ExperimentID        uuid.NullUUID  `db:"experiment_id" json:"experiment_id"`
+	Assumption          sql.NullString `db:"assumption" json:"assumption"`
+	Method              sql.NullString `db:"method" json:"method"`
+	ExperimentStatus    sql.NullString `db:"experiment_status" json:"experiment_status"`
+	Result              sql.NullString `db:"result" json:"result"`
+	ExperimentCreatedAt sql.NullTime   `db:"experiment_created_at" json:"experiment_created_at"`
+}
+
+// Solutions and their experiments for several opportunities, one row per experiment
+// (or one row with no experiment).
+func (q *Queries) ListSolutionsForOpportunities(ctx context.Context, opportunityIds []uuid.UUID) ([]ListSolutionsForOpportunitiesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSolutionsForOpportunities, opportunityIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSolutionsForOpportunitiesRow
+	for rows.Next() {
+		var i ListSolutionsForOpportunitiesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.OpportunityID,
+			&i.Title,
+			&i.Description,
+			&i.Status,
+			&i.CreatedAt,
+			&i.ExperimentID,
+			&i.Assumption,
+			&i.Method,
+			&i.ExperimentStatus,
+			&i.Result,
+			&i.ExperimentCreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
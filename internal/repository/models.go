@@ -6,33 +6,99 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/sqlc-dev/pqtype"
 )
 
+type AuditLog struct {
+	ID         uuid.UUID       `db:"id" json:"id"`
+	Action     string          `db:"action" json:"action"`
+	EntityType string          `db:"entity_type" json:"entity_type"`
+	EntityID   uuid.UUID       `db:"entity_id" json:"entity_id"`
+	Actor      sql.NullString  `db:"actor" json:"actor"`
+	Details    json.RawMessage `db:"details" json:"details"`
+	CreatedAt  time.Time       `db:"created_at" json:"created_at"`
+}
+
+type Customer struct {
+	ID        uuid.UUID       `db:"id" json:"id"`
+	Name      string          `db:"name" json:"name"`
+	Plan      sql.NullString  `db:"plan" json:"plan"`
+	Arr       sql.NullFloat64 `db:"arr" json:"arr"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+type Experiment struct {
+	ID         uuid.UUID      `db:"id" json:"id"`
+	SolutionID uuid.UUID      `db:"solution_id" json:"solution_id"`
+	Assumption string         `db:"assumption" json:"assumption"`
+	Method     sql.NullString `db:"method" json:"method"`
+	Status     string         `db:"status" json:"status"`
+	Result     sql.NullString `db:"result" json:"result"`
+	CreatedAt  time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+type Job struct {
+	ID        uuid.UUID      `db:"id" json:"id"`
+	MeetingID uuid.UUID      `db:"meeting_id" json:"meeting_id"`
+	Status    string         `db:"status" json:"status"`
+	RunAt     time.Time      `db:"run_at" json:"run_at"`
+	LockedBy  sql.NullString `db:"locked_by" json:"locked_by"`
+	LockedAt  sql.NullTime   `db:"locked_at" json:"locked_at"`
+	Rerun     bool           `db:"rerun" json:"rerun"`
+	CreatedAt sql.NullTime   `db:"created_at" json:"created_at"`
+	UpdatedAt sql.NullTime   `db:"updated_at" json:"updated_at"`
+}
+
 type Meeting struct {
-	ID               uuid.UUID             `db:"id" json:"id"`
-	Title            string                `db:"title" json:"title"`
-	RawNotes         string                `db:"raw_notes" json:"raw_notes"`
-	Source           string                `db:"source" json:"source"`
-	Metadata         pqtype.NullRawMessage `db:"metadata" json:"metadata"`
-	ProcessingStatus string                `db:"processing_status" json:"processing_status"`
-	ProcessingError  sql.NullString        `db:"processing_error" json:"processing_error"`
-	CreatedAt        sql.NullTime          `db:"created_at" json:"created_at"`
-	UpdatedAt        sql.NullTime          `db:"updated_at" json:"updated_at"`
-	ProcessedAt      sql.NullTime          `db:"processed_at" json:"processed_at"`
+	ID                 uuid.UUID             `db:"id" json:"id"`
+	Title              string                `db:"title" json:"title"`
+	RawNotes           string                `db:"raw_notes" json:"raw_notes"`
+	Source             string                `db:"source" json:"source"`
+	Metadata           pqtype.NullRawMessage `db:"metadata" json:"metadata"`
+	ProcessingStatus   string                `db:"processing_status" json:"processing_status"`
+	ProcessingError    sql.NullString        `db:"processing_error" json:"processing_error"`
+	CreatedAt          sql.NullTime          `db:"created_at" json:"created_at"`
+	UpdatedAt          sql.NullTime          `db:"updated_at" json:"updated_at"`
+	ProcessedAt        sql.NullTime          `db:"processed_at" json:"processed_at"`
+	ProcessingAttempts int32                 `db:"processing_attempts" json:"processing_attempts"`
+	ErrorHistory       json.RawMessage       `db:"error_history" json:"error_history"`
+	NextAttemptAt      sql.NullTime          `db:"next_attempt_at" json:"next_attempt_at"`
+	LeaseExpiresAt     sql.NullTime          `db:"lease_expires_at" json:"lease_expires_at"`
+	ValidationIssues   json.RawMessage       `db:"validation_issues" json:"validation_issues"`
+	Language           string                `db:"language" json:"language"`
+	CustomerID         uuid.NullUUID         `db:"customer_id" json:"customer_id"`
+	SlackResponseUrl   sql.NullString        `db:"slack_response_url" json:"slack_response_url"`
 }
 
 type Opportunity struct {
-	ID           uuid.UUID      `db:"id" json:"id"`
-	UserSegment  string         `db:"user_segment" json:"user_segment"`
-	Struggle     string         `db:"struggle" json:"struggle"`
-	WhyItMatters sql.NullString `db:"why_it_matters" json:"why_it_matters"`
-	Workaround   sql.NullString `db:"workaround" json:"workaround"`
-	ThemeID      uuid.NullUUID  `db:"theme_id" json:"theme_id"`
-	CreatedAt    sql.NullTime   `db:"created_at" json:"created_at"`
-	UpdatedAt    sql.NullTime   `db:"updated_at" json:"updated_at"`
+	ID           uuid.UUID       `db:"id" json:"id"`
+	UserSegment  string          `db:"user_segment" json:"user_segment"`
+	Struggle     string          `db:"struggle" json:"struggle"`
+	WhyItMatters sql.NullString  `db:"why_it_matters" json:"why_it_matters"`
+	Workaround   sql.NullString  `db:"workaround" json:"workaround"`
+	ThemeID      uuid.NullUUID   `db:"theme_id" json:"theme_id"`
+	CreatedAt    sql.NullTime    `db:"created_at" json:"created_at"`
+	UpdatedAt    sql.NullTime    `db:"updated_at" json:"updated_at"`
+	SearchConfig string          `db:"search_config" json:"search_config"`
+	Impact       sql.NullFloat64 `db:"impact" json:"impact"`
+	Confidence   sql.NullFloat64 `db:"confidence" json:"confidence"`
+	Effort       sql.NullFloat64 `db:"effort" json:"effort"`
+	OutcomeID    uuid.NullUUID   `db:"outcome_id" json:"outcome_id"`
+	ParentID     uuid.NullUUID   `db:"parent_id" json:"parent_id"`
+}
+
+type OpportunityEmbedding struct {
+	OpportunityID uuid.UUID   `db:"opportunity_id" json:"opportunity_id"`
+	Embedding     interface{} `db:"embedding" json:"embedding"`
+	Model         string      `db:"model" json:"model"`
+	ContentHash   string      `db:"content_hash" json:"content_hash"`
+	UpdatedAt     time.Time   `db:"updated_at" json:"updated_at"`
 }
 
 type OpportunityEvidence struct {
@@ -42,10 +108,79 @@ type OpportunityEvidence struct {
 	Quote         string         `db:"quote" json:"quote"`
 	Context       sql.NullString `db:"context" json:"context"`
 	CreatedAt     sql.NullTime   `db:"created_at" json:"created_at"`
+	ChunkIndex    int32          `db:"chunk_index" json:"chunk_index"`
+	ChunkOffset   int32          `db:"chunk_offset" json:"chunk_offset"`
+	Verified      bool           `db:"verified" json:"verified"`
+	QuoteStart    sql.NullInt32  `db:"quote_start" json:"quote_start"`
+	QuoteEnd      sql.NullInt32  `db:"quote_end" json:"quote_end"`
+	SearchConfig  string         `db:"search_config" json:"search_config"`
+}
+
+type OpportunityRedirect struct {
+	FromID    uuid.UUID `db:"from_id" json:"from_id"`
+	ToID      uuid.UUID `db:"to_id" json:"to_id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type Outcome struct {
+	ID          uuid.UUID      `db:"id" json:"id"`
+	Title       string         `db:"title" json:"title"`
+	Description sql.NullString `db:"description" json:"description"`
+	Metric      sql.NullString `db:"metric" json:"metric"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+type ScoringWeight struct {
+	Workspace        string    `db:"workspace" json:"workspace"`
+	ReachPerCustomer float64   `db:"reach_per_customer" json:"reach_per_customer"`
+	ReachPerEvidence float64   `db:"reach_per_evidence" json:"reach_per_evidence"`
+	ReachWeight      float64   `db:"reach_weight" json:"reach_weight"`
+	ImpactWeight     float64   `db:"impact_weight" json:"impact_weight"`
+	ConfidenceWeight float64   `db:"confidence_weight" json:"confidence_weight"`
+	EffortWeight     float64   `db:"effort_weight" json:"effort_weight"`
+	UpdatedAt        time.Time `db:"updated_at" json:"updated_at"`
+}
+
+type Solution struct {
+	ID            uuid.UUID      `db:"id" json:"id"`
+	OpportunityID uuid.UUID      `db:"opportunity_id" json:"opportunity_id"`
+	Title         string         `db:"title" json:"title"`
+	Description   sql.NullString `db:"description" json:"description"`
+	Status        string         `db:"status" json:"status"`
+	CreatedAt     time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time      `db:"updated_at" json:"updated_at"`
 }
 
 type Theme struct {
 	ID        uuid.UUID    `db:"id" json:"id"`
 	Name      string       `db:"name" json:"name"`
 	CreatedAt sql.NullTime `db:"created_at" json:"created_at"`
+	UpdatedAt sql.NullTime `db:"updated_at" json:"updated_at"`
+}
+
+type Webhook struct {
+	ID          uuid.UUID      `db:"id" json:"id"`
+	Url         string         `db:"url" json:"url"`
+	Secret      string         `db:"secret" json:"secret"`
+	Events      []string       `db:"events" json:"events"`
+	Description sql.NullString `db:"description" json:"description"`
+	Active      bool           `db:"active" json:"active"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID       `db:"id" json:"id"`
+	WebhookID      uuid.UUID       `db:"webhook_id" json:"webhook_id"`
+	Event          string          `db:"event" json:"event"`
+	Payload        json.RawMessage `db:"payload" json:"payload"`
+	Status         string          `db:"status" json:"status"`
+	Attempts       int32           `db:"attempts" json:"attempts"`
+	NextAttemptAt  time.Time       `db:"next_attempt_at" json:"next_attempt_at"`
+	ResponseStatus sql.NullInt32   `db:"response_status" json:"response_status"`
+	LastError      sql.NullString  `db:"last_error" json:"last_error"`
+	DeliveredAt    sql.NullTime    `db:"delivered_at" json:"delivered_at"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time       `db:"updated_at" json:"updated_at"`
 }
@@ -6,25 +6,183 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
 
 type Querier interface {
-	AddEvidence(ctx context.Context, arg AddEvidenceParams) error
+	AddAuditEntry(ctx context.Context, arg AddAuditEntryParams) error
+	// Returns 0 when the meeting already gave this opportunity the same quote.
+	AddEvidence(ctx context.Context, arg AddEvidenceParams) (int64, error)
+	ClaimNextJob(ctx context.Context, lockedBy sql.NullString) (Job, error)
+	// Takes up to max_rows due deliveries and pushes their next attempt lease_seconds
+	// out, so a dispatcher that dies mid-send leaves them to be picked up again.
+	ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]ClaimWebhookDeliveriesRow, error)
+	// Deletes a finished job, unless it was enqueued again while running (0 rows).
+	CompleteJob(ctx context.Context, id uuid.UUID) (int64, error)
+	// Marks the meeting done. Returns no rows when the attempt lost its lease and
+	// another worker took the meeting over in the meantime.
+	CompleteMeetingAttempt(ctx context.Context, arg CompleteMeetingAttemptParams) (int64, error)
+	CountEvidence(ctx context.Context, opportunityID uuid.UUID) (int64, error)
+	CreateExperiment(ctx context.Context, arg CreateExperimentParams) (Experiment, error)
 	// internal/repository/queries.sql
 	CreateMeeting(ctx context.Context, arg CreateMeetingParams) (CreateMeetingRow, error)
+	// Searched in the language of the meeting it was found in, if any.
 	CreateOpportunity(ctx context.Context, arg CreateOpportunityParams) (Opportunity, error)
+	CreateOutcome(ctx context.Context, arg CreateOutcomeParams) (Outcome, error)
+	CreateSolution(ctx context.Context, arg CreateSolutionParams) (Solution, error)
+	// Carved out of the source: same parent, outcome and search language.
+	CreateSplitOpportunity(ctx context.Context, arg CreateSplitOpportunityParams) (Opportunity, error)
+	// Two workers may create the same theme at once; both get the same row back.
 	CreateTheme(ctx context.Context, name string) (Theme, error)
+	// events is a comma-separated list.
+	CreateWebhook(ctx context.Context, arg CreateWebhookParams) (CreateWebhookRow, error)
+	DeleteEvidence(ctx context.Context, id uuid.UUID) (DeleteEvidenceRow, error)
+	DeleteExperiment(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
+	// Removes the evidence a meeting contributed and deletes the opportunities
+	// left with no evidence at all, unless someone added solutions or child opportunities to them.
+	// Returns the deleted opportunity IDs.
+	DeleteMeetingContribution(ctx context.Context, meetingID uuid.UUID) ([]uuid.UUID, error)
+	DeleteOpportunity(ctx context.Context, id uuid.UUID) (int64, error)
+	DeleteOutcome(ctx context.Context, id uuid.UUID) (int64, error)
+	DeleteSolution(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
+	DeleteTheme(ctx context.Context, id uuid.UUID) error
+	DeleteWebhook(ctx context.Context, id uuid.UUID) (int64, error)
+	// Removes source quotes the target already holds (same meeting and quote),
+	// which would otherwise break the unique index when moved.
+	DropDuplicateEvidence(ctx context.Context, arg DropDuplicateEvidenceParams) (int64, error)
+	// A meeting can only have one job at a time. Enqueueing a queued job again is a
+	// no-op; enqueueing a running one marks it to run again once it finishes.
+	EnqueueJob(ctx context.Context, meetingID uuid.UUID) error
+	// One delivery per active webhook subscribed to the event.
+	EnqueueWebhookDeliveries(ctx context.Context, arg EnqueueWebhookDeliveriesParams) (int64, error)
+	// Keeps a long-running job from being requeued as stale.
+	ExtendJobLease(ctx context.Context, meetingID uuid.UUID) error
+	// Renews the lease of a running attempt. Returns no rows when the lease was lost:
+	// the meeting finished, or another worker took it over and started a new attempt.
+	ExtendMeetingLease(ctx context.Context, arg ExtendMeetingLeaseParams) (int64, error)
+	// Finds an opportunity this meeting already created, so replaying it reuses the row.
+	FindOpportunityFromMeeting(ctx context.Context, arg FindOpportunityFromMeetingParams) (uuid.UUID, error)
+	GetCustomer(ctx context.Context, id uuid.UUID) (Customer, error)
+	// n of the embedding column's vector(n), which pgvector keeps as the type modifier.
+	GetEmbeddingDimensions(ctx context.Context) (int32, error)
 	GetMeeting(ctx context.Context, id uuid.UUID) (Meeting, error)
 	GetOpportunity(ctx context.Context, id uuid.UUID) (GetOpportunityRow, error)
+	GetOpportunityRedirect(ctx context.Context, fromID uuid.UUID) (uuid.UUID, error)
+	GetOutcome(ctx context.Context, id uuid.UUID) (Outcome, error)
+	GetScoringWeights(ctx context.Context, workspace string) (ScoringWeight, error)
+	GetSolution(ctx context.Context, id uuid.UUID) (Solution, error)
 	GetThemeByName(ctx context.Context, name string) (Theme, error)
+	GetWebhook(ctx context.Context, id uuid.UUID) (GetWebhookRow, error)
+	// Keeps the opportunity from being merged away or deleted until the transaction
+	// ends, without blocking others that only add evidence to it.
+	KeepOpportunity(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
 	ListAllOpportunitiesForDeduplication(ctx context.Context) ([]ListAllOpportunitiesForDeduplicationRow, error)
+	ListAuditEntries(ctx context.Context, arg ListAuditEntriesParams) ([]AuditLog, error)
+	// Opportunities with evidence from the customer's meetings, most mentioned first.
+	ListCustomerOpportunities(ctx context.Context, customerID uuid.UUID) ([]ListCustomerOpportunitiesRow, error)
+	ListCustomers(ctx context.Context) ([]ListCustomersRow, error)
 	ListEvidenceByOpportunity(ctx context.Context, opportunityID uuid.UUID) ([]ListEvidenceByOpportunityRow, error)
+	// Everything extracted from one meeting, grouped by opportunity.
+	ListMeetingEvidence(ctx context.Context, meetingID uuid.UUID) ([]ListMeetingEvidenceRow, error)
+	// Newest first. Pass the last row's created_at and id as the cursor to get the next page.
+	ListMeetings(ctx context.Context, arg ListMeetingsParams) ([]ListMeetingsRow, error)
+	ListMeetingsForReprocess(ctx context.Context, arg ListMeetingsForReprocessParams) ([]uuid.UUID, error)
+	// Meetings with metadata but no customer, oldest first. Pass the last row's
+	// created_at and id to get the next batch.
+	ListMeetingsWithoutCustomer(ctx context.Context, arg ListMeetingsWithoutCustomerParams) ([]ListMeetingsWithoutCustomerRow, error)
+	// Same shape as ListAllOpportunitiesForDeduplication, limited to the top-K by cosine distance.
+	ListNearestOpportunities(ctx context.Context, arg ListNearestOpportunitiesParams) ([]ListNearestOpportunitiesRow, error)
+	// One page of opportunities, sorted by sort_by: recent (created_at), evidence (evidence count,
+	// then recency) or last_evidence (newest quote). Pass the last row's sort_count, sort_time and
+	// id as the cursor to get the next page.
+	ListOpportunities(ctx context.Context, arg ListOpportunitiesParams) ([]ListOpportunitiesRow, error)
+	// Opportunities never embedded, embedded by another model, or edited since.
+	ListOpportunitiesMissingEmbedding(ctx context.Context, arg ListOpportunitiesMissingEmbeddingParams) ([]ListOpportunitiesMissingEmbeddingRow, error)
+	// The parent, grandparent and so on up to the root, nearest first (depth 1 is the parent).
+	ListOpportunityAncestors(ctx context.Context, id uuid.UUID) ([]ListOpportunityAncestorsRow, error)
+	// An opportunity (depth 0) and everything below it, parents before their children.
+	ListOpportunitySubtree(ctx context.Context, id uuid.UUID) ([]ListOpportunitySubtreeRow, error)
+	ListOutcomes(ctx context.Context) ([]Outcome, error)
+	// The best opportunities under framework (rice or ice), scored the way
+	// service.ScoreOpportunity does: missing estimates take the default_* values,
+	// and reach counts distinct linked customers. Meetings without a customer add
+	// evidence but no customer.
+	ListPrioritizedOpportunities(ctx context.Context, arg ListPrioritizedOpportunitiesParams) ([]ListPrioritizedOpportunitiesRow, error)
 	ListRecentOpportunities(ctx context.Context) ([]ListRecentOpportunitiesRow, error)
+	// Meetings no worker is looking after: pending or due for retry since before the cutoff,
+	// or processing with an expired lease (the worker crashed or was killed).
+	ListRecoverableMeetings(ctx context.Context, arg ListRecoverableMeetingsParams) ([]uuid.UUID, error)
 	ListTopOpportunitiesByTheme(ctx context.Context, arg ListTopOpportunitiesByThemeParams) ([]ListTopOpportunitiesByThemeRow, error)
+	// Opportunities ranked by the evidence they gathered since a date.
+	ListTopOpportunitiesSince(ctx context.Context, arg ListTopOpportunitiesSinceParams) ([]ListTopOpportunitiesSinceRow, error)
 	ListTopThemesThisWeek(ctx context.Context) ([]ListTopThemesThisWeekRow, error)
-	UpdateMeetingStatus(ctx context.Context, arg UpdateMeetingStatusParams) error
+	// Every opportunity with the outcome it rolls up to, for the tree. Opportunities
+	// without an outcome of their own roll up to their nearest ancestor's.
+	ListTreeOpportunities(ctx context.Context, outcomeID uuid.NullUUID) ([]ListTreeOpportunitiesRow, error)
+	// Newest first. Pass the last row's created_at and id as the cursor to get the next page.
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]ListWebhookDeliveriesRow, error)
+	ListWebhooks(ctx context.Context) ([]ListWebhooksRow, error)
+	// Takes the row lock, so concurrent merges/splits of the same opportunity queue up.
+	LockOpportunity(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
+	// Serializes parent changes, so two concurrent moves can't close a loop between them.
+	LockOpportunityHierarchy(ctx context.Context) error
+	MoveAllEvidence(ctx context.Context, arg MoveAllEvidenceParams) (int64, error)
+	// Re-parents the children of source_id onto target_id (NULL makes them top-level).
+	// target_id itself is never made its own child.
+	MoveChildOpportunities(ctx context.Context, arg MoveChildOpportunitiesParams) (int64, error)
+	MoveEvidence(ctx context.Context, arg MoveEvidenceParams) (int64, error)
+	MoveSolutions(ctx context.Context, arg MoveSolutionsParams) error
+	// A NULL to_id leaves the opportunities without a theme.
+	MoveThemeOpportunities(ctx context.Context, arg MoveThemeOpportunitiesParams) (int64, error)
+	// Records a failed attempt, unless the attempt lost its lease to a newer one.
+	RecordMeetingFailure(ctx context.Context, arg RecordMeetingFailureParams) (int64, error)
+	// status is delivered, failed (gave up) or pending (retry at next_attempt_at).
+	RecordWebhookAttempt(ctx context.Context, arg RecordWebhookAttemptParams) error
+	// Points from_id, and everything already redirected to it, at to_id.
+	RedirectOpportunity(ctx context.Context, arg RedirectOpportunityParams) error
+	// Hands back an attempt interrupted by shutdown; it doesn't count against max_attempts.
+	ReleaseMeeting(ctx context.Context, arg ReleaseMeetingParams) error
+	RenameTheme(ctx context.Context, arg RenameThemeParams) error
+	// Puts back jobs whose worker died before finishing them.
+	RequeueStaleJobs(ctx context.Context, lockedBefore sql.NullTime) (int64, error)
+	// Queues a job that was enqueued again while running, to run right away.
+	RerunJob(ctx context.Context, id uuid.UUID) error
+	// Puts a meeting back to "pending" for a fresh run. Meetings a worker is
+	// currently processing are left alone (0 rows).
+	ResetMeetingForReprocess(ctx context.Context, id uuid.UUID) (int64, error)
+	// A job enqueued again while running doesn't wait out its backoff.
+	RetryJob(ctx context.Context, arg RetryJobParams) error
+	// Sends a delivery again right away, whatever happened to it before.
+	RetryWebhookDelivery(ctx context.Context, id uuid.UUID) (RetryWebhookDeliveryRow, error)
+	// Full-text search over opportunities and their quotes, one row per matching quote
+	// (or a single row with no quote when only the opportunity itself matched).
+	// Opportunities are ranked by their own match plus the matches of their quotes.
+	SearchOpportunities(ctx context.Context, arg SearchOpportunitiesParams) ([]SearchOpportunitiesRow, error)
+	SetMeetingCustomer(ctx context.Context, arg SetMeetingCustomerParams) error
+	SetMeetingValidationIssues(ctx context.Context, arg SetMeetingValidationIssuesParams) error
+	// A NULL parent_id makes it a top-level opportunity.
+	SetOpportunityParent(ctx context.Context, arg SetOpportunityParentParams) error
+	// Claims the meeting for one attempt. Returns no rows when the meeting is finished
+	// or another worker still holds a valid lease on it.
+	StartMeetingAttempt(ctx context.Context, arg StartMeetingAttemptParams) (int32, error)
+	// Returns the meeting's Slack response_url and clears it, so the result is posted once.
+	TakeMeetingSlackResponseURL(ctx context.Context, id uuid.UUID) (string, error)
+	TouchOpportunity(ctx context.Context, id uuid.UUID) error
+	UpdateExperiment(ctx context.Context, arg UpdateExperimentParams) (Experiment, error)
+	// NULL leaves a field as it is; an empty why_it_matters/workaround clears it.
+	UpdateOpportunity(ctx context.Context, arg UpdateOpportunityParams) error
+	// NULL leaves a field as it is; an empty description/metric clears it.
+	UpdateOutcome(ctx context.Context, arg UpdateOutcomeParams) (Outcome, error)
+	UpdateSolution(ctx context.Context, arg UpdateSolutionParams) (Solution, error)
+	// NULL leaves a field as it is; an empty description clears it. events is a comma-separated list.
+	UpdateWebhook(ctx context.Context, arg UpdateWebhookParams) (UpdateWebhookRow, error)
+	// Plan and ARR are overwritten by the latest meeting that reports them.
+	UpsertCustomer(ctx context.Context, arg UpsertCustomerParams) (Customer, error)
+	// embedding is pgvector's text form: [0.1,0.2,...]
+	UpsertOpportunityEmbedding(ctx context.Context, arg UpsertOpportunityEmbeddingParams) error
+	UpsertScoringWeights(ctx context.Context, arg UpsertScoringWeightsParams) (ScoringWeight, error)
 }
 
 var _ Querier = (*Queries)(nil)
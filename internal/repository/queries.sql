@@ -1,35 +1,136 @@
+-- internal/repository/queries.sql
 -- name: CreateMeeting :one
-INSERT INTO meetings (title, raw_notes, source, metadata)
-VALUES ($1, $2, $3, $4)
+INSERT INTO meetings (title, raw_notes, source, metadata, language, customer_id, slack_response_url)
+VALUES ($1, $2, $3, $4, COALESCE(sqlc.narg(language)::text, 'simple')::regconfig, sqlc.narg(customer_id)::uuid,
+        sqlc.narg(slack_response_url)::text)
 RETURNING id, created_at;
 
 -- name: GetMeeting :one
 SELECT * FROM meetings WHERE id = $1;
 
--- name: UpdateMeetingStatus :exec
+-- name: CompleteMeetingAttempt :execrows
+-- Marks the meeting done. Returns no rows when the attempt lost its lease and
+-- another worker took the meeting over in the meantime.
 UPDATE meetings
-SET 
+SET
+  processing_status = 'done',
+  processing_error = NULL,
+  processed_at = NOW(),
+  lease_expires_at = NULL,
+  updated_at = NOW()
+WHERE id = $1
+  AND processing_status = 'processing'
+  AND processing_attempts = sqlc.arg(attempt);
+
+-- name: StartMeetingAttempt :one
+-- Claims the meeting for one attempt. Returns no rows when the meeting is finished
+-- or another worker still holds a valid lease on it.
+UPDATE meetings
+SET
+  processing_status = 'processing',
+  processing_attempts = processing_attempts + 1,
+  next_attempt_at = NULL,
+  lease_expires_at = NOW() + make_interval(secs => sqlc.arg(lease_seconds)::int),
+  updated_at = NOW()
+WHERE id = $1
+  AND (
+    processing_status IN ('pending', 'retrying')
+    OR (processing_status = 'processing' AND (lease_expires_at IS NULL OR lease_expires_at < NOW()))
+  )
+RETURNING processing_attempts;
+
+-- name: ExtendMeetingLease :execrows
+-- Renews the lease of a running attempt. Returns no rows when the lease was lost:
+-- the meeting finished, or another worker took it over and started a new attempt.
+UPDATE meetings
+SET lease_expires_at = NOW() + make_interval(secs => sqlc.arg(lease_seconds)::int)
+WHERE id = $1
+  AND processing_status = 'processing'
+  AND processing_attempts = sqlc.arg(attempt);
+
+-- name: RecordMeetingFailure :execrows
+-- Records a failed attempt, unless the attempt lost its lease to a newer one.
+UPDATE meetings
+SET
   processing_status = $2,
-  processing_error = CASE 
-    WHEN $3::text = '' THEN NULL 
-    ELSE $3 
+  processing_error = sqlc.arg(error)::text,
+  error_history = error_history || jsonb_build_array(jsonb_build_object(
+    'attempt', processing_attempts,
+    'error', sqlc.arg(error)::text,
+    'at', NOW()
+  )),
+  next_attempt_at = sqlc.narg(next_attempt_at),
+  processed_at = CASE
+    WHEN $2 = 'failed' OR $2 = 'dead' THEN NOW()
+    ELSE processed_at
   END,
-  processed_at = CASE 
-    WHEN $2 = 'done' OR $2 = 'failed' THEN NOW()
-    ELSE processed_at 
-  END
+  lease_expires_at = NULL,
+  updated_at = NOW()
+WHERE id = $1
+  AND processing_status = 'processing'
+  AND processing_attempts = sqlc.arg(attempt);
+
+-- name: SetMeetingValidationIssues :exec
+UPDATE meetings
+SET validation_issues = sqlc.arg(issues)::jsonb, updated_at = NOW()
 WHERE id = $1;
 
+-- name: TakeMeetingSlackResponseURL :one
+-- Returns the meeting's Slack response_url and clears it, so the result is posted once.
+UPDATE meetings
+SET slack_response_url = NULL
+WHERE id = $1 AND slack_response_url IS NOT NULL
+RETURNING slack_response_url::text;
+
+-- name: ReleaseMeeting :exec
+-- Hands back an attempt interrupted by shutdown; it doesn't count against max_attempts.
+UPDATE meetings
+SET
+  processing_status = 'pending',
+  processing_attempts = GREATEST(processing_attempts - 1, 0),
+  lease_expires_at = NULL,
+  updated_at = NOW()
+WHERE id = $1
+  AND processing_status = 'processing'
+  AND processing_attempts = sqlc.arg(attempt);
+
+-- name: ListRecoverableMeetings :many
+-- Meetings no worker is looking after: pending or due for retry since before the cutoff,
+-- or processing with an expired lease (the worker crashed or was killed).
+SELECT id FROM meetings
+WHERE (processing_status = 'pending' AND created_at < sqlc.arg(stale_before))
+   OR (processing_status = 'retrying' AND next_attempt_at < sqlc.arg(stale_before))
+   OR (processing_status = 'processing' AND (lease_expires_at IS NULL OR lease_expires_at < NOW()))
+ORDER BY created_at
+LIMIT $1;
+
 -- name: CreateTheme :one
-INSERT INTO themes (name) VALUES ($1) RETURNING id, name, created_at;
+-- Two workers may create the same theme at once; both get the same row back.
+INSERT INTO themes (name) VALUES ($1)
+ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+RETURNING *;
 
 -- name: GetThemeByName :one
-SELECT id, name, created_at FROM themes WHERE name = $1;
+SELECT * FROM themes WHERE name = $1;
 
 -- name: CreateOpportunity :one
+-- Searched in the language of the meeting it was found in, if any.
 INSERT INTO opportunities (
-    user_segment, struggle, why_it_matters, workaround, theme_id
-) VALUES ($1, $2, $3, $4, $5)
+    user_segment, struggle, why_it_matters, workaround, theme_id, parent_id, search_config
+) VALUES (
+    $1, $2, $3, $4, $5, sqlc.narg(parent_id)::uuid,
+    COALESCE((SELECT m.language FROM meetings m WHERE m.id = sqlc.narg(meeting_id)::uuid), 'simple')
+)
+RETURNING *;
+
+-- name: CreateSplitOpportunity :one
+-- Carved out of the source: same parent, outcome and search language.
+INSERT INTO opportunities (
+    user_segment, struggle, why_it_matters, workaround, theme_id, parent_id, outcome_id, search_config
+)
+SELECT $1, $2, $3, $4, $5, o.parent_id, o.outcome_id, o.search_config
+FROM opportunities o
+WHERE o.id = sqlc.arg(source_id)
 RETURNING *;
 
 -- name: GetOpportunity :one
@@ -49,6 +150,11 @@ SELECT
     oe.id,
     oe.quote,
     oe.context,
+    oe.chunk_index,
+    oe.chunk_offset,
+    oe.verified,
+    oe.quote_start,
+    oe.quote_end,
     oe.created_at,
     
     m.id            AS meeting_id,
@@ -62,10 +168,24 @@ JOIN meetings m ON oe.meeting_id = m.id
 WHERE oe.opportunity_id = $1
 ORDER BY oe.created_at DESC;
 
--- name: AddEvidence :exec
+-- name: AddEvidence :execrows
+-- Returns 0 when the meeting already gave this opportunity the same quote.
 INSERT INTO opportunity_evidence (
-    opportunity_id, meeting_id, quote, context
-) VALUES ($1, $2, $3, $4);
+    opportunity_id, meeting_id, quote, context, chunk_index, chunk_offset,
+    verified, quote_start, quote_end, search_config
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, sqlc.narg(quote_start), sqlc.narg(quote_end),
+    (SELECT m.language FROM meetings m WHERE m.id = $2)
+)
+ON CONFLICT (opportunity_id, meeting_id, md5(quote)) DO NOTHING;
+
+-- name: FindOpportunityFromMeeting :one
+-- Finds an opportunity this meeting already created, so replaying it reuses the row.
+SELECT o.id
+FROM opportunities o
+JOIN opportunity_evidence oe ON oe.opportunity_id = o.id
+WHERE oe.meeting_id = $1 AND LOWER(o.struggle) = LOWER(sqlc.arg(struggle))
+LIMIT 1;
 
 -- name: ListAllOpportunitiesForDeduplication :many
 SELECT 
@@ -93,6 +213,47 @@ LEFT JOIN LATERAL (
 WHERE o.created_at >= NOW() - INTERVAL '24 hours'
 ORDER BY o.created_at DESC;
 
+-- name: ListOpportunities :many
+-- One page of opportunities, sorted by sort_by: recent (created_at), evidence (evidence count,
+-- then recency) or last_evidence (newest quote). Pass the last row's sort_count, sort_time and
+-- id as the cursor to get the next page.
+WITH listed AS (
+    SELECT
+        o.id, o.user_segment, o.struggle, o.why_it_matters, o.workaround, o.created_at, o.outcome_id,
+        o.parent_id,
+        t.name AS theme_name,
+        COALESCE(ev.cnt, 0)::bigint AS evidence_count,
+        ev.last_at AS last_evidence_at
+    FROM opportunities o
+    LEFT JOIN themes t ON t.id = o.theme_id
+    LEFT JOIN LATERAL (
+        SELECT COUNT(*) AS cnt, MAX(oe.created_at) AS last_at
+        FROM opportunity_evidence oe
+        WHERE oe.opportunity_id = o.id
+    ) ev ON true
+    WHERE (sqlc.narg(since)::timestamptz IS NULL OR o.created_at >= sqlc.narg(since))
+      AND (sqlc.narg(until)::timestamptz IS NULL OR o.created_at < sqlc.narg(until))
+      AND (sqlc.narg(theme)::text IS NULL OR LOWER(t.name) = LOWER(sqlc.narg(theme)))
+      AND (sqlc.narg(segment)::text IS NULL OR LOWER(o.user_segment) = LOWER(sqlc.narg(segment)))
+),
+keyed AS (
+    SELECT
+        listed.*,
+        (CASE WHEN sqlc.arg(sort_by)::text = 'evidence' THEN evidence_count ELSE 0 END)::bigint AS sort_count,
+        (CASE WHEN sqlc.arg(sort_by)::text = 'last_evidence' THEN COALESCE(last_evidence_at, created_at)
+              ELSE created_at END)::timestamptz AS sort_time
+    FROM listed
+    WHERE evidence_count >= sqlc.arg(min_evidence)::bigint
+)
+SELECT
+    id, user_segment, struggle, why_it_matters, workaround, created_at, outcome_id, parent_id,
+    theme_name, evidence_count, sort_count, sort_time
+FROM keyed
+WHERE sqlc.narg(cursor_id)::uuid IS NULL
+   OR (sort_count, sort_time, id) < (sqlc.narg(cursor_count)::bigint, sqlc.narg(cursor_time)::timestamptz, sqlc.narg(cursor_id)::uuid)
+ORDER BY sort_count DESC, sort_time DESC, id DESC
+LIMIT sqlc.arg(max_rows);
+
 -- name: ListTopThemesThisWeek :many
 SELECT 
     t.name AS theme_name,
@@ -123,3 +284,764 @@ WHERE LOWER(t.name) = LOWER($1)
 GROUP BY o.id, o.struggle, o.created_at
 ORDER BY evidence_count DESC, o.created_at DESC
 LIMIT $2;
+
+-- name: ListTopOpportunitiesSince :many
+-- Opportunities ranked by the evidence they gathered since a date.
+SELECT
+    o.id,
+    o.user_segment,
+    o.struggle,
+    t.name AS theme_name,
+    COUNT(oe.id) AS evidence_count,
+    COUNT(DISTINCT oe.meeting_id) AS meeting_count
+FROM opportunities o
+JOIN opportunity_evidence oe ON oe.opportunity_id = o.id
+LEFT JOIN themes t ON o.theme_id = t.id
+WHERE oe.created_at >= sqlc.arg(since)::timestamptz
+GROUP BY o.id, t.name
+ORDER BY evidence_count DESC, meeting_count DESC, o.created_at DESC, o.id
+LIMIT sqlc.arg(max_rows);
+
+-- name: EnqueueJob :exec
+-- A meeting can only have one job at a time. Enqueueing a queued job again is a
+-- no-op; enqueueing a running one marks it to run again once it finishes.
+INSERT INTO jobs (meeting_id) VALUES ($1)
+ON CONFLICT (meeting_id) DO UPDATE
+SET rerun = TRUE, updated_at = NOW()
+WHERE jobs.status = 'running';
+
+-- name: ClaimNextJob :one
+UPDATE jobs
+SET
+  status = 'running',
+  locked_by = $1,
+  locked_at = NOW(),
+  updated_at = NOW()
+WHERE id = (
+    SELECT j.id
+    FROM jobs j
+    WHERE j.status = 'queued' AND j.run_at <= NOW()
+    ORDER BY j.run_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CompleteJob :execrows
+-- Deletes a finished job, unless it was enqueued again while running (0 rows).
+DELETE FROM jobs WHERE id = $1 AND NOT rerun;
+
+-- name: RerunJob :exec
+-- Queues a job that was enqueued again while running, to run right away.
+UPDATE jobs
+SET
+  status = 'queued',
+  run_at = NOW(),
+  rerun = FALSE,
+  locked_by = NULL,
+  locked_at = NULL,
+  updated_at = NOW()
+WHERE id = $1 AND rerun;
+
+-- name: RetryJob :exec
+-- A job enqueued again while running doesn't wait out its backoff.
+UPDATE jobs
+SET
+  status = 'queued',
+  run_at = CASE WHEN rerun THEN NOW() ELSE sqlc.arg(run_at) END,
+  rerun = FALSE,
+  locked_by = NULL,
+  locked_at = NULL,
+  updated_at = NOW()
+WHERE id = $1;
+
+-- name: ExtendJobLease :exec
+-- Keeps a long-running job from being requeued as stale.
+UPDATE jobs
+SET locked_at = NOW(), updated_at = NOW()
+WHERE meeting_id = $1 AND status = 'running';
+
+-- name: RequeueStaleJobs :execrows
+-- Puts back jobs whose worker died before finishing them.
+UPDATE jobs
+SET
+  status = 'queued',
+  rerun = FALSE,
+  locked_by = NULL,
+  locked_at = NULL,
+  updated_at = NOW()
+WHERE status = 'running' AND locked_at < sqlc.arg(locked_before);
+
+-- name: ResetMeetingForReprocess :execrows
+-- Puts a meeting back to "pending" for a fresh run. Meetings a worker is
+-- currently processing are left alone (0 rows).
+UPDATE meetings
+SET
+  processing_status = 'pending',
+  processing_error = NULL,
+  processing_attempts = 0,
+  error_history = '[]',
+  next_attempt_at = NULL,
+  lease_expires_at = NULL,
+  processed_at = NULL,
+  updated_at = NOW()
+WHERE id = $1
+  AND (processing_status <> 'processing' OR lease_expires_at < NOW());
+
+-- name: DeleteMeetingContribution :many
+-- Removes the evidence a meeting contributed and deletes the opportunities
+-- left with no evidence at all, unless someone added solutions or child opportunities to them.
+-- Returns the deleted opportunity IDs.
+WITH removed AS (
+    DELETE FROM opportunity_evidence
+    WHERE meeting_id = $1
+    RETURNING opportunity_id
+)
+DELETE FROM opportunities o
+WHERE o.id IN (SELECT opportunity_id FROM removed)
+  AND NOT EXISTS (
+    SELECT 1 FROM opportunity_evidence oe
+    WHERE oe.opportunity_id = o.id AND oe.meeting_id <> $1
+  )
+  AND NOT EXISTS (SELECT 1 FROM solutions s WHERE s.opportunity_id = o.id)
+  AND NOT EXISTS (SELECT 1 FROM opportunities c WHERE c.parent_id = o.id)
+RETURNING o.id;
+
+-- name: ListMeetingsForReprocess :many
+SELECT id FROM meetings
+WHERE processing_status <> 'processing'
+  AND (sqlc.narg(status)::text IS NULL OR processing_status = sqlc.narg(status))
+  AND (sqlc.narg(source)::text IS NULL OR source = sqlc.narg(source))
+  AND (sqlc.narg(created_after)::timestamptz IS NULL OR created_at >= sqlc.narg(created_after))
+  AND (sqlc.narg(created_before)::timestamptz IS NULL OR created_at < sqlc.narg(created_before))
+ORDER BY created_at
+LIMIT sqlc.arg(max_meetings);
+
+-- name: ListOpportunitiesMissingEmbedding :many
+-- Opportunities never embedded, embedded by another model, or edited since.
+SELECT
+    o.id,
+    o.struggle,
+    COALESCE(t.name, '')::text AS theme_name,
+    md5(o.struggle || '|' || COALESCE(t.name, ''))::text AS content_hash
+FROM opportunities o
+LEFT JOIN themes t ON o.theme_id = t.id
+LEFT JOIN opportunity_embeddings e ON e.opportunity_id = o.id
+WHERE e.opportunity_id IS NULL
+   OR e.model <> sqlc.arg(model)::text
+   OR e.content_hash <> md5(o.struggle || '|' || COALESCE(t.name, ''))
+ORDER BY o.created_at
+LIMIT sqlc.arg(max_rows);
+
+-- name: GetEmbeddingDimensions :one
+-- n of the embedding column's vector(n), which pgvector keeps as the type modifier.
+SELECT atttypmod::int AS dimensions FROM pg_catalog.pg_attribute
+WHERE attrelid = 'opportunity_embeddings'::regclass AND attname = 'embedding';
+
+-- name: UpsertOpportunityEmbedding :exec
+-- embedding is pgvector's text form: [0.1,0.2,...]
+INSERT INTO opportunity_embeddings (opportunity_id, embedding, model, content_hash)
+VALUES (sqlc.arg(opportunity_id), sqlc.arg(embedding)::text::vector, sqlc.arg(model)::text, sqlc.arg(content_hash)::text)
+ON CONFLICT (opportunity_id) DO UPDATE
+SET embedding = EXCLUDED.embedding,
+    model = EXCLUDED.model,
+    content_hash = EXCLUDED.content_hash,
+    updated_at = NOW();
+
+-- name: ListNearestOpportunities :many
+-- Same shape as ListAllOpportunitiesForDeduplication, limited to the top-K by cosine distance.
+SELECT
+    o.id::text AS opportunity_id,
+    o.struggle AS struggle,
+    COALESCE(t.name, 'No theme') AS theme_name
+FROM opportunity_embeddings e
+JOIN opportunities o ON o.id = e.opportunity_id
+LEFT JOIN themes t ON o.theme_id = t.id
+WHERE e.model = sqlc.arg(model)::text
+ORDER BY e.embedding <=> sqlc.arg(embedding)::text::vector
+LIMIT sqlc.arg(top_k);
+
+-- name: LockOpportunity :one
+-- Takes the row lock, so concurrent merges/splits of the same opportunity queue up.
+SELECT id FROM opportunities WHERE id = $1 FOR UPDATE;
+
+-- name: KeepOpportunity :one
+-- Keeps the opportunity from being merged away or deleted until the transaction
+-- ends, without blocking others that only add evidence to it.
+SELECT id FROM opportunities WHERE id = $1 FOR KEY SHARE;
+
+-- name: TouchOpportunity :exec
+UPDATE opportunities SET updated_at = NOW() WHERE id = $1;
+
+-- name: DropDuplicateEvidence :execrows
+-- Removes source quotes the target already holds (same meeting and quote),
+-- which would otherwise break the unique index when moved.
+DELETE FROM opportunity_evidence s
+USING opportunity_evidence t
+WHERE s.opportunity_id = sqlc.arg(source_id)
+  AND t.opportunity_id = sqlc.arg(target_id)
+  AND t.meeting_id = s.meeting_id
+  AND md5(t.quote) = md5(s.quote);
+
+-- name: MoveAllEvidence :execrows
+UPDATE opportunity_evidence
+SET opportunity_id = sqlc.arg(target_id)
+WHERE opportunity_id = sqlc.arg(source_id);
+
+-- name: MoveEvidence :execrows
+UPDATE opportunity_evidence
+SET opportunity_id = sqlc.arg(target_id)
+WHERE id = sqlc.arg(id) AND opportunity_id = sqlc.arg(source_id);
+
+-- name: CountEvidence :one
+SELECT COUNT(*) FROM opportunity_evidence WHERE opportunity_id = $1;
+
+-- name: DeleteOpportunity :execrows
+DELETE FROM opportunities WHERE id = $1;
+
+-- name: RedirectOpportunity :exec
+-- Points from_id, and everything already redirected to it, at to_id.
+WITH repointed AS (
+    UPDATE opportunity_redirects
+    SET to_id = sqlc.arg(to_id)
+    WHERE to_id = sqlc.arg(from_id)
+)
+INSERT INTO opportunity_redirects (from_id, to_id)
+VALUES (sqlc.arg(from_id), sqlc.arg(to_id))
+ON CONFLICT (from_id) DO UPDATE SET to_id = EXCLUDED.to_id;
+
+-- name: GetOpportunityRedirect :one
+SELECT to_id FROM opportunity_redirects WHERE from_id = $1;
+
+-- name: AddAuditEntry :exec
+INSERT INTO audit_log (action, entity_type, entity_id, actor, details)
+VALUES ($1, $2, $3, sqlc.narg(actor), sqlc.arg(details)::jsonb);
+
+-- name: ListAuditEntries :many
+SELECT id, action, entity_type, entity_id, actor, details, created_at
+FROM audit_log
+WHERE entity_type = $1 AND entity_id = $2
+ORDER BY created_at;
+
+-- name: UpdateOpportunity :exec
+-- NULL leaves a field as it is; an empty why_it_matters/workaround clears it.
+UPDATE opportunities
+SET
+  user_segment = COALESCE(sqlc.narg(user_segment)::text, user_segment),
+  struggle = COALESCE(sqlc.narg(struggle)::text, struggle),
+  why_it_matters = CASE
+    WHEN sqlc.narg(why_it_matters)::text IS NULL THEN why_it_matters
+    ELSE NULLIF(sqlc.narg(why_it_matters)::text, '')
+  END,
+  workaround = CASE
+    WHEN sqlc.narg(workaround)::text IS NULL THEN workaround
+    ELSE NULLIF(sqlc.narg(workaround)::text, '')
+  END,
+  theme_id = CASE
+    WHEN sqlc.arg(set_theme)::bool THEN sqlc.narg(theme_id)::uuid
+    ELSE theme_id
+  END,
+  impact = COALESCE(sqlc.narg(impact)::float8, impact),
+  confidence = COALESCE(sqlc.narg(confidence)::float8, confidence),
+  effort = COALESCE(sqlc.narg(effort)::float8, effort),
+  outcome_id = CASE
+    WHEN sqlc.arg(set_outcome)::bool THEN sqlc.narg(outcome_id)::uuid
+    ELSE outcome_id
+  END,
+  parent_id = CASE
+    WHEN sqlc.arg(set_parent)::bool THEN sqlc.narg(parent_id)::uuid
+    ELSE parent_id
+  END,
+  updated_at = NOW()
+WHERE id = sqlc.arg(id);
+
+-- name: DeleteEvidence :one
+DELETE FROM opportunity_evidence WHERE id = $1
+RETURNING opportunity_id, meeting_id, quote;
+
+-- name: RenameTheme :exec
+UPDATE themes SET name = $2, updated_at = NOW() WHERE id = $1;
+
+-- name: MoveThemeOpportunities :execrows
+-- A NULL to_id leaves the opportunities without a theme.
+UPDATE opportunities
+SET theme_id = sqlc.narg(to_id), updated_at = NOW()
+WHERE theme_id = sqlc.arg(from_id)::uuid;
+
+-- name: DeleteTheme :exec
+DELETE FROM themes WHERE id = $1;
+
+-- name: ListMeetings :many
+-- Newest first. Pass the last row's created_at and id as the cursor to get the next page.
+SELECT
+    m.id, m.title, m.source, m.metadata, m.processing_status, m.processing_error,
+    m.processing_attempts, m.created_at, m.updated_at, m.processed_at,
+    (SELECT COUNT(*) FROM opportunity_evidence oe WHERE oe.meeting_id = m.id) AS evidence_count
+FROM meetings m
+WHERE (sqlc.narg(status)::text IS NULL OR m.processing_status = sqlc.narg(status))
+  AND (sqlc.narg(source)::text IS NULL OR m.source = sqlc.narg(source))
+  AND (sqlc.narg(created_after)::timestamptz IS NULL OR m.created_at >= sqlc.narg(created_after))
+  AND (sqlc.narg(created_before)::timestamptz IS NULL OR m.created_at < sqlc.narg(created_before))
+  AND (sqlc.narg(metadata_key)::text IS NULL OR m.metadata ->> sqlc.narg(metadata_key)::text IS NOT NULL)
+  AND (sqlc.narg(metadata_value)::text IS NULL OR m.metadata ->> sqlc.narg(metadata_key)::text = sqlc.narg(metadata_value))
+  AND (sqlc.narg(search)::text IS NULL
+       OR m.title ILIKE '%' || sqlc.narg(search) || '%'
+       OR m.raw_notes ILIKE '%' || sqlc.narg(search) || '%')
+  AND (sqlc.narg(cursor_created)::timestamptz IS NULL
+       OR (m.created_at, m.id) < (sqlc.narg(cursor_created), sqlc.narg(cursor_id)::uuid))
+ORDER BY m.created_at DESC, m.id DESC
+LIMIT sqlc.arg(max_rows);
+
+-- name: ListMeetingEvidence :many
+-- Everything extracted from one meeting, grouped by opportunity.
+SELECT
+    o.id AS opportunity_id,
+    o.user_segment,
+    o.struggle,
+    o.why_it_matters,
+    o.workaround,
+    o.created_at AS opportunity_created_at,
+    t.name AS theme_name,
+    oe.id,
+    oe.quote,
+    oe.context,
+    oe.chunk_index,
+    oe.chunk_offset,
+    oe.verified,
+    oe.quote_start,
+    oe.quote_end,
+    oe.created_at
+FROM opportunity_evidence oe
+JOIN opportunities o ON o.id = oe.opportunity_id
+LEFT JOIN themes t ON o.theme_id = t.id
+WHERE oe.meeting_id = $1
+ORDER BY o.created_at, o.id, oe.quote_start NULLS LAST, oe.created_at;
+
+-- name: SearchOpportunities :many
+-- Full-text search over opportunities and their quotes, one row per matching quote
+-- (or a single row with no quote when only the opportunity itself matched).
+-- Opportunities are ranked by their own match plus the matches of their quotes.
+WITH query AS (
+    SELECT websearch_to_tsquery('simple', sqlc.arg(query)::text)
+        || websearch_to_tsquery(sqlc.arg(language)::text::regconfig, sqlc.arg(query)::text) AS q
+),
+opp_hits AS (
+    SELECT o.id, ts_rank(
+        setweight(to_tsvector(o.search_config, o.struggle), 'A') ||
+        setweight(to_tsvector(o.search_config, coalesce(o.why_it_matters, '') || ' ' || coalesce(o.workaround, '')), 'B') ||
+        to_tsvector('simple', o.struggle || ' ' || coalesce(o.why_it_matters, '') || ' ' || coalesce(o.workaround, '')),
+        query.q) AS rank
+    FROM opportunities o, query
+    WHERE (
+        setweight(to_tsvector(o.search_config, o.struggle), 'A') ||
+        setweight(to_tsvector(o.search_config, coalesce(o.why_it_matters, '') || ' ' || coalesce(o.workaround, '')), 'B') ||
+        to_tsvector('simple', o.struggle || ' ' || coalesce(o.why_it_matters, '') || ' ' || coalesce(o.workaround, ''))
+    ) @@ query.q
+),
+ev_hits AS (
+    SELECT oe.id, oe.opportunity_id, oe.meeting_id, oe.quote, oe.search_config,
+        ts_rank(to_tsvector(oe.search_config, oe.quote) || to_tsvector('simple', oe.quote), query.q) AS rank
+    FROM opportunity_evidence oe, query
+    WHERE (to_tsvector(oe.search_config, oe.quote) || to_tsvector('simple', oe.quote)) @@ query.q
+),
+scored AS (
+    SELECT ids.id, (
+        COALESCE((SELECT rank FROM opp_hits WHERE opp_hits.id = ids.id), 0) +
+        COALESCE((SELECT SUM(rank) FROM ev_hits WHERE ev_hits.opportunity_id = ids.id), 0)
+    )::float8 AS score
+    FROM (SELECT id FROM opp_hits UNION SELECT opportunity_id FROM ev_hits) ids
+    ORDER BY score DESC, ids.id
+    LIMIT sqlc.arg(max_opportunities)
+)
+SELECT
+    o.id AS opportunity_id,
+    o.user_segment,
+    o.struggle,
+    t.name AS theme_name,
+    s.score,
+    ts_headline(o.search_config, o.struggle, query.q,
+        'StartSel=<mark>, StopSel=</mark>, HighlightAll=true')::text AS struggle_highlight,
+    ev.id AS evidence_id,
+    ev.meeting_id,
+    ev.quote,
+    COALESCE(ts_headline(ev.search_config, ev.quote, query.q,
+        'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MinWords=5, MaxWords=25'), '')::text AS quote_highlight,
+    COALESCE(ev.rank, 0)::float8 AS quote_rank
+FROM scored s
+CROSS JOIN query
+JOIN opportunities o ON o.id = s.id
+LEFT JOIN themes t ON t.id = o.theme_id
+LEFT JOIN ev_hits ev ON ev.opportunity_id = s.id
+ORDER BY s.score DESC, s.id, ev.rank DESC NULLS LAST;
+
+-- name: GetScoringWeights :one
+SELECT * FROM scoring_weights WHERE workspace = $1;
+
+-- name: UpsertScoringWeights :one
+INSERT INTO scoring_weights (
+    workspace, reach_per_customer, reach_per_evidence,
+    reach_weight, impact_weight, confidence_weight, effort_weight
+) VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (workspace) DO UPDATE SET
+    reach_per_customer = EXCLUDED.reach_per_customer,
+    reach_per_evidence = EXCLUDED.reach_per_evidence,
+    reach_weight = EXCLUDED.reach_weight,
+    impact_weight = EXCLUDED.impact_weight,
+    confidence_weight = EXCLUDED.confidence_weight,
+    effort_weight = EXCLUDED.effort_weight,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: ListPrioritizedOpportunities :many
+-- The best opportunities under framework (rice or ice), scored the way
+-- service.ScoreOpportunity does: missing estimates take the default_* values,
+-- and reach counts distinct linked customers. Meetings without a customer add
+-- evidence but no customer.
+WITH inputs AS (
+    SELECT
+        o.id, o.user_segment, o.struggle, o.created_at, o.impact, o.confidence, o.effort,
+        t.name AS theme_name,
+        COALESCE(ev.evidence_count, 0)::bigint AS evidence_count,
+        COALESCE(ev.customer_count, 0)::bigint AS customer_count
+    FROM opportunities o
+    LEFT JOIN themes t ON t.id = o.theme_id
+    LEFT JOIN LATERAL (
+        SELECT COUNT(*) AS evidence_count, COUNT(DISTINCT m.customer_id) AS customer_count
+        FROM opportunity_evidence oe
+        JOIN meetings m ON m.id = oe.meeting_id
+        WHERE oe.opportunity_id = o.id
+    ) ev ON true
+),
+scored AS (
+    SELECT
+        inputs.*,
+        sqlc.arg(reach_per_customer)::float8 * customer_count
+            + sqlc.arg(reach_per_evidence)::float8 * evidence_count AS reach,
+        POWER(COALESCE(impact, sqlc.arg(default_impact)::float8), sqlc.arg(impact_weight)::float8)
+            * POWER(COALESCE(confidence, sqlc.arg(default_confidence)::float8), sqlc.arg(confidence_weight)::float8)
+            / POWER(COALESCE(effort, sqlc.arg(default_effort)::float8), sqlc.arg(effort_weight)::float8) AS ice
+    FROM inputs
+)
+SELECT
+    id, user_segment, struggle, created_at, impact, confidence, effort, theme_name,
+    evidence_count, customer_count
+FROM scored
+ORDER BY
+    CASE WHEN sqlc.arg(framework)::text = 'ice' THEN ice
+         ELSE POWER(reach, sqlc.arg(reach_weight)::float8) * ice END DESC,
+    evidence_count DESC,
+    id
+LIMIT sqlc.arg(max_rows);
+
+-- name: UpsertCustomer :one
+-- Plan and ARR are overwritten by the latest meeting that reports them.
+INSERT INTO customers (name, plan, arr)
+VALUES (sqlc.arg(name), sqlc.narg(plan)::text, sqlc.narg(arr)::float8)
+ON CONFLICT ((LOWER(name))) DO UPDATE SET
+    plan = COALESCE(EXCLUDED.plan, customers.plan),
+    arr = COALESCE(EXCLUDED.arr, customers.arr),
+    updated_at = NOW()
+RETURNING *;
+
+-- name: ListMeetingsWithoutCustomer :many
+-- Meetings with metadata but no customer, oldest first. Pass the last row's
+-- created_at and id to get the next batch.
+SELECT id, metadata, created_at FROM meetings
+WHERE customer_id IS NULL
+  AND metadata IS NOT NULL
+  AND (sqlc.narg(after_id)::uuid IS NULL
+       OR (created_at, id) > (sqlc.narg(after_time)::timestamptz, sqlc.narg(after_id)::uuid))
+ORDER BY created_at, id
+LIMIT sqlc.arg(max_rows);
+
+-- name: SetMeetingCustomer :exec
+UPDATE meetings SET customer_id = $2 WHERE id = $1;
+
+-- name: GetCustomer :one
+SELECT * FROM customers WHERE id = $1;
+
+-- name: ListCustomers :many
+SELECT
+    c.*,
+    (SELECT COUNT(*) FROM meetings m WHERE m.customer_id = c.id) AS meeting_count
+FROM customers c
+ORDER BY c.arr DESC NULLS LAST, LOWER(c.name);
+
+-- name: ListCustomerOpportunities :many
+-- Opportunities with evidence from the customer's meetings, most mentioned first.
+SELECT
+    o.id, o.user_segment, o.struggle, o.why_it_matters, o.workaround, o.created_at,
+    t.name AS theme_name,
+    COUNT(oe.id) AS evidence_count
+FROM opportunities o
+JOIN opportunity_evidence oe ON oe.opportunity_id = o.id
+JOIN meetings m ON m.id = oe.meeting_id
+LEFT JOIN themes t ON t.id = o.theme_id
+WHERE m.customer_id = sqlc.arg(customer_id)::uuid
+GROUP BY o.id, t.name
+ORDER BY evidence_count DESC, o.created_at DESC;
+
+-- name: MoveSolutions :exec
+UPDATE solutions SET opportunity_id = sqlc.arg(target_id), updated_at = NOW()
+WHERE opportunity_id = sqlc.arg(source_id);
+
+-- name: CreateOutcome :one
+INSERT INTO outcomes (title, description, metric)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetOutcome :one
+SELECT * FROM outcomes WHERE id = $1;
+
+-- name: ListOutcomes :many
+SELECT * FROM outcomes ORDER BY created_at;
+
+-- name: UpdateOutcome :one
+-- NULL leaves a field as it is; an empty description/metric clears it.
+UPDATE outcomes
+SET
+  title = COALESCE(sqlc.narg(title)::text, title),
+  description = CASE
+    WHEN sqlc.narg(description)::text IS NULL THEN description
+    ELSE NULLIF(sqlc.narg(description)::text, '')
+  END,
+  metric = CASE
+    WHEN sqlc.narg(metric)::text IS NULL THEN metric
+    ELSE NULLIF(sqlc.narg(metric)::text, '')
+  END,
+  updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: DeleteOutcome :execrows
+DELETE FROM outcomes WHERE id = $1;
+
+-- name: CreateSolution :one
+INSERT INTO solutions (opportunity_id, title, description, status)
+VALUES ($1, $2, $3, COALESCE(sqlc.narg(status)::text, 'idea'))
+RETURNING *;
+
+-- name: GetSolution :one
+SELECT * FROM solutions WHERE id = $1;
+
+-- name: UpdateSolution :one
+UPDATE solutions
+SET
+  title = COALESCE(sqlc.narg(title)::text, title),
+  description = CASE
+    WHEN sqlc.narg(description)::text IS NULL THEN description
+    ELSE NULLIF(sqlc.narg(description)::text, '')
+  END,
+  status = COALESCE(sqlc.narg(status)::text, status),
+  updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: DeleteSolution :one
+DELETE FROM solutions WHERE id = $1
+RETURNING opportunity_id;
+
+-- name: CreateExperiment :one
+INSERT INTO experiments (solution_id, assumption, method, status, result)
+VALUES ($1, $2, $3, COALESCE(sqlc.narg(status)::text, 'planned'), $4)
+RETURNING *;
+
+-- name: UpdateExperiment :one
+UPDATE experiments
+SET
+  assumption = COALESCE(sqlc.narg(assumption)::text, assumption),
+  method = CASE
+    WHEN sqlc.narg(method)::text IS NULL THEN method
+    ELSE NULLIF(sqlc.narg(method)::text, '')
+  END,
+  status = COALESCE(sqlc.narg(status)::text, status),
+  result = CASE
+    WHEN sqlc.narg(result)::text IS NULL THEN result
+    ELSE NULLIF(sqlc.narg(result)::text, '')
+  END,
+  updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: DeleteExperiment :one
+DELETE FROM experiments WHERE id = $1
+RETURNING solution_id;
+
+-- name: ListTreeOpportunities :many
+-- Every opportunity with the outcome it rolls up to, for the tree. Opportunities
+-- without an outcome of their own roll up to their nearest ancestor's.
+WITH RECURSIVE placed AS (
+    SELECT o.id, o.outcome_id AS tree_outcome_id
+    FROM opportunities o
+    WHERE o.parent_id IS NULL
+    UNION ALL
+    SELECT c.id, COALESCE(c.outcome_id, p.tree_outcome_id)
+    FROM placed p
+    JOIN opportunities c ON c.parent_id = p.id
+)
+SELECT
+    o.id, o.outcome_id, o.parent_id, o.user_segment, o.struggle, o.created_at,
+    pl.tree_outcome_id,
+    t.name AS theme_name,
+    (SELECT COUNT(*) FROM opportunity_evidence oe WHERE oe.opportunity_id = o.id) AS evidence_count
+FROM opportunities o
+JOIN placed pl ON pl.id = o.id
+LEFT JOIN themes t ON t.id = o.theme_id
+WHERE sqlc.narg(outcome_id)::uuid IS NULL OR pl.tree_outcome_id = sqlc.narg(outcome_id)
+ORDER BY evidence_count DESC, o.created_at DESC;
+
+-- name: LockOpportunityHierarchy :exec
+-- Serializes parent changes, so two concurrent moves can't close a loop between them.
+SELECT pg_advisory_xact_lock(hashtext('opportunity_hierarchy'));
+
+-- name: ListOpportunityAncestors :many
+-- The parent, grandparent and so on up to the root, nearest first (depth 1 is the parent).
+WITH RECURSIVE ancestors AS (
+    SELECT p.id, p.parent_id, 1 AS depth
+    FROM opportunities o
+    JOIN opportunities p ON p.id = o.parent_id
+    WHERE o.id = $1
+    UNION ALL
+    SELECT p.id, p.parent_id, a.depth + 1
+    FROM ancestors a
+    JOIN opportunities p ON p.id = a.parent_id
+) CYCLE id SET is_cycle USING path
+SELECT
+    a.id, a.parent_id, a.depth::int AS depth,
+    o.user_segment, o.struggle, o.created_at,
+    t.name AS theme_name,
+    (SELECT COUNT(*) FROM opportunity_evidence oe WHERE oe.opportunity_id = a.id) AS evidence_count
+FROM ancestors a
+JOIN opportunities o ON o.id = a.id
+LEFT JOIN themes t ON t.id = o.theme_id
+WHERE NOT a.is_cycle
+ORDER BY a.depth;
+
+-- name: ListOpportunitySubtree :many
+-- An opportunity (depth 0) and everything below it, parents before their children.
+WITH RECURSIVE subtree AS (
+    SELECT o.id, o.parent_id, 0 AS depth
+    FROM opportunities o
+    WHERE o.id = $1
+    UNION ALL
+    SELECT c.id, c.parent_id, s.depth + 1
+    FROM subtree s
+    JOIN opportunities c ON c.parent_id = s.id
+) CYCLE id SET is_cycle USING path
+SELECT
+    s.id, s.parent_id, s.depth::int AS depth,
+    o.user_segment, o.struggle, o.created_at,
+    t.name AS theme_name,
+    (SELECT COUNT(*) FROM opportunity_evidence oe WHERE oe.opportunity_id = s.id) AS evidence_count
+FROM subtree s
+JOIN opportunities o ON o.id = s.id
+LEFT JOIN themes t ON t.id = o.theme_id
+WHERE NOT s.is_cycle
+ORDER BY s.depth, evidence_count DESC, o.created_at DESC;
+
+-- name: SetOpportunityParent :exec
+-- A NULL parent_id makes it a top-level opportunity.
+UPDATE opportunities SET parent_id = sqlc.narg(parent_id)::uuid, updated_at = NOW()
+WHERE id = sqlc.arg(id);
+
+-- name: MoveChildOpportunities :execrows
+-- Re-parents the children of source_id onto target_id (NULL makes them top-level).
+-- target_id itself is never made its own child.
+UPDATE opportunities
+SET parent_id = sqlc.narg(target_id)::uuid, updated_at = NOW()
+WHERE parent_id = sqlc.arg(source_id)::uuid
+  AND id IS DISTINCT FROM sqlc.narg(target_id)::uuid;
+
+-- name: CreateWebhook :one
+-- events is a comma-separated list.
+INSERT INTO webhooks (url, secret, events, description)
+VALUES ($1, $2, string_to_array(sqlc.arg(events)::text, ','), $3)
+RETURNING id, url, secret, array_to_string(events, ',')::text AS events, description, active, created_at, updated_at;
+
+-- name: GetWebhook :one
+SELECT id, url, secret, array_to_string(events, ',')::text AS events, description, active, created_at, updated_at
+FROM webhooks WHERE id = $1;
+
+-- name: ListWebhooks :many
+SELECT id, url, secret, array_to_string(events, ',')::text AS events, description, active, created_at, updated_at
+FROM webhooks ORDER BY created_at;
+
+-- name: UpdateWebhook :one
+-- NULL leaves a field as it is; an empty description clears it. events is a comma-separated list.
+UPDATE webhooks
+SET
+  url = COALESCE(sqlc.narg(url)::text, url),
+  events = COALESCE(string_to_array(sqlc.narg(events)::text, ','), events),
+  description = CASE
+    WHEN sqlc.narg(description)::text IS NULL THEN description
+    ELSE NULLIF(sqlc.narg(description)::text, '')
+  END,
+  active = COALESCE(sqlc.narg(active)::bool, active),
+  updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING id, url, secret, array_to_string(events, ',')::text AS events, description, active, created_at, updated_at;
+
+-- name: DeleteWebhook :execrows
+DELETE FROM webhooks WHERE id = $1;
+
+-- name: EnqueueWebhookDeliveries :execrows
+-- One delivery per active webhook subscribed to the event.
+INSERT INTO webhook_deliveries (webhook_id, event, payload)
+SELECT w.id, sqlc.arg(event)::text, sqlc.arg(payload)::jsonb
+FROM webhooks w
+WHERE w.active AND (sqlc.arg(event)::text = ANY(w.events) OR '*' = ANY(w.events));
+
+-- name: ClaimWebhookDeliveries :many
+-- Takes up to max_rows due deliveries and pushes their next attempt lease_seconds
+-- out, so a dispatcher that dies mid-send leaves them to be picked up again.
+WITH claimed AS (
+    UPDATE webhook_deliveries d
+    SET
+      attempts = d.attempts + 1,
+      next_attempt_at = NOW() + make_interval(secs => sqlc.arg(lease_seconds)::int),
+      updated_at = NOW()
+    WHERE d.id IN (
+        SELECT id FROM webhook_deliveries
+        WHERE status = 'pending' AND next_attempt_at <= NOW()
+        ORDER BY next_attempt_at
+        LIMIT sqlc.arg(max_rows)
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING d.id, d.webhook_id, d.event, d.payload, d.attempts
+)
+SELECT c.id, c.webhook_id, c.event, c.payload, c.attempts, w.url, w.secret
+FROM claimed c
+JOIN webhooks w ON w.id = c.webhook_id;
+
+-- name: RecordWebhookAttempt :exec
+-- status is delivered, failed (gave up) or pending (retry at next_attempt_at).
+UPDATE webhook_deliveries
+SET
+  status = sqlc.arg(status)::text,
+  next_attempt_at = COALESCE(sqlc.narg(next_attempt_at)::timestamptz, next_attempt_at),
+  response_status = sqlc.narg(response_status),
+  last_error = sqlc.narg(last_error),
+  delivered_at = CASE WHEN sqlc.arg(status)::text = 'delivered' THEN NOW() ELSE delivered_at END,
+  updated_at = NOW()
+WHERE id = sqlc.arg(id);
+
+-- name: ListWebhookDeliveries :many
+-- Newest first. Pass the last row's created_at and id as the cursor to get the next page.
+SELECT id, webhook_id, event, payload, status, attempts, next_attempt_at, response_status,
+       last_error, delivered_at, created_at
+FROM webhook_deliveries
+WHERE webhook_id = sqlc.arg(webhook_id)
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status))
+  AND (sqlc.narg(event)::text IS NULL OR event = sqlc.narg(event))
+  AND (sqlc.narg(cursor_created)::timestamptz IS NULL
+       OR (created_at, id) < (sqlc.narg(cursor_created), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(max_rows);
+
+-- name: RetryWebhookDelivery :one
+-- Sends a delivery again right away, whatever happened to it before.
+UPDATE webhook_deliveries
+SET status = 'pending', next_attempt_at = NOW(), updated_at = NOW()
+WHERE id = $1
+RETURNING id, webhook_id, event, payload, status, attempts, next_attempt_at, response_status,
+          last_error, delivered_at, created_at;
@@ -8,15 +8,46 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/sqlc-dev/pqtype"
 )
 
-const addEvidence = `-- name: AddEvidence :exec
+const addAuditEntry = `-- name: AddAuditEntry :exec
+INSERT INTO audit_log (action, entity_type, entity_id, actor, details)
+VALUES ($1, $2, $3, $4, $5::jsonb)
+`
+
+type AddAuditEntryParams struct {
+	Action     string          `db:"action" json:"action"`
+	EntityType string          `db:"entity_type" json:"entity_type"`
+	EntityID   uuid.UUID       `db:"entity_id" json:"entity_id"`
+	Actor      sql.NullString  `db:"actor" json:"actor"`
+	Details    json.RawMessage `db:"details" json:"details"`
+}
+
+func (q *Queries) AddAuditEntry(ctx context.Context, arg AddAuditEntryParams) error {
+	_, err := q.db.ExecContext(ctx, addAuditEntry,
+		arg.Action,
+		arg.EntityType,
+		arg.EntityID,
+		arg.Actor,
+		arg.Details,
+	)
+	return err
+}
+
+const addEvidence = `-- name: AddEvidence :execrows
 INSERT INTO opportunity_evidence (
-    opportunity_id, meeting_id, quote, context
-) VALUES ($1, $2, $3, $4)
+    opportunity_id, meeting_id, quote, context, chunk_index, chunk_offset,
+    verified, quote_start, quote_end, search_config
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9,
+    (SELECT m.language FROM meetings m WHERE m.id = $2)
+)
+ON CONFLICT (opportunity_id, meeting_id, md5(quote)) DO NOTHING
 `
 
 type AddEvidenceParams struct {
@@ -24,29 +55,239 @@ type AddEvidenceParams struct {
 	MeetingID     uuid.UUID      `db:"meeting_id" json:"meeting_id"`
 	Quote         string         `db:"quote" json:"quote"`
 	Context       sql.NullString `db:"context" json:"context"`
+	ChunkIndex    int32          `db:"chunk_index" json:"chunk_index"`
+	ChunkOffset   int32          `db:"chunk_offset" json:"chunk_offset"`
+	Verified      bool           `db:"verified" json:"verified"`
+	QuoteStart    sql.NullInt32  `db:"quote_start" json:"quote_start"`
+	QuoteEnd      sql.NullInt32  `db:"quote_end" json:"quote_end"`
 }
 
-func (q *Queries) AddEvidence(ctx context.Context, arg AddEvidenceParams) error {
-	_, err := q.db.ExecContext(ctx, addEvidence,
+// Returns 0 when the meeting already gave this opportunity the same quote.
+func (q *Queries) AddEvidence(ctx context.Context, arg AddEvidenceParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, addEvidence,
 		arg.OpportunityID,
 		arg.MeetingID,
 		arg.Quote,
 		arg.Context,
+		arg.ChunkIndex,
+		arg.ChunkOffset,
+		arg.Verified,
+		arg.QuoteStart,
+		arg.QuoteEnd,
 	)
-	return err
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const claimNextJob = `-- name: ClaimNextJob :one
+UPDATE jobs
+SET
+  status = 'running',
+  locked_by = $1,
+  locked_at = NOW(),
+  updated_at = NOW()
+WHERE id = (
+    SELECT j.id
+    FROM jobs j
+    WHERE j.status = 'queued' AND j.run_at <= NOW()
+    ORDER BY j.run_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, meeting_id, status, run_at, locked_by, locked_at, rerun, created_at, updated_at
+`
+
+func (q *Queries) ClaimNextJob(ctx context.Context, lockedBy sql.NullString) (Job, error) {
+	row := q.db.QueryRowContext(ctx, claimNextJob, lockedBy)
+	var i Job
+	err := row.Scan(
+		&i.ID,
+		&i.MeetingID,
+		&i.Status,
+		&i.RunAt,
+		&i.LockedBy,
+		&i.LockedAt,
+		&i.Rerun,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const claimWebhookDeliveries = `-- name: ClaimWebhookDeliveries :many
+WITH claimed AS (
+    UPDATE webhook_deliveries d
+    SET
+      attempts = d.attempts + 1,
+      next_attempt_at = NOW() + make_interval(secs => $1::int),
+      updated_at = NOW()
+    WHERE d.id IN (
+        SELECT id FROM webhook_deliveries
+        WHERE status = 'pending' AND next_attempt_at <= NOW()
+        ORDER BY next_attempt_at
+        LIMIT $2
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING d.id, d.webhook_id, d.event, d.payload, d.attempts
+)
+SELECT c.id, c.webhook_id, c.event, c.payload, c.attempts, w.url, w.secret
+FROM claimed c
+JOIN webhooks w ON w.id = c.webhook_id
+`
+
+type ClaimWebhookDeliveriesParams struct {
+	LeaseSeconds int32 `db:"lease_seconds" json:"lease_seconds"`
+	MaxRows      int32 `db:"max_rows" json:"max_rows"`
+}
+
+type ClaimWebhookDeliveriesRow struct {
+	ID        uuid.UUID       `db:"id" json:"id"`
+	WebhookID uuid.UUID       `db:"webhook_id" json:"webhook_id"`
+	Event     string          `db:"event" json:"event"`
+	Payload   json.RawMessage `db:"payload" json:"payload"`
+	Attempts  int32           `db:"attempts" json:"attempts"`
+	Url       string          `db:"url" json:"url"`
+	Secret    string          `db:"secret" json:"secret"`
+}
+
+// Takes up to max_rows due deliveries and pushes their next attempt lease_seconds
+// out, so a dispatcher that dies mid-send leaves them to be picked up again.
+func (q *Queries) ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]ClaimWebhookDeliveriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, claimWebhookDeliveries, arg.LeaseSeconds, arg.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimWebhookDeliveriesRow
+	for rows.Next() {
+		var i ClaimWebhookDeliveriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.WebhookID,
+			&i.Event,
+			&i.Payload,
+			&i.Attempts,
+			&i.Url,
+			&i.Secret,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const completeJob = `-- name: CompleteJob :execrows
+DELETE FROM jobs WHERE id = $1 AND NOT rerun
+`
+
+// Deletes a finished job, unless it was enqueued again while running (0 rows).
+func (q *Queries) CompleteJob(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, completeJob, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const completeMeetingAttempt = `-- name: CompleteMeetingAttempt :execrows
+UPDATE meetings
+SET
+  processing_status = 'done',
+  processing_error = NULL,
+  processed_at = NOW(),
+  lease_expires_at = NULL,
+  updated_at = NOW()
+WHERE id = $1
+  AND processing_status = 'processing'
+  AND processing_attempts = $2
+`
+
+type CompleteMeetingAttemptParams struct {
+	ID      uuid.UUID `db:"id" json:"id"`
+	Attempt int32     `db:"attempt" json:"attempt"`
+}
+
+// Marks the meeting done. Returns no rows when the attempt lost its lease and
+// another worker took the meeting over in the meantime.
+func (q *Queries) CompleteMeetingAttempt(ctx context.Context, arg CompleteMeetingAttemptParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, completeMeetingAttempt, arg.ID, arg.Attempt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const countEvidence = `-- name: CountEvidence :one
+SELECT COUNT(*) FROM opportunity_evidence WHERE opportunity_id = $1
+`
+
+func (q *Queries) CountEvidence(ctx context.Context, opportunityID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countEvidence, opportunityID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createExperiment = `-- name: CreateExperiment :one
+INSERT INTO experiments (solution_id, assumption, method, status, result)
+VALUES ($1, $2, $3, COALESCE($5::text, 'planned'), $4)
+RETURNING id, solution_id, assumption, method, status, result, created_at, updated_at
+`
+
+type CreateExperimentParams struct {
+	SolutionID uuid.UUID      `db:"solution_id" json:"solution_id"`
+	Assumption string         `db:"assumption" json:"assumption"`
+	Method     sql.NullString `db:"method" json:"method"`
+	Result     sql.NullString `db:"result" json:"result"`
+	Status     sql.NullString `db:"status" json:"status"`
+}
+
+func (q *Queries) CreateExperiment(ctx context.Context, arg CreateExperimentParams) (Experiment, error) {
+	row := q.db.QueryRowContext(ctx, createExperiment,
+		arg.SolutionID,
+		arg.Assumption,
+		arg.Method,
+		arg.Result,
+		arg.Status,
+	)
+	var i Experiment
+	err := row.Scan(
+		&i.ID,
+		&i.SolutionID,
+		&i.Assumption,
+		&i.Method,
+		&i.Status,
+		&i.Result,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
 }
 
 const createMeeting = `-- name: CreateMeeting :one
-INSERT INTO meetings (title, raw_notes, source, metadata)
-VALUES ($1, $2, $3, $4)
+INSERT INTO meetings (title, raw_notes, source, metadata, language, customer_id, slack_response_url)
+VALUES ($1, $2, $3, $4, COALESCE($5::text, 'simple')::regconfig, $6::uuid,
+        $7::text)
 RETURNING id, created_at
 `
 
 type CreateMeetingParams struct {
-	Title    string                `db:"title" json:"title"`
-	RawNotes string                `db:"raw_notes" json:"raw_notes"`
-	Source   string                `db:"source" json:"source"`
-	Metadata pqtype.NullRawMessage `db:"metadata" json:"metadata"`
+	Title            string                `db:"title" json:"title"`
+	RawNotes         string                `db:"raw_notes" json:"raw_notes"`
+	Source           string                `db:"source" json:"source"`
+	Metadata         pqtype.NullRawMessage `db:"metadata" json:"metadata"`
+	Language         sql.NullString        `db:"language" json:"language"`
+	CustomerID       uuid.NullUUID         `db:"customer_id" json:"customer_id"`
+	SlackResponseUrl sql.NullString        `db:"slack_response_url" json:"slack_response_url"`
 }
 
 type CreateMeetingRow struct {
@@ -61,6 +302,9 @@ func (q *Queries) CreateMeeting(ctx context.Context, arg CreateMeetingParams) (C
 		arg.RawNotes,
 		arg.Source,
 		arg.Metadata,
+		arg.Language,
+		arg.CustomerID,
+		arg.SlackResponseUrl,
 	)
 	var i CreateMeetingRow
 	err := row.Scan(&i.ID, &i.CreatedAt)
@@ -69,9 +313,12 @@ func (q *Queries) CreateMeeting(ctx context.Context, arg CreateMeetingParams) (C
 
 const createOpportunity = `-- name: CreateOpportunity :one
 INSERT INTO opportunities (
-    user_segment, struggle, why_it_matters, workaround, theme_id
-) VALUES ($1, $2, $3, $4, $5)
-RETURNING id, user_segment, struggle, why_it_matters, workaround, theme_id, created_at, updated_at
+    user_segment, struggle, why_it_matters, workaround, theme_id, parent_id, search_config
+) VALUES (
+    $1, $2, $3, $4, $5, $6::uuid,
+    COALESCE((SELECT m.language FROM meetings m WHERE m.id = $7::uuid), 'simple')
+)
+RETURNING id, user_segment, struggle, why_it_matters, workaround, theme_id, created_at, updated_at, search_config, impact, confidence, effort, outcome_id, parent_id
 `
 
 type CreateOpportunityParams struct {
@@ -80,8 +327,11 @@ type CreateOpportunityParams struct {
 	WhyItMatters sql.NullString `db:"why_it_matters" json:"why_it_matters"`
 	Workaround   sql.NullString `db:"workaround" json:"workaround"`
 	ThemeID      uuid.NullUUID  `db:"theme_id" json:"theme_id"`
+	ParentID     uuid.NullUUID  `db:"parent_id" json:"parent_id"`
+	MeetingID    uuid.NullUUID  `db:"meeting_id" json:"meeting_id"`
 }
 
+// Searched in the language of the meeting it was found in, if any.
 func (q *Queries) CreateOpportunity(ctx context.Context, arg CreateOpportunityParams) (Opportunity, error) {
 	row := q.db.QueryRowContext(ctx, createOpportunity,
 		arg.UserSegment,
@@ -89,6 +339,8 @@ func (q *Queries) CreateOpportunity(ctx context.Context, arg CreateOpportunityPa
 		arg.WhyItMatters,
 		arg.Workaround,
 		arg.ThemeID,
+		arg.ParentID,
+		arg.MeetingID,
 	)
 	var i Opportunity
 	err := row.Scan(
@@ -100,72 +352,105 @@ func (q *Queries) CreateOpportunity(ctx context.Context, arg CreateOpportunityPa
 		&i.ThemeID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.SearchConfig,
+		&i.Impact,
+		&i.Confidence,
+		&i.Effort,
+		&i.OutcomeID,
+		&i.ParentID,
 	)
 	return i, err
 }
 
-const createTheme = `-- name: CreateTheme :one
-INSERT INTO themes (name) VALUES ($1) RETURNING id, name, created_at
+const createOutcome = `-- name: CreateOutcome :one
+INSERT INTO outcomes (title, description, metric)
+VALUES ($1, $2, $3)
+RETURNING id, title, description, metric, created_at, updated_at
 `
 
-func (q *Queries) CreateTheme(ctx context.Context, name string) (Theme, error) {
-	row := q.db.QueryRowContext(ctx, createTheme, name)
-	var i Theme
-	err := row.Scan(&i.ID, &i.Name, &i.CreatedAt)
+type CreateOutcomeParams struct {
+	Title       string         `db:"title" json:"title"`
+	Description sql.NullString `db:"description" json:"description"`
+	Metric      sql.NullString `db:"metric" json:"metric"`
+}
+
+func (q *Queries) CreateOutcome(ctx context.Context, arg CreateOutcomeParams) (Outcome, error) {
+	row := q.db.QueryRowContext(ctx, createOutcome, arg.Title, arg.Description, arg.Metric)
+	var i Outcome
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Description,
+		&i.Metric,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
 	return i, err
 }
 
-const getMeeting = `-- name: GetMeeting :one
-SELECT id, title, raw_notes, source, metadata, processing_status, processing_error, created_at, updated_at, processed_at FROM meetings WHERE id = $1
+const createSolution = `-- name: CreateSolution :one
+INSERT INTO solutions (opportunity_id, title, description, status)
+VALUES ($1, $2, $3, COALESCE($4::text, 'idea'))
+RETURNING id, opportunity_id, title, description, status, created_at, updated_at
 `
 
-func (q *Queries) GetMeeting(ctx context.Context, id uuid.UUID) (Meeting, error) {
-	row := q.db.QueryRowContext(ctx, getMeeting, id)
-	var i Meeting
+type CreateSolutionParams struct {
+	OpportunityID uuid.UUID      `db:"opportunity_id" json:"opportunity_id"`
+	Title         string         `db:"title" json:"title"`
+	Description   sql.NullString `db:"description" json:"description"`
+	Status        sql.NullString `db:"status" json:"status"`
+}
+
+func (q *Queries) CreateSolution(ctx context.Context, arg CreateSolutionParams) (Solution, error) {
+	row := q.db.QueryRowContext(ctx, createSolution,
+		arg.OpportunityID,
+		arg.Title,
+		arg.Description,
+		arg.Status,
+	)
+	var i Solution
 	err := row.Scan(
 		&i.ID,
+		&i.OpportunityID,
 		&i.Title,
-		&i.RawNotes,
-		&i.Source,
-		&i.Metadata,
-		&i.ProcessingStatus,
-		&i.ProcessingError,
+		&i.Description,
+		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.ProcessedAt,
 	)
 	return i, err
 }
 
-const getOpportunity = `-- name: GetOpportunity :one
-SELECT 
-    o.id, o.user_segment, o.struggle, o.why_it_matters, o.workaround, o.theme_id, o.created_at, o.updated_at,
-    
-    t.name AS theme_name,
-    COUNT(oe.id) AS evidence_count
+const createSplitOpportunity = `-- name: CreateSplitOpportunity :one
+INSERT INTO opportunities (
+    user_segment, struggle, why_it_matters, workaround, theme_id, parent_id, outcome_id, search_config
+)
+SELECT $1, $2, $3, $4, $5, o.parent_id, o.outcome_id, o.search_config
 FROM opportunities o
-LEFT JOIN themes t ON o.theme_id = t.id
-LEFT JOIN opportunity_evidence oe ON oe.opportunity_id = o.id
-WHERE o.id = $1
-GROUP BY o.id, t.name
+WHERE o.id = $6
+RETURNING id, user_segment, struggle, why_it_matters, workaround, theme_id, created_at, updated_at, search_config, impact, confidence, effort, outcome_id, parent_id
 `
 
-type GetOpportunityRow struct {
-	ID            uuid.UUID      `db:"id" json:"id"`
-	UserSegment   string         `db:"user_segment" json:"user_segment"`
-	Struggle      string         `db:"struggle" json:"struggle"`
-	WhyItMatters  sql.NullString `db:"why_it_matters" json:"why_it_matters"`
-	Workaround    sql.NullString `db:"workaround" json:"workaround"`
-	ThemeID       uuid.NullUUID  `db:"theme_id" json:"theme_id"`
-	CreatedAt     sql.NullTime   `db:"created_at" json:"created_at"`
-	UpdatedAt     sql.NullTime   `db:"updated_at" json:"updated_at"`
-	ThemeName     sql.NullString `db:"theme_name" json:"theme_name"`
-	EvidenceCount int64          `db:"evidence_count" json:"evidence_count"`
+type CreateSplitOpportunityParams struct {
+	UserSegment  string         `db:"user_segment" json:"user_segment"`
+	Struggle     string         `db:"struggle" json:"struggle"`
+	WhyItMatters sql.NullString `db:"why_it_matters" json:"why_it_matters"`
+	Workaround   sql.NullString `db:"workaround" json:"workaround"`
+	ThemeID      uuid.NullUUID  `db:"theme_id" json:"theme_id"`
+	SourceID     uuid.UUID      `db:"source_id" json:"source_id"`
 }
 
-func (q *Queries) GetOpportunity(ctx context.Context, id uuid.UUID) (GetOpportunityRow, error) {
-	row := q.db.QueryRowContext(ctx, getOpportunity, id)
-	var i GetOpportunityRow
+// Carved out of the source: same parent, outcome and search language.
+func (q *Queries) CreateSplitOpportunity(ctx context.Context, arg CreateSplitOpportunityParams) (Opportunity, error) {
+	row := q.db.QueryRowContext(ctx, createSplitOpportunity,
+		arg.UserSegment,
+		arg.Struggle,
+		arg.WhyItMatters,
+		arg.Workaround,
+		arg.ThemeID,
+		arg.SourceID,
+	)
+	var i Opportunity
 	err := row.Scan(
 		&i.ID,
 		&i.UserSegment,
@@ -175,114 +460,144 @@ func (q *Queries) GetOpportunity(ctx context.Context, id uuid.UUID) (GetOpportun
 		&i.ThemeID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.ThemeName,
-		&i.EvidenceCount,
+		&i.SearchConfig,
+		&i.Impact,
+		&i.Confidence,
+		&i.Effort,
+		&i.OutcomeID,
+		&i.ParentID,
 	)
 	return i, err
 }
 
-const getThemeByName = `-- name: GetThemeByName :one
-SELECT id, name, created_at FROM themes WHERE name = $1
+const createTheme = `-- name: CreateTheme :one
+INSERT INTO themes (name) VALUES ($1)
+ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+RETURNING id, name, created_at, updated_at
 `
 
-func (q *Queries) GetThemeByName(ctx context.Context, name string) (Theme, error) {
-	row := q.db.QueryRowContext(ctx, getThemeByName, name)
+// Two workers may create the same theme at once; both get the same row back.
+func (q *Queries) CreateTheme(ctx context.Context, name string) (Theme, error) {
+	row := q.db.QueryRowContext(ctx, createTheme, name)
 	var i Theme
-	err := row.Scan(&i.ID, &i.Name, &i.CreatedAt)
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
 	return i, err
 }
 
-const listAllOpportunitiesForDeduplication = `-- name: ListAllOpportunitiesForDeduplication :many
-SELECT 
-    o.id::text AS opportunity_id,
-    o.struggle AS struggle,
-    COALESCE(t.name, 'No theme') AS theme_name
-FROM opportunities o
-LEFT JOIN themes t ON o.theme_id = t.id
-ORDER BY o.created_at DESC
+const createWebhook = `-- name: CreateWebhook :one
+INSERT INTO webhooks (url, secret, events, description)
+VALUES ($1, $2, string_to_array($4::text, ','), $3)
+RETURNING id, url, secret, array_to_string(events, ',')::text AS events, description, active, created_at, updated_at
 `
 
-type ListAllOpportunitiesForDeduplicationRow struct {
-	OpportunityID string `db:"opportunity_id" json:"opportunity_id"`
-	Struggle      string `db:"struggle" json:"struggle"`
-	ThemeName     string `db:"theme_name" json:"theme_name"`
+type CreateWebhookParams struct {
+	Url         string         `db:"url" json:"url"`
+	Secret      string         `db:"secret" json:"secret"`
+	Description sql.NullString `db:"description" json:"description"`
+	Events      string         `db:"events" json:"events"`
 }
 
-func (q *Queries) ListAllOpportunitiesForDeduplication(ctx context.Context) ([]ListAllOpportunitiesForDeduplicationRow, error) {
-	rows, err := q.db.QueryContext(ctx, listAllOpportunitiesForDeduplication)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []ListAllOpportunitiesForDeduplicationRow
-	for rows.Next() {
-		var i ListAllOpportunitiesForDeduplicationRow
-		if err := rows.Scan(&i.OpportunityID, &i.Struggle, &i.ThemeName); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+type CreateWebhookRow struct {
+	ID          uuid.UUID      `db:"id" json:"id"`
+	Url         string         `db:"url" json:"url"`
+	Secret      string         `db:"secret" json:"secret"`
+	Events      string         `db:"events" json:"events"`
+	Description sql.NullString `db:"description" json:"description"`
+	Active      bool           `db:"active" json:"active"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time      `db:"updated_at" json:"updated_at"`
 }
 
-const listEvidenceByOpportunity = `-- name: ListEvidenceByOpportunity :many
-SELECT 
-    oe.id,
-    oe.quote,
-    oe.context,
-    oe.created_at,
-    
-    m.id            AS meeting_id,
-    m.title         AS meeting_title,
-    m.created_at    AS meeting_date,
-    
-    COUNT(*) OVER() AS total_count
+// events is a comma-separated list.
+func (q *Queries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (CreateWebhookRow, error) {
+	row := q.db.QueryRowContext(ctx, createWebhook,
+		arg.Url,
+		arg.Secret,
+		arg.Description,
+		arg.Events,
+	)
+	var i CreateWebhookRow
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.Secret,
+		&i.Events,
+		&i.Description,
+		&i.Active,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
 
-FROM opportunity_evidence oe
-JOIN meetings m ON oe.meeting_id = m.id
-WHERE oe.opportunity_id = $1
-ORDER BY oe.created_at DESC
+const deleteEvidence = `-- name: DeleteEvidence :one
+DELETE FROM opportunity_evidence WHERE id = $1
+RETURNING opportunity_id, meeting_id, quote
 `
 
-type ListEvidenceByOpportunityRow struct {
-	ID           uuid.UUID      `db:"id" json:"id"`
-	Quote        string         `db:"quote" json:"quote"`
-	Context      sql.NullString `db:"context" json:"context"`
-	CreatedAt    sql.NullTime   `db:"created_at" json:"created_at"`
-	MeetingID    uuid.UUID      `db:"meeting_id" json:"meeting_id"`
-	MeetingTitle string         `db:"meeting_title" json:"meeting_title"`
-	MeetingDate  sql.NullTime   `db:"meeting_date" json:"meeting_date"`
-	TotalCount   int64          `db:"total_count" json:"total_count"`
+type DeleteEvidenceRow struct {
+	OpportunityID uuid.UUID `db:"opportunity_id" json:"opportunity_id"`
+	MeetingID     uuid.UUID `db:"meeting_id" json:"meeting_id"`
+	Quote         string    `db:"quote" json:"quote"`
 }
 
-func (q *Queries) ListEvidenceByOpportunity(ctx context.Context, opportunityID uuid.UUID) ([]ListEvidenceByOpportunityRow, error) {
-	rows, err := q.db.QueryContext(ctx, listEvidenceByOpportunity, opportunityID)
+func (q *Queries) DeleteEvidence(ctx context.Context, id uuid.UUID) (DeleteEvidenceRow, error) {
+	row := q.db.QueryRowContext(ctx, deleteEvidence, id)
+	var i DeleteEvidenceRow
+	err := row.Scan(&i.OpportunityID, &i.MeetingID, &i.Quote)
+	return i, err
+}
+
+const deleteExperiment = `-- name: DeleteExperiment :one
+DELETE FROM experiments WHERE id = $1
+RETURNING solution_id
+`
+
+func (q *Queries) DeleteExperiment(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, deleteExperiment, id)
+	var solution_id uuid.UUID
+	err := row.Scan(&solution_id)
+	return solution_id, err
+}
+
+const deleteMeetingContribution = `-- name: DeleteMeetingContribution :many
+WITH removed AS (
+    DELETE FROM opportunity_evidence
+    WHERE meeting_id = $1
+    RETURNING opportunity_id
+)
+DELETE FROM opportunities o
+WHERE o.id IN (SELECT opportunity_id FROM removed)
+  AND NOT EXISTS (
+    SELECT 1 FROM opportunity_evidence oe
+    WHERE oe.opportunity_id = o.id AND oe.meeting_id <> $1
+  )
+  AND NOT EXISTS (SELECT 1 FROM solutions s WHERE s.opportunity_id = o.id)
+  AND NOT EXISTS (SELECT 1 FROM opportunities c WHERE c.parent_id = o.id)
+RETURNING o.id
+`
+
+// Removes the evidence a meeting contributed and deletes the opportunities
+// left with no evidence at all, unless someone added solutions or child opportunities to them.
+// Returns the deleted opportunity IDs.
+func (q *Queries) DeleteMeetingContribution(ctx context.Context, meetingID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, deleteMeetingContribution, meetingID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ListEvidenceByOpportunityRow
+	var items []uuid.UUID
 	for rows.Next() {
-		var i ListEvidenceByOpportunityRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.Quote,
-			&i.Context,
-			&i.CreatedAt,
-			&i.MeetingID,
-			&i.MeetingTitle,
-			&i.MeetingDate,
-			&i.TotalCount,
-		); err != nil {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
 			return nil, err
 		}
-		items = append(items, i)
+		items = append(items, id)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -293,57 +608,2432 @@ func (q *Queries) ListEvidenceByOpportunity(ctx context.Context, opportunityID u
 	return items, nil
 }
 
-const listRecentOpportunities = `-- name: ListRecentOpportunities :many
-SELECT 
-    o.id, o.user_segment, o.struggle, o.why_it_matters, o.workaround, o.theme_id, o.created_at, o.updated_at,
-    t.name AS theme_name,
-    COALESCE(ev_count.cnt, 0) AS evidence_count
+const deleteOpportunity = `-- name: DeleteOpportunity :execrows
+DELETE FROM opportunities WHERE id = $1
+`
+
+func (q *Queries) DeleteOpportunity(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteOpportunity, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteOutcome = `-- name: DeleteOutcome :execrows
+DELETE FROM outcomes WHERE id = $1
+`
+
+func (q *Queries) DeleteOutcome(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteOutcome, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteSolution = `-- name: DeleteSolution :one
+DELETE FROM solutions WHERE id = $1
+RETURNING opportunity_id
+`
+
+func (q *Queries) DeleteSolution(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, deleteSolution, id)
+	var opportunity_id uuid.UUID
+	err := row.Scan(&opportunity_id)
+	return opportunity_id, err
+}
+
+const deleteTheme = `-- name: DeleteTheme :exec
+DELETE FROM themes WHERE id = $1
+`
+
+func (q *Queries) DeleteTheme(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteTheme, id)
+	return err
+}
+
+const deleteWebhook = `-- name: DeleteWebhook :execrows
+DELETE FROM webhooks WHERE id = $1
+`
+
+func (q *Queries) DeleteWebhook(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteWebhook, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const dropDuplicateEvidence = `-- name: DropDuplicateEvidence :execrows
+DELETE FROM opportunity_evidence s
+USING opportunity_evidence t
+WHERE s.opportunity_id = $1
+  AND t.opportunity_id = $2
+  AND t.meeting_id = s.meeting_id
+  AND md5(t.quote) = md5(s.quote)
+`
+
+type DropDuplicateEvidenceParams struct {
+	SourceID uuid.UUID `db:"source_id" json:"source_id"`
+	TargetID uuid.UUID `db:"target_id" json:"target_id"`
+}
+
+// Removes source quotes the target already holds (same meeting and quote),
+// which would otherwise break the unique index when moved.
+func (q *Queries) DropDuplicateEvidence(ctx context.Context, arg DropDuplicateEvidenceParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, dropDuplicateEvidence, arg.SourceID, arg.TargetID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const enqueueJob = `-- name: EnqueueJob :exec
+INSERT INTO jobs (meeting_id) VALUES ($1)
+ON CONFLICT (meeting_id) DO UPDATE
+SET rerun = TRUE, updated_at = NOW()
+WHERE jobs.status = 'running'
+`
+
+// A meeting can only have one job at a time. Enqueueing a queued job again is a
+// no-op; enqueueing a running one marks it to run again once it finishes.
+func (q *Queries) EnqueueJob(ctx context.Context, meetingID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, enqueueJob, meetingID)
+	return err
+}
+
+const enqueueWebhookDeliveries = `-- name: EnqueueWebhookDeliveries :execrows
+INSERT INTO webhook_deliveries (webhook_id, event, payload)
+SELECT w.id, $1::text, $2::jsonb
+FROM webhooks w
+WHERE w.active AND ($1::text = ANY(w.events) OR '*' = ANY(w.events))
+`
+
+type EnqueueWebhookDeliveriesParams struct {
+	Event   string          `db:"event" json:"event"`
+	Payload json.RawMessage `db:"payload" json:"payload"`
+}
+
+// One delivery per active webhook subscribed to the event.
+func (q *Queries) EnqueueWebhookDeliveries(ctx context.Context, arg EnqueueWebhookDeliveriesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, enqueueWebhookDeliveries, arg.Event, arg.Payload)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const extendJobLease = `-- name: ExtendJobLease :exec
+UPDATE jobs
+SET locked_at = NOW(), updated_at = NOW()
+WHERE meeting_id = $1 AND status = 'running'
+`
+
+// Keeps a long-running job from being requeued as stale.
+func (q *Queries) ExtendJobLease(ctx context.Context, meetingID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, extendJobLease, meetingID)
+	return err
+}
+
+const extendMeetingLease = `-- name: ExtendMeetingLease :execrows
+UPDATE meetings
+SET lease_expires_at = NOW() + make_interval(secs => $2::int)
+WHERE id = $1
+  AND processing_status = 'processing'
+  AND processing_attempts = $3
+`
+
+type ExtendMeetingLeaseParams struct {
+	ID           uuid.UUID `db:"id" json:"id"`
+	LeaseSeconds int32     `db:"lease_seconds" json:"lease_seconds"`
+	Attempt      int32     `db:"attempt" json:"attempt"`
+}
+
+// Renews the lease of a running attempt. Returns no rows when the lease was lost:
+// the meeting finished, or another worker took it over and started a new attempt.
+func (q *Queries) ExtendMeetingLease(ctx context.Context, arg ExtendMeetingLeaseParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, extendMeetingLease, arg.ID, arg.LeaseSeconds, arg.Attempt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const findOpportunityFromMeeting = `-- name: FindOpportunityFromMeeting :one
+SELECT o.id
 FROM opportunities o
+JOIN opportunity_evidence oe ON oe.opportunity_id = o.id
+WHERE oe.meeting_id = $1 AND LOWER(o.struggle) = LOWER($2)
+LIMIT 1
+`
+
+type FindOpportunityFromMeetingParams struct {
+	MeetingID uuid.UUID `db:"meeting_id" json:"meeting_id"`
+	Struggle  string    `db:"struggle" json:"struggle"`
+}
+
+// Finds an opportunity this meeting already created, so replaying it reuses the row.
+func (q *Queries) FindOpportunityFromMeeting(ctx context.Context, arg FindOpportunityFromMeetingParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, findOpportunityFromMeeting, arg.MeetingID, arg.Struggle)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getCustomer = `-- name: GetCustomer :one
+SELECT id, name, plan, arr, created_at, updated_at FROM customers WHERE id = $1
+`
+
+func (q *Queries) GetCustomer(ctx context.Context, id uuid.UUID) (Customer, error) {
+	row := q.db.QueryRowContext(ctx, getCustomer, id)
+	var i Customer
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Plan,
+		&i.Arr,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getEmbeddingDimensions = `-- name: GetEmbeddingDimensions :one
+SELECT atttypmod::int AS dimensions FROM pg_catalog.pg_attribute
+WHERE attrelid = 'opportunity_embeddings'::regclass AND attname = 'embedding'
+`
+
+// n of the embedding column's vector(n), which pgvector keeps as the type modifier.
+func (q *Queries) GetEmbeddingDimensions(ctx context.Context) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getEmbeddingDimensions)
+	var dimensions int32
+	err := row.Scan(&dimensions)
+	return dimensions, err
+}
+
+const getMeeting = `-- name: GetMeeting :one
+SELECT id, title, raw_notes, source, metadata, processing_status, processing_error, created_at, updated_at, processed_at, processing_attempts, error_history, next_attempt_at, lease_expires_at, validation_issues, language, customer_id, slack_response_url FROM meetings WHERE id = $1
+`
+
+func (q *Queries) GetMeeting(ctx context.Context, id uuid.UUID) (Meeting, error) {
+	row := q.db.QueryRowContext(ctx, getMeeting, id)
+	var i Meeting
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.RawNotes,
+		&i.Source,
+		&i.Metadata,
+		&i.ProcessingStatus,
+		&i.ProcessingError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ProcessedAt,
+		&i.ProcessingAttempts,
+		&i.ErrorHistory,
+		&i.NextAttemptAt,
+		&i.LeaseExpiresAt,
+		&i.ValidationIssues,
+		&i.Language,
+		&i.CustomerID,
+		&i.SlackResponseUrl,
+	)
+	return i, err
+}
 
+const getOpportunity = `-- name: GetOpportunity :one
+SELECT 
+    o.id, o.user_segment, o.struggle, o.why_it_matters, o.workaround, o.theme_id, o.created_at, o.updated_at, o.search_config, o.impact, o.confidence, o.effort, o.outcome_id, o.parent_id,
+    
+    t.name AS theme_name,
+    COUNT(oe.id) AS evidence_count
+FROM opportunities o
 LEFT JOIN themes t ON o.theme_id = t.id
-LEFT JOIN LATERAL (
-    SELECT COUNT(*) AS cnt
-    FROM opportunity_evidence oe
-    WHERE oe.opportunity_id = o.id
-) ev_count ON true
+LEFT JOIN opportunity_evidence oe ON oe.opportunity_id = o.id
+WHERE o.id = $1
+GROUP BY o.id, t.name
+`
 
-WHERE o.created_at >= NOW() - INTERVAL '24 hours'
+type GetOpportunityRow struct {
+	ID            uuid.UUID       `db:"id" json:"id"`
+	UserSegment   string          `db:"user_segment" json:"user_segment"`
+	Struggle      string          `db:"struggle" json:"struggle"`
+	WhyItMatters  sql.NullString  `db:"why_it_matters" json:"why_it_matters"`
+	Workaround    sql.NullString  `db:"workaround" json:"workaround"`
+	ThemeID       uuid.NullUUID   `db:"theme_id" json:"theme_id"`
+	CreatedAt     sql.NullTime    `db:"created_at" json:"created_at"`
+	UpdatedAt     sql.NullTime    `db:"updated_at" json:"updated_at"`
+	SearchConfig  string          `db:"search_config" json:"search_config"`
+	Impact        sql.NullFloat64 `db:"impact" json:"impact"`
+	Confidence    sql.NullFloat64 `db:"confidence" json:"confidence"`
+	Effort        sql.NullFloat64 `db:"effort" json:"effort"`
+	OutcomeID     uuid.NullUUID   `db:"outcome_id" json:"outcome_id"`
+	ParentID      uuid.NullUUID   `db:"parent_id" json:"parent_id"`
+	ThemeName     sql.NullString  `db:"theme_name" json:"theme_name"`
+	EvidenceCount int64           `db:"evidence_count" json:"evidence_count"`
+}
+
+func (q *Queries) GetOpportunity(ctx context.Context, id uuid.UUID) (GetOpportunityRow, error) {
+	row := q.db.QueryRowContext(ctx, getOpportunity, id)
+	var i GetOpportunityRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserSegment,
+		&i.Struggle,
+		&i.WhyItMatters,
+		&i.Workaround,
+		&i.ThemeID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SearchConfig,
+		&i.Impact,
+		&i.Confidence,
+		&i.Effort,
+		&i.OutcomeID,
+		&i.ParentID,
+		&i.ThemeName,
+		&i.EvidenceCount,
+	)
+	return i, err
+}
+
+const getOpportunityRedirect = `-- name: GetOpportunityRedirect :one
+SELECT to_id FROM opportunity_redirects WHERE from_id = $1
+`
+
+func (q *Queries) GetOpportunityRedirect(ctx context.Context, fromID uuid.UUID) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, getOpportunityRedirect, fromID)
+	var to_id uuid.UUID
+	err := row.Scan(&to_id)
+	return to_id, err
+}
+
+const getOutcome = `-- name: GetOutcome :one
+SELECT id, title, description, metric, created_at, updated_at FROM outcomes WHERE id = $1
+`
+
+func (q *Queries) GetOutcome(ctx context.Context, id uuid.UUID) (Outcome, error) {
+	row := q.db.QueryRowContext(ctx, getOutcome, id)
+	var i Outcome
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Description,
+		&i.Metric,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getScoringWeights = `-- name: GetScoringWeights :one
+SELECT workspace, reach_per_customer, reach_per_evidence, reach_weight, impact_weight, confidence_weight, effort_weight, updated_at FROM scoring_weights WHERE workspace = $1
+`
+
+func (q *Queries) GetScoringWeights(ctx context.Context, workspace string) (ScoringWeight, error) {
+	row := q.db.QueryRowContext(ctx, getScoringWeights, workspace)
+	var i ScoringWeight
+	err := row.Scan(
+		&i.Workspace,
+		&i.ReachPerCustomer,
+		&i.ReachPerEvidence,
+		&i.ReachWeight,
+		&i.ImpactWeight,
+		&i.ConfidenceWeight,
+		&i.EffortWeight,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getSolution = `-- name: GetSolution :one
+SELECT id, opportunity_id, title, description, status, created_at, updated_at FROM solutions WHERE id = $1
+`
+
+func (q *Queries) GetSolution(ctx context.Context, id uuid.UUID) (Solution, error) {
+	row := q.db.QueryRowContext(ctx, getSolution, id)
+	var i Solution
+	err := row.Scan(
+		&i.ID,
+		&i.OpportunityID,
+		&i.Title,
+		&i.Description,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getThemeByName = `-- name: GetThemeByName :one
+SELECT id, name, created_at, updated_at FROM themes WHERE name = $1
+`
+
+func (q *Queries) GetThemeByName(ctx context.Context, name string) (Theme, error) {
+	row := q.db.QueryRowContext(ctx, getThemeByName, name)
+	var i Theme
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getWebhook = `-- name: GetWebhook :one
+SELECT id, url, secret, array_to_string(events, ',')::text AS events, description, active, created_at, updated_at
+FROM webhooks WHERE id = $1
+`
+
+type GetWebhookRow struct {
+	ID          uuid.UUID      `db:"id" json:"id"`
+	Url         string         `db:"url" json:"url"`
+	Secret      string         `db:"secret" json:"secret"`
+	Events      string         `db:"events" json:"events"`
+	Description sql.NullString `db:"description" json:"description"`
+	Active      bool           `db:"active" json:"active"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+func (q *Queries) GetWebhook(ctx context.Context, id uuid.UUID) (GetWebhookRow, error) {
+	row := q.db.QueryRowContext(ctx, getWebhook, id)
+	var i GetWebhookRow
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.Secret,
+		&i.Events,
+		&i.Description,
+		&i.Active,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const keepOpportunity = `-- name: KeepOpportunity :one
+SELECT id FROM opportunities WHERE id = $1 FOR KEY SHARE
+`
+
+// Keeps the opportunity from being merged away or deleted until the transaction
+// ends, without blocking others that only add evidence to it.
+func (q *Queries) KeepOpportunity(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, keepOpportunity, id)
+	err := row.Scan(&id)
+	return id, err
+}
+
+const listAllOpportunitiesForDeduplication = `-- name: ListAllOpportunitiesForDeduplication :many
+SELECT 
+    o.id::text AS opportunity_id,
+    o.struggle AS struggle,
+    COALESCE(t.name, 'No theme') AS theme_name
+FROM opportunities o
+LEFT JOIN themes t ON o.theme_id = t.id
 ORDER BY o.created_at DESC
 `
 
-type ListRecentOpportunitiesRow struct {
-	ID            uuid.UUID      `db:"id" json:"id"`
-	UserSegment   string         `db:"user_segment" json:"user_segment"`
-	Struggle      string         `db:"struggle" json:"struggle"`
-	WhyItMatters  sql.NullString `db:"why_it_matters" json:"why_it_matters"`
-	Workaround    sql.NullString `db:"workaround" json:"workaround"`
-	ThemeID       uuid.NullUUID  `db:"theme_id" json:"theme_id"`
-	CreatedAt     sql.NullTime   `db:"created_at" json:"created_at"`
-	UpdatedAt     sql.NullTime   `db:"updated_at" json:"updated_at"`
-	ThemeName     sql.NullString `db:"theme_name" json:"theme_name"`
-	EvidenceCount int64          `db:"evidence_count" json:"evidence_count"`
+type ListAllOpportunitiesForDeduplicationRow struct {
+	OpportunityID string `db:"opportunity_id" json:"opportunity_id"`
+	Struggle      string `db:"struggle" json:"struggle"`
+	ThemeName     string `db:"theme_name" json:"theme_name"`
+}
+
+func (q *Queries) ListAllOpportunitiesForDeduplication(ctx context.Context) ([]ListAllOpportunitiesForDeduplicationRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAllOpportunitiesForDeduplication)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAllOpportunitiesForDeduplicationRow
+	for rows.Next() {
+		var i ListAllOpportunitiesForDeduplicationRow
+		if err := rows.Scan(&i.OpportunityID, &i.Struggle, &i.ThemeName); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuditEntries = `-- name: ListAuditEntries :many
+SELECT id, action, entity_type, entity_id, actor, details, created_at
+FROM audit_log
+WHERE entity_type = $1 AND entity_id = $2
+ORDER BY created_at
+`
+
+type ListAuditEntriesParams struct {
+	EntityType string    `db:"entity_type" json:"entity_type"`
+	EntityID   uuid.UUID `db:"entity_id" json:"entity_id"`
+}
+
+func (q *Queries) ListAuditEntries(ctx context.Context, arg ListAuditEntriesParams) ([]AuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, listAuditEntries, arg.EntityType, arg.EntityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditLog
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Action,
+			&i.EntityType,
+			&i.EntityID,
+			&i.Actor,
+			&i.Details,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCustomerOpportunities = `-- name: ListCustomerOpportunities :many
+SELECT
+    o.id, o.user_segment, o.struggle, o.why_it_matters, o.workaround, o.created_at,
+    t.name AS theme_name,
+    COUNT(oe.id) AS evidence_count
+FROM opportunities o
+JOIN opportunity_evidence oe ON oe.opportunity_id = o.id
+JOIN meetings m ON m.id = oe.meeting_id
+LEFT JOIN themes t ON t.id = o.theme_id
+WHERE m.customer_id = $1::uuid
+GROUP BY o.id, t.name
+ORDER BY evidence_count DESC, o.created_at DESC
+`
+
+type ListCustomerOpportunitiesRow struct {
+	ID            uuid.UUID      `db:"id" json:"id"`
+	UserSegment   string         `db:"user_segment" json:"user_segment"`
+	Struggle      string         `db:"struggle" json:"struggle"`
+	WhyItMatters  sql.NullString `db:"why_it_matters" json:"why_it_matters"`
+	Workaround    sql.NullString `db:"workaround" json:"workaround"`
+	CreatedAt     sql.NullTime   `db:"created_at" json:"created_at"`
+	ThemeName     sql.NullString `db:"theme_name" json:"theme_name"`
+	EvidenceCount int64          `db:"evidence_count" json:"evidence_count"`
+}
+
+// Opportunities with evidence from the customer's meetings, most mentioned first.
+func (q *Queries) ListCustomerOpportunities(ctx context.Context, customerID uuid.UUID) ([]ListCustomerOpportunitiesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCustomerOpportunities, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCustomerOpportunitiesRow
+	for rows.Next() {
+		var i ListCustomerOpportunitiesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserSegment,
+			&i.Struggle,
+			&i.WhyItMatters,
+			&i.Workaround,
+			&i.CreatedAt,
+			&i.ThemeName,
+			&i.EvidenceCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCustomers = `-- name: ListCustomers :many
+SELECT
+    c.id, c.name, c.plan, c.arr, c.created_at, c.updated_at,
+    (SELECT COUNT(*) FROM meetings m WHERE m.customer_id = c.id) AS meeting_count
+FROM customers c
+ORDER BY c.arr DESC NULLS LAST, LOWER(c.name)
+`
+
+type ListCustomersRow struct {
+	ID           uuid.UUID       `db:"id" json:"id"`
+	Name         string          `db:"name" json:"name"`
+	Plan         sql.NullString  `db:"plan" json:"plan"`
+	Arr          sql.NullFloat64 `db:"arr" json:"arr"`
+	CreatedAt    time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time       `db:"updated_at" json:"updated_at"`
+	MeetingCount int64           `db:"meeting_count" json:"meeting_count"`
+}
+
+func (q *Queries) ListCustomers(ctx context.Context) ([]ListCustomersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCustomers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCustomersRow
+	for rows.Next() {
+		var i ListCustomersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Plan,
+			&i.Arr,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.MeetingCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEvidenceByOpportunity = `-- name: ListEvidenceByOpportunity :many
+SELECT 
+    oe.id,
+    oe.quote,
+    oe.context,
+    oe.chunk_index,
+    oe.chunk_offset,
+    oe.verified,
+    oe.quote_start,
+    oe.quote_end,
+    oe.created_at,
+    
+    m.id            AS meeting_id,
+    m.title         AS meeting_title,
+    m.created_at    AS meeting_date,
+    
+    COUNT(*) OVER() AS total_count
+
+FROM opportunity_evidence oe
+JOIN meetings m ON oe.meeting_id = m.id
+WHERE oe.opportunity_id = $1
+ORDER BY oe.created_at DESC
+`
+
+type ListEvidenceByOpportunityRow struct {
+	ID           uuid.UUID      `db:"id" json:"id"`
+	Quote        string         `db:"quote" json:"quote"`
+	Context      sql.NullString `db:"context" json:"context"`
+	ChunkIndex   int32          `db:"chunk_index" json:"chunk_index"`
+	ChunkOffset  int32          `db:"chunk_offset" json:"chunk_offset"`
+	Verified     bool           `db:"verified" json:"verified"`
+	QuoteStart   sql.NullInt32  `db:"quote_start" json:"quote_start"`
+	QuoteEnd     sql.NullInt32  `db:"quote_end" json:"quote_end"`
+	CreatedAt    sql.NullTime   `db:"created_at" json:"created_at"`
+	MeetingID    uuid.UUID      `db:"meeting_id" json:"meeting_id"`
+	MeetingTitle string         `db:"meeting_title" json:"meeting_title"`
+	MeetingDate  sql.NullTime   `db:"meeting_date" json:"meeting_date"`
+	TotalCount   int64          `db:"total_count" json:"total_count"`
+}
+
+func (q *Queries) ListEvidenceByOpportunity(ctx context.Context, opportunityID uuid.UUID) ([]ListEvidenceByOpportunityRow, error) {
+	rows, err := q.db.QueryContext(ctx, listEvidenceByOpportunity, opportunityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListEvidenceByOpportunityRow
+	for rows.Next() {
+		var i ListEvidenceByOpportunityRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Quote,
+			&i.Context,
+			&i.ChunkIndex,
+			&i.ChunkOffset,
+			&i.Verified,
+			&i.QuoteStart,
+			&i.QuoteEnd,
+			&i.CreatedAt,
+			&i.MeetingID,
+			&i.MeetingTitle,
+			&i.MeetingDate,
+			&i.TotalCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMeetingEvidence = `-- name: ListMeetingEvidence :many
+SELECT
+    o.id AS opportunity_id,
+    o.user_segment,
+    o.struggle,
+    o.why_it_matters,
+    o.workaround,
+    o.created_at AS opportunity_created_at,
+    t.name AS theme_name,
+    oe.id,
+    oe.quote,
+    oe.context,
+    oe.chunk_index,
+    oe.chunk_offset,
+    oe.verified,
+    oe.quote_start,
+    oe.quote_end,
+    oe.created_at
+FROM opportunity_evidence oe
+JOIN opportunities o ON o.id = oe.opportunity_id
+LEFT JOIN themes t ON o.theme_id = t.id
+WHERE oe.meeting_id = $1
+ORDER BY o.created_at, o.id, oe.quote_start NULLS LAST, oe.created_at
+`
+
+type ListMeetingEvidenceRow struct {
+	OpportunityID        uuid.UUID      `db:"opportunity_id" json:"opportunity_id"`
+	UserSegment          string         `db:"user_segment" json:"user_segment"`
+	Struggle             string         `db:"struggle" json:"struggle"`
+	WhyItMatters         sql.NullString `db:"why_it_matters" json:"why_it_matters"`
+	Workaround           sql.NullString `db:"workaround" json:"workaround"`
+	OpportunityCreatedAt sql.NullTime   `db:"opportunity_created_at" json:"opportunity_created_at"`
+	ThemeName            sql.NullString `db:"theme_name" json:"theme_name"`
+	ID                   uuid.UUID      `db:"id" json:"id"`
+	Quote                string         `db:"quote" json:"quote"`
+	Context              sql.NullString `db:"context" json:"context"`
+	ChunkIndex           int32          `db:"chunk_index" json:"chunk_index"`
+	ChunkOffset          int32          `db:"chunk_offset" json:"chunk_offset"`
+	Verified             bool           `db:"verified" json:"verified"`
+	QuoteStart           sql.NullInt32  `db:"quote_start" json:"quote_start"`
+	QuoteEnd             sql.NullInt32  `db:"quote_end" json:"quote_end"`
+	CreatedAt            sql.NullTime   `db:"created_at" json:"created_at"`
+}
+
+// Everything extracted from one meeting, grouped by opportunity.
+func (q *Queries) ListMeetingEvidence(ctx context.Context, meetingID uuid.UUID) ([]ListMeetingEvidenceRow, error) {
+	rows, err := q.db.QueryContext(ctx, listMeetingEvidence, meetingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMeetingEvidenceRow
+	for rows.Next() {
+		var i ListMeetingEvidenceRow
+		if err := rows.Scan(
+			&i.OpportunityID,
+			&i.UserSegment,
+			&i.Struggle,
+			&i.WhyItMatters,
+			&i.Workaround,
+			&i.OpportunityCreatedAt,
+			&i.ThemeName,
+			&i.ID,
+			&i.Quote,
+			&i.Context,
+			&i.ChunkIndex,
+			&i.ChunkOffset,
+			&i.Verified,
+			&i.QuoteStart,
+			&i.QuoteEnd,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMeetings = `-- name: ListMeetings :many
+SELECT
+    m.id, m.title, m.source, m.metadata, m.processing_status, m.processing_error,
+    m.processing_attempts, m.created_at, m.updated_at, m.processed_at,
+    (SELECT COUNT(*) FROM opportunity_evidence oe WHERE oe.meeting_id = m.id) AS evidence_count
+FROM meetings m
+WHERE ($1::text IS NULL OR m.processing_status = $1)
+  AND ($2::text IS NULL OR m.source = $2)
+  AND ($3::timestamptz IS NULL OR m.created_at >= $3)
+  AND ($4::timestamptz IS NULL OR m.created_at < $4)
+  AND ($5::text IS NULL OR m.metadata ->> $5::text IS NOT NULL)
+  AND ($6::text IS NULL OR m.metadata ->> $5::text = $6)
+  AND ($7::text IS NULL
+       OR m.title ILIKE '%' || $7 || '%'
+       OR m.raw_notes ILIKE '%' || $7 || '%')
+  AND ($8::timestamptz IS NULL
+       OR (m.created_at, m.id) < ($8, $9::uuid))
+ORDER BY m.created_at DESC, m.id DESC
+LIMIT $10
+`
+
+type ListMeetingsParams struct {
+	Status        sql.NullString `db:"status" json:"status"`
+	Source        sql.NullString `db:"source" json:"source"`
+	CreatedAfter  sql.NullTime   `db:"created_after" json:"created_after"`
+	CreatedBefore sql.NullTime   `db:"created_before" json:"created_before"`
+	MetadataKey   sql.NullString `db:"metadata_key" json:"metadata_key"`
+	MetadataValue sql.NullString `db:"metadata_value" json:"metadata_value"`
+	Search        sql.NullString `db:"search" json:"search"`
+	CursorCreated sql.NullTime   `db:"cursor_created" json:"cursor_created"`
+	CursorID      uuid.NullUUID  `db:"cursor_id" json:"cursor_id"`
+	MaxRows       int32          `db:"max_rows" json:"max_rows"`
+}
+
+type ListMeetingsRow struct {
+	ID                 uuid.UUID             `db:"id" json:"id"`
+	Title              string                `db:"title" json:"title"`
+	Source             string                `db:"source" json:"source"`
+	Metadata           pqtype.NullRawMessage `db:"metadata" json:"metadata"`
+	ProcessingStatus   string                `db:"processing_status" json:"processing_status"`
+	ProcessingError    sql.NullString        `db:"processing_error" json:"processing_error"`
+	ProcessingAttempts int32                 `db:"processing_attempts" json:"processing_attempts"`
+	CreatedAt          sql.NullTime          `db:"created_at" json:"created_at"`
+	UpdatedAt          sql.NullTime          `db:"updated_at" json:"updated_at"`
+	ProcessedAt        sql.NullTime          `db:"processed_at" json:"processed_at"`
+	EvidenceCount      int64                 `db:"evidence_count" json:"evidence_count"`
+}
+
+// Newest first. Pass the last row's created_at and id as the cursor to get the next page.
+func (q *Queries) ListMeetings(ctx context.Context, arg ListMeetingsParams) ([]ListMeetingsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listMeetings,
+		arg.Status,
+		arg.Source,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.MetadataKey,
+		arg.MetadataValue,
+		arg.Search,
+		arg.CursorCreated,
+		arg.CursorID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMeetingsRow
+	for rows.Next() {
+		var i ListMeetingsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Source,
+			&i.Metadata,
+			&i.ProcessingStatus,
+			&i.ProcessingError,
+			&i.ProcessingAttempts,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ProcessedAt,
+			&i.EvidenceCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMeetingsForReprocess = `-- name: ListMeetingsForReprocess :many
+SELECT id FROM meetings
+WHERE processing_status <> 'processing'
+  AND ($1::text IS NULL OR processing_status = $1)
+  AND ($2::text IS NULL OR source = $2)
+  AND ($3::timestamptz IS NULL OR created_at >= $3)
+  AND ($4::timestamptz IS NULL OR created_at < $4)
+ORDER BY created_at
+LIMIT $5
+`
+
+type ListMeetingsForReprocessParams struct {
+	Status        sql.NullString `db:"status" json:"status"`
+	Source        sql.NullString `db:"source" json:"source"`
+	CreatedAfter  sql.NullTime   `db:"created_after" json:"created_after"`
+	CreatedBefore sql.NullTime   `db:"created_before" json:"created_before"`
+	MaxMeetings   int32          `db:"max_meetings" json:"max_meetings"`
+}
+
+func (q *Queries) ListMeetingsForReprocess(ctx context.Context, arg ListMeetingsForReprocessParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, listMeetingsForReprocess,
+		arg.Status,
+		arg.Source,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.MaxMeetings,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMeetingsWithoutCustomer = `-- name: ListMeetingsWithoutCustomer :many
+SELECT id, metadata, created_at FROM meetings
+WHERE customer_id IS NULL
+  AND metadata IS NOT NULL
+  AND ($1::uuid IS NULL
+       OR (created_at, id) > ($2::timestamptz, $1::uuid))
+ORDER BY created_at, id
+LIMIT $3
+`
+
+type ListMeetingsWithoutCustomerParams struct {
+	AfterID   uuid.NullUUID `db:"after_id" json:"after_id"`
+	AfterTime sql.NullTime  `db:"after_time" json:"after_time"`
+	MaxRows   int32         `db:"max_rows" json:"max_rows"`
+}
+
+type ListMeetingsWithoutCustomerRow struct {
+	ID        uuid.UUID             `db:"id" json:"id"`
+	Metadata  pqtype.NullRawMessage `db:"metadata" json:"metadata"`
+	CreatedAt sql.NullTime          `db:"created_at" json:"created_at"`
+}
+
+// Meetings with metadata but no customer, oldest first. Pass the last row's
+// created_at and id to get the next batch.
+func (q *Queries) ListMeetingsWithoutCustomer(ctx context.Context, arg ListMeetingsWithoutCustomerParams) ([]ListMeetingsWithoutCustomerRow, error) {
+	rows, err := q.db.QueryContext(ctx, listMeetingsWithoutCustomer, arg.AfterID, arg.AfterTime, arg.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMeetingsWithoutCustomerRow
+	for rows.Next() {
+		var i ListMeetingsWithoutCustomerRow
+		if err := rows.Scan(&i.ID, &i.Metadata, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNearestOpportunities = `-- name: ListNearestOpportunities :many
+SELECT
+    o.id::text AS opportunity_id,
+    o.struggle AS struggle,
+    COALESCE(t.name, 'No theme') AS theme_name
+FROM opportunity_embeddings e
+JOIN opportunities o ON o.id = e.opportunity_id
+LEFT JOIN themes t ON o.theme_id = t.id
+WHERE e.model = $1::text
+ORDER BY e.embedding <=> $2::text::vector
+LIMIT $3
+`
+
+type ListNearestOpportunitiesParams struct {
+	Model     string `db:"model" json:"model"`
+	Embedding string `db:"embedding" json:"embedding"`
+	TopK      int32  `db:"top_k" json:"top_k"`
+}
+
+type ListNearestOpportunitiesRow struct {
+	OpportunityID string `db:"opportunity_id" json:"opportunity_id"`
+	Struggle      string `db:"struggle" json:"struggle"`
+	ThemeName     string `db:"theme_name" json:"theme_name"`
+}
+
+// Same shape as ListAllOpportunitiesForDeduplication, limited to the top-K by cosine distance.
+func (q *Queries) ListNearestOpportunities(ctx context.Context, arg ListNearestOpportunitiesParams) ([]ListNearestOpportunitiesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listNearestOpportunities, arg.Model, arg.Embedding, arg.TopK)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListNearestOpportunitiesRow
+	for rows.Next() {
+		var i ListNearestOpportunitiesRow
+		if err := rows.Scan(&i.OpportunityID, &i.Struggle, &i.ThemeName); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOpportunities = `-- name: ListOpportunities :many
+WITH listed AS (
+    SELECT
+        o.id, o.user_segment, o.struggle, o.why_it_matters, o.workaround, o.created_at, o.outcome_id,
+        o.parent_id,
+        t.name AS theme_name,
+        COALESCE(ev.cnt, 0)::bigint AS evidence_count,
+        ev.last_at AS last_evidence_at
+    FROM opportunities o
+    LEFT JOIN themes t ON t.id = o.theme_id
+    LEFT JOIN LATERAL (
+        SELECT COUNT(*) AS cnt, MAX(oe.created_at) AS last_at
+        FROM opportunity_evidence oe
+        WHERE oe.opportunity_id = o.id
+    ) ev ON true
+    WHERE ($5::timestamptz IS NULL OR o.created_at >= $5)
+      AND ($6::timestamptz IS NULL OR o.created_at < $6)
+      AND ($7::text IS NULL OR LOWER(t.name) = LOWER($7))
+      AND ($8::text IS NULL OR LOWER(o.user_segment) = LOWER($8))
+),
+keyed AS (
+    SELECT
+        listed.id, listed.user_segment, listed.struggle, listed.why_it_matters, listed.workaround, listed.created_at, listed.outcome_id, listed.parent_id, listed.theme_name, listed.evidence_count, listed.last_evidence_at,
+        (CASE WHEN $9::text = 'evidence' THEN evidence_count ELSE 0 END)::bigint AS sort_count,
+        (CASE WHEN $9::text = 'last_evidence' THEN COALESCE(last_evidence_at, created_at)
+              ELSE created_at END)::timestamptz AS sort_time
+    FROM listed
+    WHERE evidence_count >= $10::bigint
+)
+SELECT
+    id, user_segment, struggle, why_it_matters, workaround, created_at, outcome_id, parent_id,
+    theme_name, evidence_count, sort_count, sort_time
+FROM keyed
+WHERE $1::uuid IS NULL
+   OR (sort_count, sort_time, id) < ($2::bigint, $3::timestamptz, $1::uuid)
+ORDER BY sort_count DESC, sort_time DESC, id DESC
+LIMIT $4
+`
+
+type ListOpportunitiesParams struct {
+	CursorID    uuid.NullUUID  `db:"cursor_id" json:"cursor_id"`
+	CursorCount sql.NullInt64  `db:"cursor_count" json:"cursor_count"`
+	CursorTime  sql.NullTime   `db:"cursor_time" json:"cursor_time"`
+	MaxRows     int32          `db:"max_rows" json:"max_rows"`
+	Since       sql.NullTime   `db:"since" json:"since"`
+	Until       sql.NullTime   `db:"until" json:"until"`
+	Theme       sql.NullString `db:"theme" json:"theme"`
+	Segment     sql.NullString `db:"segment" json:"segment"`
+	SortBy      string         `db:"sort_by" json:"sort_by"`
+	MinEvidence int64          `db:"min_evidence" json:"min_evidence"`
+}
+
+type ListOpportunitiesRow struct {
+	ID            uuid.UUID      `db:"id" json:"id"`
+	UserSegment   string         `db:"user_segment" json:"user_segment"`
+	Struggle      string         `db:"struggle" json:"struggle"`
+	WhyItMatters  sql.NullString `db:"why_it_matters" json:"why_it_matters"`
+	Workaround    sql.NullString `db:"workaround" json:"workaround"`
+	CreatedAt     sql.NullTime   `db:"created_at" json:"created_at"`
+	OutcomeID     uuid.NullUUID  `db:"outcome_id" json:"outcome_id"`
+	ParentID      uuid.NullUUID  `db:"parent_id" json:"parent_id"`
+	ThemeName     sql.NullString `db:"theme_name" json:"theme_name"`
+	EvidenceCount int64          `db:"evidence_count" json:"evidence_count"`
+	SortCount     int64          `db:"sort_count" json:"sort_count"`
+	SortTime      time.Time      `db:"sort_time" json:"sort_time"`
+}
+
+// One page of opportunities, sorted by sort_by: recent (created_at), evidence (evidence count,
+// then recency) or last_evidence (newest quote). Pass the last row's sort_count, sort_time and
+// id as the cursor to get the next page.
+func (q *Queries) ListOpportunities(ctx context.Context, arg ListOpportunitiesParams) ([]ListOpportunitiesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOpportunities,
+		arg.CursorID,
+		arg.CursorCount,
+		arg.CursorTime,
+		arg.MaxRows,
+		arg.Since,
+		arg.Until,
+		arg.Theme,
+		arg.Segment,
+		arg.SortBy,
+		arg.MinEvidence,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOpportunitiesRow
+	for rows.Next() {
+		var i ListOpportunitiesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserSegment,
+			&i.Struggle,
+			&i.WhyItMatters,
+			&i.Workaround,
+			&i.CreatedAt,
+			&i.OutcomeID,
+			&i.ParentID,
+			&i.ThemeName,
+			&i.EvidenceCount,
+			&i.SortCount,
+			&i.SortTime,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOpportunitiesMissingEmbedding = `-- name: ListOpportunitiesMissingEmbedding :many
+SELECT
+    o.id,
+    o.struggle,
+    COALESCE(t.name, '')::text AS theme_name,
+    md5(o.struggle || '|' || COALESCE(t.name, ''))::text AS content_hash
+FROM opportunities o
+LEFT JOIN themes t ON o.theme_id = t.id
+LEFT JOIN opportunity_embeddings e ON e.opportunity_id = o.id
+WHERE e.opportunity_id IS NULL
+   OR e.model <> $1::text
+   OR e.content_hash <> md5(o.struggle || '|' || COALESCE(t.name, ''))
+ORDER BY o.created_at
+LIMIT $2
+`
+
+type ListOpportunitiesMissingEmbeddingParams struct {
+	Model   string `db:"model" json:"model"`
+	MaxRows int32  `db:"max_rows" json:"max_rows"`
+}
+
+type ListOpportunitiesMissingEmbeddingRow struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	Struggle    string    `db:"struggle" json:"struggle"`
+	ThemeName   string    `db:"theme_name" json:"theme_name"`
+	ContentHash string    `db:"content_hash" json:"content_hash"`
+}
+
+// Opportunities never embedded, embedded by another model, or edited since.
+func (q *Queries) ListOpportunitiesMissingEmbedding(ctx context.Context, arg ListOpportunitiesMissingEmbeddingParams) ([]ListOpportunitiesMissingEmbeddingRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOpportunitiesMissingEmbedding, arg.Model, arg.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOpportunitiesMissingEmbeddingRow
+	for rows.Next() {
+		var i ListOpportunitiesMissingEmbeddingRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Struggle,
+			&i.ThemeName,
+			&i.ContentHash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOpportunityAncestors = `-- name: ListOpportunityAncestors :many
+WITH RECURSIVE ancestors AS (
+    SELECT p.id, p.parent_id, 1 AS depth
+    FROM opportunities o
+    JOIN opportunities p ON p.id = o.parent_id
+    WHERE o.id = $1
+    UNION ALL
+    SELECT p.id, p.parent_id, a.depth + 1
+    FROM ancestors a
+    JOIN opportunities p ON p.id = a.parent_id
+) CYCLE id SET is_cycle USING path
+SELECT
+    a.id, a.parent_id, a.depth::int AS depth,
+    o.user_segment, o.struggle, o.created_at,
+    t.name AS theme_name,
+    (SELECT COUNT(*) FROM opportunity_evidence oe WHERE oe.opportunity_id = a.id) AS evidence_count
+FROM ancestors a
+JOIN opportunities o ON o.id = a.id
+LEFT JOIN themes t ON t.id = o.theme_id
+WHERE NOT a.is_cycle
+ORDER BY a.depth
+`
+
+type ListOpportunityAncestorsRow struct {
+	ID            uuid.UUID      `db:"id" json:"id"`
+	ParentID      uuid.NullUUID  `db:"parent_id" json:"parent_id"`
+	Depth         int32          `db:"depth" json:"depth"`
+	UserSegment   string         `db:"user_segment" json:"user_segment"`
+	Struggle      string         `db:"struggle" json:"struggle"`
+	CreatedAt     sql.NullTime   `db:"created_at" json:"created_at"`
+	ThemeName     sql.NullString `db:"theme_name" json:"theme_name"`
+	EvidenceCount int64          `db:"evidence_count" json:"evidence_count"`
+}
+
+// The parent, grandparent and so on up to the root, nearest first (depth 1 is the parent).
+func (q *Queries) ListOpportunityAncestors(ctx context.Context, id uuid.UUID) ([]ListOpportunityAncestorsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOpportunityAncestors, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOpportunityAncestorsRow
+	for rows.Next() {
+		var i ListOpportunityAncestorsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ParentID,
+			&i.Depth,
+			&i.UserSegment,
+			&i.Struggle,
+			&i.CreatedAt,
+			&i.ThemeName,
+			&i.EvidenceCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOpportunitySubtree = `-- name: ListOpportunitySubtree :many
+WITH RECURSIVE subtree AS (
+    SELECT o.id, o.parent_id, 0 AS depth
+    FROM opportunities o
+    WHERE o.id = $1
+    UNION ALL
+    SELECT c.id, c.parent_id, s.depth + 1
+    FROM subtree s
+    JOIN opportunities c ON c.parent_id = s.id
+) CYCLE id SET is_cycle USING path
+SELECT
+    s.id, s.parent_id, s.depth::int AS depth,
+    o.user_segment, o.struggle, o.created_at,
+    t.name AS theme_name,
+    (SELECT COUNT(*) FROM opportunity_evidence oe WHERE oe.opportunity_id = s.id) AS evidence_count
+FROM subtree s
+JOIN opportunities o ON o.id = s.id
+LEFT JOIN themes t ON t.id = o.theme_id
+WHERE NOT s.is_cycle
+ORDER BY s.depth, evidence_count DESC, o.created_at DESC
+`
+
+type ListOpportunitySubtreeRow struct {
+	ID            uuid.UUID      `db:"id" json:"id"`
+	ParentID      uuid.NullUUID  `db:"parent_id" json:"parent_id"`
+	Depth         int32          `db:"depth" json:"depth"`
+	UserSegment   string         `db:"user_segment" json:"user_segment"`
+	Struggle      string         `db:"struggle" json:"struggle"`
+	CreatedAt     sql.NullTime   `db:"created_at" json:"created_at"`
+	ThemeName     sql.NullString `db:"theme_name" json:"theme_name"`
+	EvidenceCount int64          `db:"evidence_count" json:"evidence_count"`
+}
+
+// An opportunity (depth 0) and everything below it, parents before their children.
+func (q *Queries) ListOpportunitySubtree(ctx context.Context, id uuid.UUID) ([]ListOpportunitySubtreeRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOpportunitySubtree, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOpportunitySubtreeRow
+	for rows.Next() {
+		var i ListOpportunitySubtreeRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ParentID,
+			&i.Depth,
+			&i.UserSegment,
+			&i.Struggle,
+			&i.CreatedAt,
+			&i.ThemeName,
+			&i.EvidenceCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOutcomes = `-- name: ListOutcomes :many
+SELECT id, title, description, metric, created_at, updated_at FROM outcomes ORDER BY created_at
+`
+
+func (q *Queries) ListOutcomes(ctx context.Context) ([]Outcome, error) {
+	rows, err := q.db.QueryContext(ctx, listOutcomes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Outcome
+	for rows.Next() {
+		var i Outcome
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Description,
+			&i.Metric,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPrioritizedOpportunities = `-- name: ListPrioritizedOpportunities :many
+WITH inputs AS (
+    SELECT
+        o.id, o.user_segment, o.struggle, o.created_at, o.impact, o.confidence, o.effort,
+        t.name AS theme_name,
+        COALESCE(ev.evidence_count, 0)::bigint AS evidence_count,
+        COALESCE(ev.customer_count, 0)::bigint AS customer_count
+    FROM opportunities o
+    LEFT JOIN themes t ON t.id = o.theme_id
+    LEFT JOIN LATERAL (
+        SELECT COUNT(*) AS evidence_count, COUNT(DISTINCT m.customer_id) AS customer_count
+        FROM opportunity_evidence oe
+        JOIN meetings m ON m.id = oe.meeting_id
+        WHERE oe.opportunity_id = o.id
+    ) ev ON true
+),
+scored AS (
+    SELECT
+        inputs.id, inputs.user_segment, inputs.struggle, inputs.created_at, inputs.impact, inputs.confidence, inputs.effort, inputs.theme_name, inputs.evidence_count, inputs.customer_count,
+        $4::float8 * customer_count
+            + $5::float8 * evidence_count AS reach,
+        POWER(COALESCE(impact, $6::float8), $7::float8)
+            * POWER(COALESCE(confidence, $8::float8), $9::float8)
+            / POWER(COALESCE(effort, $10::float8), $11::float8) AS ice
+    FROM inputs
+)
+SELECT
+    id, user_segment, struggle, created_at, impact, confidence, effort, theme_name,
+    evidence_count, customer_count
+FROM scored
+ORDER BY
+    CASE WHEN $1::text = 'ice' THEN ice
+         ELSE POWER(reach, $2::float8) * ice END DESC,
+    evidence_count DESC,
+    id
+LIMIT $3
+`
+
+type ListPrioritizedOpportunitiesParams struct {
+	Framework         string  `db:"framework" json:"framework"`
+	ReachWeight       float64 `db:"reach_weight" json:"reach_weight"`
+	MaxRows           int32   `db:"max_rows" json:"max_rows"`
+	ReachPerCustomer  float64 `db:"reach_per_customer" json:"reach_per_customer"`
+	ReachPerEvidence  float64 `db:"reach_per_evidence" json:"reach_per_evidence"`
+	DefaultImpact     float64 `db:"default_impact" json:"default_impact"`
+	ImpactWeight      float64 `db:"impact_weight" json:"impact_weight"`
+	DefaultConfidence float64 `db:"default_confidence" json:"default_confidence"`
+	ConfidenceWeight  float64 `db:"confidence_weight" json:"confidence_weight"`
+	DefaultEffort     float64 `db:"default_effort" json:"default_effort"`
+	EffortWeight      float64 `db:"effort_weight" json:"effort_weight"`
+}
+
+type ListPrioritizedOpportunitiesRow struct {
+	ID            uuid.UUID       `db:"id" json:"id"`
+	UserSegment   string          `db:"user_segment" json:"user_segment"`
+	Struggle      string          `db:"struggle" json:"struggle"`
+	CreatedAt     sql.NullTime    `db:"created_at" json:"created_at"`
+	Impact        sql.NullFloat64 `db:"impact" json:"impact"`
+	Confidence    sql.NullFloat64 `db:"confidence" json:"confidence"`
+	Effort        sql.NullFloat64 `db:"effort" json:"effort"`
+	ThemeName     sql.NullString  `db:"theme_name" json:"theme_name"`
+	EvidenceCount int64           `db:"evidence_count" json:"evidence_count"`
+	CustomerCount int64           `db:"customer_count" json:"customer_count"`
+}
+
+// The best opportunities under framework (rice or ice), scored the way
+// service.ScoreOpportunity does: missing estimates take the default_* values,
+// and reach counts distinct linked customers. Meetings without a customer add
+// evidence but no customer.
+func (q *Queries) ListPrioritizedOpportunities(ctx context.Context, arg ListPrioritizedOpportunitiesParams) ([]ListPrioritizedOpportunitiesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPrioritizedOpportunities,
+		arg.Framework,
+		arg.ReachWeight,
+		arg.MaxRows,
+		arg.ReachPerCustomer,
+		arg.ReachPerEvidence,
+		arg.DefaultImpact,
+		arg.ImpactWeight,
+		arg.DefaultConfidence,
+		arg.ConfidenceWeight,
+		arg.DefaultEffort,
+		arg.EffortWeight,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPrioritizedOpportunitiesRow
+	for rows.Next() {
+		var i ListPrioritizedOpportunitiesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserSegment,
+			&i.Struggle,
+			&i.CreatedAt,
+			&i.Impact,
+			&i.Confidence,
+			&i.Effort,
+			&i.ThemeName,
+			&i.EvidenceCount,
+			&i.CustomerCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRecentOpportunities = `-- name: ListRecentOpportunities :many
+SELECT 
+    o.id, o.user_segment, o.struggle, o.why_it_matters, o.workaround, o.theme_id, o.created_at, o.updated_at, o.search_config, o.impact, o.confidence, o.effort, o.outcome_id, o.parent_id,
+    t.name AS theme_name,
+    COALESCE(ev_count.cnt, 0) AS evidence_count
+FROM opportunities o
+
+LEFT JOIN themes t ON o.theme_id = t.id
+LEFT JOIN LATERAL (
+    SELECT COUNT(*) AS cnt
+    FROM opportunity_evidence oe
+    WHERE oe.opportunity_id = o.id
+) ev_count ON true
+
+WHERE o.created_at >= NOW() - INTERVAL '24 hours'
+ORDER BY o.created_at DESC
+`
+
+type ListRecentOpportunitiesRow struct {
+	ID            uuid.UUID       `db:"id" json:"id"`
+	UserSegment   string          `db:"user_segment" json:"user_segment"`
+	Struggle      string          `db:"struggle" json:"struggle"`
+	WhyItMatters  sql.NullString  `db:"why_it_matters" json:"why_it_matters"`
+	Workaround    sql.NullString  `db:"workaround" json:"workaround"`
+	ThemeID       uuid.NullUUID   `db:"theme_id" json:"theme_id"`
+	CreatedAt     sql.NullTime    `db:"created_at" json:"created_at"`
+	UpdatedAt     sql.NullTime    `db:"updated_at" json:"updated_at"`
+	SearchConfig  string          `db:"search_config" json:"search_config"`
+	Impact        sql.NullFloat64 `db:"impact" json:"impact"`
+	Confidence    sql.NullFloat64 `db:"confidence" json:"confidence"`
+	Effort        sql.NullFloat64 `db:"effort" json:"effort"`
+	OutcomeID     uuid.NullUUID   `db:"outcome_id" json:"outcome_id"`
+	ParentID      uuid.NullUUID   `db:"parent_id" json:"parent_id"`
+	ThemeName     sql.NullString  `db:"theme_name" json:"theme_name"`
+	EvidenceCount int64           `db:"evidence_count" json:"evidence_count"`
+}
+
+func (q *Queries) ListRecentOpportunities(ctx context.Context) ([]ListRecentOpportunitiesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listRecentOpportunities)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListRecentOpportunitiesRow
+	for rows.Next() {
+		var i ListRecentOpportunitiesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserSegment,
+			&i.Struggle,
+			&i.WhyItMatters,
+			&i.Workaround,
+			&i.ThemeID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.SearchConfig,
+			&i.Impact,
+			&i.Confidence,
+			&i.Effort,
+			&i.OutcomeID,
+			&i.ParentID,
+			&i.ThemeName,
+			&i.EvidenceCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRecoverableMeetings = `-- name: ListRecoverableMeetings :many
+SELECT id FROM meetings
+WHERE (processing_status = 'pending' AND created_at < $2)
+   OR (processing_status = 'retrying' AND next_attempt_at < $2)
+   OR (processing_status = 'processing' AND (lease_expires_at IS NULL OR lease_expires_at < NOW()))
+ORDER BY created_at
+LIMIT $1
+`
+
+type ListRecoverableMeetingsParams struct {
+	Limit       int32        `db:"limit" json:"limit"`
+	StaleBefore sql.NullTime `db:"stale_before" json:"stale_before"`
+}
+
+// Meetings no worker is looking after: pending or due for retry since before the cutoff,
+// or processing with an expired lease (the worker crashed or was killed).
+func (q *Queries) ListRecoverableMeetings(ctx context.Context, arg ListRecoverableMeetingsParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, listRecoverableMeetings, arg.Limit, arg.StaleBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTopOpportunitiesByTheme = `-- name: ListTopOpportunitiesByTheme :many
+SELECT 
+    o.id,
+    o.user_segment,
+    o.struggle,
+    o.why_it_matters,
+    o.workaround,
+    o.created_at,
+    COUNT(oe.id) AS evidence_count
+FROM opportunities o
+LEFT JOIN opportunity_evidence oe ON oe.opportunity_id = o.id
+JOIN themes t ON o.theme_id = t.id
+WHERE LOWER(t.name) = LOWER($1)
+GROUP BY o.id, o.struggle, o.created_at
+ORDER BY evidence_count DESC, o.created_at DESC
+LIMIT $2
+`
+
+type ListTopOpportunitiesByThemeParams struct {
+	Lower string `db:"lower" json:"lower"`
+	Limit int32  `db:"limit" json:"limit"`
+}
+
+type ListTopOpportunitiesByThemeRow struct {
+	ID            uuid.UUID      `db:"id" json:"id"`
+	UserSegment   string         `db:"user_segment" json:"user_segment"`
+	Struggle      string         `db:"struggle" json:"struggle"`
+	WhyItMatters  sql.NullString `db:"why_it_matters" json:"why_it_matters"`
+	Workaround    sql.NullString `db:"workaround" json:"workaround"`
+	CreatedAt     sql.NullTime   `db:"created_at" json:"created_at"`
+	EvidenceCount int64          `db:"evidence_count" json:"evidence_count"`
+}
+
+func (q *Queries) ListTopOpportunitiesByTheme(ctx context.Context, arg ListTopOpportunitiesByThemeParams) ([]ListTopOpportunitiesByThemeRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTopOpportunitiesByTheme, arg.Lower, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTopOpportunitiesByThemeRow
+	for rows.Next() {
+		var i ListTopOpportunitiesByThemeRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserSegment,
+			&i.Struggle,
+			&i.WhyItMatters,
+			&i.Workaround,
+			&i.CreatedAt,
+			&i.EvidenceCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTopOpportunitiesSince = `-- name: ListTopOpportunitiesSince :many
+SELECT
+    o.id,
+    o.user_segment,
+    o.struggle,
+    t.name AS theme_name,
+    COUNT(oe.id) AS evidence_count,
+    COUNT(DISTINCT oe.meeting_id) AS meeting_count
+FROM opportunities o
+JOIN opportunity_evidence oe ON oe.opportunity_id = o.id
+LEFT JOIN themes t ON o.theme_id = t.id
+WHERE oe.created_at >= $1::timestamptz
+GROUP BY o.id, t.name
+ORDER BY evidence_count DESC, meeting_count DESC, o.created_at DESC, o.id
+LIMIT $2
+`
+
+type ListTopOpportunitiesSinceParams struct {
+	Since   time.Time `db:"since" json:"since"`
+	MaxRows int32     `db:"max_rows" json:"max_rows"`
+}
+
+type ListTopOpportunitiesSinceRow struct {
+	ID            uuid.UUID      `db:"id" json:"id"`
+	UserSegment   string         `db:"user_segment" json:"user_segment"`
+	Struggle      string         `db:"struggle" json:"struggle"`
+	ThemeName     sql.NullString `db:"theme_name" json:"theme_name"`
+	EvidenceCount int64          `db:"evidence_count" json:"evidence_count"`
+	MeetingCount  int64          `db:"meeting_count" json:"meeting_count"`
+}
+
+// Opportunities ranked by the evidence they gathered since a date.
+func (q *Queries) ListTopOpportunitiesSince(ctx context.Context, arg ListTopOpportunitiesSinceParams) ([]ListTopOpportunitiesSinceRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTopOpportunitiesSince, arg.Since, arg.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTopOpportunitiesSinceRow
+	for rows.Next() {
+		var i ListTopOpportunitiesSinceRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserSegment,
+			&i.Struggle,
+			&i.ThemeName,
+			&i.EvidenceCount,
+			&i.MeetingCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTopThemesThisWeek = `-- name: ListTopThemesThisWeek :many
+SELECT 
+    t.name AS theme_name,
+    COUNT(*) AS opportunity_count,
+    -- for better ranking
+    COUNT(*) + COUNT(*) FILTER (WHERE o.created_at >= NOW() - INTERVAL '3 days') AS score
+FROM opportunities o
+JOIN themes t ON o.theme_id = t.id
+WHERE o.created_at >= date_trunc('week', CURRENT_DATE)
+GROUP BY t.id, t.name
+HAVING COUNT(*) >= 1
+ORDER BY score DESC, opportunity_count DESC, t.name
+LIMIT 10
+`
+
+type ListTopThemesThisWeekRow struct {
+	ThemeName        string `db:"theme_name" json:"theme_name"`
+	OpportunityCount int64  `db:"opportunity_count" json:"opportunity_count"`
+	Score            int32  `db:"score" json:"score"`
+}
+
+func (q *Queries) ListTopThemesThisWeek(ctx context.Context) ([]ListTopThemesThisWeekRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTopThemesThisWeek)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTopThemesThisWeekRow
+	for rows.Next() {
+		var i ListTopThemesThisWeekRow
+		if err := rows.Scan(&i.ThemeName, &i.OpportunityCount, &i.Score); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTreeOpportunities = `-- name: ListTreeOpportunities :many
+WITH RECURSIVE placed AS (
+    SELECT o.id, o.outcome_id AS tree_outcome_id
+    FROM opportunities o
+    WHERE o.parent_id IS NULL
+    UNION ALL
+    SELECT c.id, COALESCE(c.outcome_id, p.tree_outcome_id)
+    FROM placed p
+    JOIN opportunities c ON c.parent_id = p.id
+)
+SELECT
+    o.id, o.outcome_id, o.parent_id, o.user_segment, o.struggle, o.created_at,
+    pl.tree_outcome_id,
+    t.name AS theme_name,
+    (SELECT COUNT(*) FROM opportunity_evidence oe WHERE oe.opportunity_id = o.id) AS evidence_count
+FROM opportunities o
+JOIN placed pl ON pl.id = o.id
+LEFT JOIN themes t ON t.id = o.theme_id
+WHERE $1::uuid IS NULL OR pl.tree_outcome_id = $1
+ORDER BY evidence_count DESC, o.created_at DESC
+`
+
+type ListTreeOpportunitiesRow struct {
+	ID            uuid.UUID      `db:"id" json:"id"`
+	OutcomeID     uuid.NullUUID  `db:"outcome_id" json:"outcome_id"`
+	ParentID      uuid.NullUUID  `db:"parent_id" json:"parent_id"`
+	UserSegment   string         `db:"user_segment" json:"user_segment"`
+	Struggle      string         `db:"struggle" json:"struggle"`
+	CreatedAt     sql.NullTime   `db:"created_at" json:"created_at"`
+	TreeOutcomeID uuid.NullUUID  `db:"tree_outcome_id" json:"tree_outcome_id"`
+	ThemeName     sql.NullString `db:"theme_name" json:"theme_name"`
+	EvidenceCount int64          `db:"evidence_count" json:"evidence_count"`
+}
+
+// Every opportunity with the outcome it rolls up to, for the tree. Opportunities
+// without an outcome of their own roll up to their nearest ancestor's.
+func (q *Queries) ListTreeOpportunities(ctx context.Context, outcomeID uuid.NullUUID) ([]ListTreeOpportunitiesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTreeOpportunities, outcomeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTreeOpportunitiesRow
+	for rows.Next() {
+		var i ListTreeOpportunitiesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.OutcomeID,
+			&i.ParentID,
+			&i.UserSegment,
+			&i.Struggle,
+			&i.CreatedAt,
+			&i.TreeOutcomeID,
+			&i.ThemeName,
+			&i.EvidenceCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookDeliveries = `-- name: ListWebhookDeliveries :many
+SELECT id, webhook_id, event, payload, status, attempts, next_attempt_at, response_status,
+       last_error, delivered_at, created_at
+FROM webhook_deliveries
+WHERE webhook_id = $1
+  AND ($2::text IS NULL OR status = $2)
+  AND ($3::text IS NULL OR event = $3)
+  AND ($4::timestamptz IS NULL
+       OR (created_at, id) < ($4, $5::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $6
+`
+
+type ListWebhookDeliveriesParams struct {
+	WebhookID     uuid.UUID      `db:"webhook_id" json:"webhook_id"`
+	Status        sql.NullString `db:"status" json:"status"`
+	Event         sql.NullString `db:"event" json:"event"`
+	CursorCreated sql.NullTime   `db:"cursor_created" json:"cursor_created"`
+	CursorID      uuid.NullUUID  `db:"cursor_id" json:"cursor_id"`
+	MaxRows       int32          `db:"max_rows" json:"max_rows"`
+}
+
+type ListWebhookDeliveriesRow struct {
+	ID             uuid.UUID       `db:"id" json:"id"`
+	WebhookID      uuid.UUID       `db:"webhook_id" json:"webhook_id"`
+	Event          string          `db:"event" json:"event"`
+	Payload        json.RawMessage `db:"payload" json:"payload"`
+	Status         string          `db:"status" json:"status"`
+	Attempts       int32           `db:"attempts" json:"attempts"`
+	NextAttemptAt  time.Time       `db:"next_attempt_at" json:"next_attempt_at"`
+	ResponseStatus sql.NullInt32   `db:"response_status" json:"response_status"`
+	LastError      sql.NullString  `db:"last_error" json:"last_error"`
+	DeliveredAt    sql.NullTime    `db:"delivered_at" json:"delivered_at"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
+}
+
+// Newest first. Pass the last row's created_at and id as the cursor to get the next page.
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]ListWebhookDeliveriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listWebhookDeliveries,
+		arg.WebhookID,
+		arg.Status,
+		arg.Event,
+		arg.CursorCreated,
+		arg.CursorID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListWebhookDeliveriesRow
+	for rows.Next() {
+		var i ListWebhookDeliveriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.WebhookID,
+			&i.Event,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.ResponseStatus,
+			&i.LastError,
+			&i.DeliveredAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhooks = `-- name: ListWebhooks :many
+SELECT id, url, secret, array_to_string(events, ',')::text AS events, description, active, created_at, updated_at
+FROM webhooks ORDER BY created_at
+`
+
+type ListWebhooksRow struct {
+	ID          uuid.UUID      `db:"id" json:"id"`
+	Url         string         `db:"url" json:"url"`
+	Secret      string         `db:"secret" json:"secret"`
+	Events      string         `db:"events" json:"events"`
+	Description sql.NullString `db:"description" json:"description"`
+	Active      bool           `db:"active" json:"active"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+func (q *Queries) ListWebhooks(ctx context.Context) ([]ListWebhooksRow, error) {
+	rows, err := q.db.QueryContext(ctx, listWebhooks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListWebhooksRow
+	for rows.Next() {
+		var i ListWebhooksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Url,
+			&i.Secret,
+			&i.Events,
+			&i.Description,
+			&i.Active,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockOpportunity = `-- name: LockOpportunity :one
+SELECT id FROM opportunities WHERE id = $1 FOR UPDATE
+`
+
+// Takes the row lock, so concurrent merges/splits of the same opportunity queue up.
+func (q *Queries) LockOpportunity(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, lockOpportunity, id)
+	err := row.Scan(&id)
+	return id, err
+}
+
+const lockOpportunityHierarchy = `-- name: LockOpportunityHierarchy :exec
+SELECT pg_advisory_xact_lock(hashtext('opportunity_hierarchy'))
+`
+
+// Serializes parent changes, so two concurrent moves can't close a loop between them.
+func (q *Queries) LockOpportunityHierarchy(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, lockOpportunityHierarchy)
+	return err
+}
+
+const moveAllEvidence = `-- name: MoveAllEvidence :execrows
+UPDATE opportunity_evidence
+SET opportunity_id = $1
+WHERE opportunity_id = $2
+`
+
+type MoveAllEvidenceParams struct {
+	TargetID uuid.UUID `db:"target_id" json:"target_id"`
+	SourceID uuid.UUID `db:"source_id" json:"source_id"`
+}
+
+func (q *Queries) MoveAllEvidence(ctx context.Context, arg MoveAllEvidenceParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, moveAllEvidence, arg.TargetID, arg.SourceID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const moveChildOpportunities = `-- name: MoveChildOpportunities :execrows
+UPDATE opportunities
+SET parent_id = $1::uuid, updated_at = NOW()
+WHERE parent_id = $2::uuid
+  AND id IS DISTINCT FROM $1::uuid
+`
+
+type MoveChildOpportunitiesParams struct {
+	TargetID uuid.NullUUID `db:"target_id" json:"target_id"`
+	SourceID uuid.UUID     `db:"source_id" json:"source_id"`
+}
+
+// Re-parents the children of source_id onto target_id (NULL makes them top-level).
+// target_id itself is never made its own child.
+func (q *Queries) MoveChildOpportunities(ctx context.Context, arg MoveChildOpportunitiesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, moveChildOpportunities, arg.TargetID, arg.SourceID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const moveEvidence = `-- name: MoveEvidence :execrows
+UPDATE opportunity_evidence
+SET opportunity_id = $1
+WHERE id = $2 AND opportunity_id = $3
+`
+
+type MoveEvidenceParams struct {
+	TargetID uuid.UUID `db:"target_id" json:"target_id"`
+	ID       uuid.UUID `db:"id" json:"id"`
+	SourceID uuid.UUID `db:"source_id" json:"source_id"`
+}
+
+func (q *Queries) MoveEvidence(ctx context.Context, arg MoveEvidenceParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, moveEvidence, arg.TargetID, arg.ID, arg.SourceID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const moveSolutions = `-- name: MoveSolutions :exec
+UPDATE solutions SET opportunity_id = $1, updated_at = NOW()
+WHERE opportunity_id = $2
+`
+
+type MoveSolutionsParams struct {
+	TargetID uuid.UUID `db:"target_id" json:"target_id"`
+	SourceID uuid.UUID `db:"source_id" json:"source_id"`
+}
+
+func (q *Queries) MoveSolutions(ctx context.Context, arg MoveSolutionsParams) error {
+	_, err := q.db.ExecContext(ctx, moveSolutions, arg.TargetID, arg.SourceID)
+	return err
+}
+
+const moveThemeOpportunities = `-- name: MoveThemeOpportunities :execrows
+UPDATE opportunities
+SET theme_id = $1, updated_at = NOW()
+WHERE theme_id = $2::uuid
+`
+
+type MoveThemeOpportunitiesParams struct {
+	ToID   uuid.NullUUID `db:"to_id" json:"to_id"`
+	FromID uuid.UUID     `db:"from_id" json:"from_id"`
+}
+
+// A NULL to_id leaves the opportunities without a theme.
+func (q *Queries) MoveThemeOpportunities(ctx context.Context, arg MoveThemeOpportunitiesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, moveThemeOpportunities, arg.ToID, arg.FromID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const recordMeetingFailure = `-- name: RecordMeetingFailure :execrows
+UPDATE meetings
+SET
+  processing_status = $2,
+  processing_error = $3::text,
+  error_history = error_history || jsonb_build_array(jsonb_build_object(
+    'attempt', processing_attempts,
+    'error', $3::text,
+    'at', NOW()
+  )),
+  next_attempt_at = $4,
+  processed_at = CASE
+    WHEN $2 = 'failed' OR $2 = 'dead' THEN NOW()
+    ELSE processed_at
+  END,
+  lease_expires_at = NULL,
+  updated_at = NOW()
+WHERE id = $1
+  AND processing_status = 'processing'
+  AND processing_attempts = $5
+`
+
+type RecordMeetingFailureParams struct {
+	ID               uuid.UUID    `db:"id" json:"id"`
+	ProcessingStatus string       `db:"processing_status" json:"processing_status"`
+	Error            string       `db:"error" json:"error"`
+	NextAttemptAt    sql.NullTime `db:"next_attempt_at" json:"next_attempt_at"`
+	Attempt          int32        `db:"attempt" json:"attempt"`
+}
+
+// Records a failed attempt, unless the attempt lost its lease to a newer one.
+func (q *Queries) RecordMeetingFailure(ctx context.Context, arg RecordMeetingFailureParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, recordMeetingFailure,
+		arg.ID,
+		arg.ProcessingStatus,
+		arg.Error,
+		arg.NextAttemptAt,
+		arg.Attempt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const recordWebhookAttempt = `-- name: RecordWebhookAttempt :exec
+UPDATE webhook_deliveries
+SET
+  status = $1::text,
+  next_attempt_at = COALESCE($2::timestamptz, next_attempt_at),
+  response_status = $3,
+  last_error = $4,
+  delivered_at = CASE WHEN $1::text = 'delivered' THEN NOW() ELSE delivered_at END,
+  updated_at = NOW()
+WHERE id = $5
+`
+
+type RecordWebhookAttemptParams struct {
+	Status         string         `db:"status" json:"status"`
+	NextAttemptAt  sql.NullTime   `db:"next_attempt_at" json:"next_attempt_at"`
+	ResponseStatus sql.NullInt32  `db:"response_status" json:"response_status"`
+	LastError      sql.NullString `db:"last_error" json:"last_error"`
+	ID             uuid.UUID      `db:"id" json:"id"`
+}
+
+// status is delivered, failed (gave up) or pending (retry at next_attempt_at).
+func (q *Queries) RecordWebhookAttempt(ctx context.Context, arg RecordWebhookAttemptParams) error {
+	_, err := q.db.ExecContext(ctx, recordWebhookAttempt,
+		arg.Status,
+		arg.NextAttemptAt,
+		arg.ResponseStatus,
+		arg.LastError,
+		arg.ID,
+	)
+	return err
+}
+
+const redirectOpportunity = `-- name: RedirectOpportunity :exec
+WITH repointed AS (
+    UPDATE opportunity_redirects
+    SET to_id = $2
+    WHERE to_id = $1
+)
+INSERT INTO opportunity_redirects (from_id, to_id)
+VALUES ($1, $2)
+ON CONFLICT (from_id) DO UPDATE SET to_id = EXCLUDED.to_id
+`
+
+type RedirectOpportunityParams struct {
+	FromID uuid.UUID `db:"from_id" json:"from_id"`
+	ToID   uuid.UUID `db:"to_id" json:"to_id"`
+}
+
+// Points from_id, and everything already redirected to it, at to_id.
+func (q *Queries) RedirectOpportunity(ctx context.Context, arg RedirectOpportunityParams) error {
+	_, err := q.db.ExecContext(ctx, redirectOpportunity, arg.FromID, arg.ToID)
+	return err
+}
+
+const releaseMeeting = `-- name: ReleaseMeeting :exec
+UPDATE meetings
+SET
+  processing_status = 'pending',
+  processing_attempts = GREATEST(processing_attempts - 1, 0),
+  lease_expires_at = NULL,
+  updated_at = NOW()
+WHERE id = $1
+  AND processing_status = 'processing'
+  AND processing_attempts = $2
+`
+
+type ReleaseMeetingParams struct {
+	ID      uuid.UUID `db:"id" json:"id"`
+	Attempt int32     `db:"attempt" json:"attempt"`
+}
+
+// Hands back an attempt interrupted by shutdown; it doesn't count against max_attempts.
+func (q *Queries) ReleaseMeeting(ctx context.Context, arg ReleaseMeetingParams) error {
+	_, err := q.db.ExecContext(ctx, releaseMeeting, arg.ID, arg.Attempt)
+	return err
+}
+
+const renameTheme = `-- name: RenameTheme :exec
+UPDATE themes SET name = $2, updated_at = NOW() WHERE id = $1
+`
+
+type RenameThemeParams struct {
+	ID   uuid.UUID `db:"id" json:"id"`
+	Name string    `db:"name" json:"name"`
+}
+
+func (q *Queries) RenameTheme(ctx context.Context, arg RenameThemeParams) error {
+	_, err := q.db.ExecContext(ctx, renameTheme, arg.ID, arg.Name)
+	return err
+}
+
+const requeueStaleJobs = `-- name: RequeueStaleJobs :execrows
+UPDATE jobs
+SET
+  status = 'queued',
+  rerun = FALSE,
+  locked_by = NULL,
+  locked_at = NULL,
+  updated_at = NOW()
+WHERE status = 'running' AND locked_at < $1
+`
+
+// Puts back jobs whose worker died before finishing them.
+func (q *Queries) RequeueStaleJobs(ctx context.Context, lockedBefore sql.NullTime) (int64, error) {
+	result, err := q.db.ExecContext(ctx, requeueStaleJobs, lockedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const rerunJob = `-- name: RerunJob :exec
+UPDATE jobs
+SET
+  status = 'queued',
+  run_at = NOW(),
+  rerun = FALSE,
+  locked_by = NULL,
+  locked_at = NULL,
+  updated_at = NOW()
+WHERE id = $1 AND rerun
+`
+
+// Queues a job that was enqueued again while running, to run right away.
+func (q *Queries) RerunJob(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, rerunJob, id)
+	return err
+}
+
+const resetMeetingForReprocess = `-- name: ResetMeetingForReprocess :execrows
+UPDATE meetings
+SET
+  processing_status = 'pending',
+  processing_error = NULL,
+  processing_attempts = 0,
+  error_history = '[]',
+  next_attempt_at = NULL,
+  lease_expires_at = NULL,
+  processed_at = NULL,
+  updated_at = NOW()
+WHERE id = $1
+  AND (processing_status <> 'processing' OR lease_expires_at < NOW())
+`
+
+// Puts a meeting back to "pending" for a fresh run. Meetings a worker is
+// currently processing are left alone (0 rows).
+func (q *Queries) ResetMeetingForReprocess(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, resetMeetingForReprocess, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const retryJob = `-- name: RetryJob :exec
+UPDATE jobs
+SET
+  status = 'queued',
+  run_at = CASE WHEN rerun THEN NOW() ELSE $2 END,
+  rerun = FALSE,
+  locked_by = NULL,
+  locked_at = NULL,
+  updated_at = NOW()
+WHERE id = $1
+`
+
+type RetryJobParams struct {
+	ID    uuid.UUID `db:"id" json:"id"`
+	RunAt time.Time `db:"run_at" json:"run_at"`
+}
+
+// A job enqueued again while running doesn't wait out its backoff.
+func (q *Queries) RetryJob(ctx context.Context, arg RetryJobParams) error {
+	_, err := q.db.ExecContext(ctx, retryJob, arg.ID, arg.RunAt)
+	return err
+}
+
+const retryWebhookDelivery = `-- name: RetryWebhookDelivery :one
+UPDATE webhook_deliveries
+SET status = 'pending', next_attempt_at = NOW(), updated_at = NOW()
+WHERE id = $1
+RETURNING id, webhook_id, event, payload, status, attempts, next_attempt_at, response_status,
+          last_error, delivered_at, created_at
+`
+
+type RetryWebhookDeliveryRow struct {
+	ID             uuid.UUID       `db:"id" json:"id"`
+	WebhookID      uuid.UUID       `db:"webhook_id" json:"webhook_id"`
+	Event          string          `db:"event" json:"event"`
+	Payload        json.RawMessage `db:"payload" json:"payload"`
+	Status         string          `db:"status" json:"status"`
+	Attempts       int32           `db:"attempts" json:"attempts"`
+	NextAttemptAt  time.Time       `db:"next_attempt_at" json:"next_attempt_at"`
+	ResponseStatus sql.NullInt32   `db:"response_status" json:"response_status"`
+	LastError      sql.NullString  `db:"last_error" json:"last_error"`
+	DeliveredAt    sql.NullTime    `db:"delivered_at" json:"delivered_at"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
+}
+
+// Sends a delivery again right away, whatever happened to it before.
+func (q *Queries) RetryWebhookDelivery(ctx context.Context, id uuid.UUID) (RetryWebhookDeliveryRow, error) {
+	row := q.db.QueryRowContext(ctx, retryWebhookDelivery, id)
+	var i RetryWebhookDeliveryRow
+	err := row.Scan(
+		&i.ID,
+		&i.WebhookID,
+		&i.Event,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.ResponseStatus,
+		&i.LastError,
+		&i.DeliveredAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const searchOpportunities = `-- name: SearchOpportunities :many
+WITH query AS (
+    SELECT websearch_to_tsquery('simple', $1::text)
+        || websearch_to_tsquery($2::text::regconfig, $1::text) AS q
+),
+opp_hits AS (
+    SELECT o.id, ts_rank(
+        setweight(to_tsvector(o.search_config, o.struggle), 'A') ||
+        setweight(to_tsvector(o.search_config, coalesce(o.why_it_matters, '') || ' ' || coalesce(o.workaround, '')), 'B') ||
+        to_tsvector('simple', o.struggle || ' ' || coalesce(o.why_it_matters, '') || ' ' || coalesce(o.workaround, '')),
+        query.q) AS rank
+    FROM opportunities o, query
+    WHERE (
+        setweight(to_tsvector(o.search_config, o.struggle), 'A') ||
+        setweight(to_tsvector(o.search_config, coalesce(o.why_it_matters, '') || ' ' || coalesce(o.workaround, '')), 'B') ||
+        to_tsvector('simple', o.struggle || ' ' || coalesce(o.why_it_matters, '') || ' ' || coalesce(o.workaround, ''))
+    ) @@ query.q
+),
+ev_hits AS (
+    SELECT oe.id, oe.opportunity_id, oe.meeting_id, oe.quote, oe.search_config,
+        ts_rank(to_tsvector(oe.search_config, oe.quote) || to_tsvector('simple', oe.quote), query.q) AS rank
+    FROM opportunity_evidence oe, query
+    WHERE (to_tsvector(oe.search_config, oe.quote) || to_tsvector('simple', oe.quote)) @@ query.q
+),
+scored AS (
+    SELECT ids.id, (
+        COALESCE((SELECT rank FROM opp_hits WHERE opp_hits.id = ids.id), 0) +
+        COALESCE((SELECT SUM(rank) FROM ev_hits WHERE ev_hits.opportunity_id = ids.id), 0)
+    )::float8 AS score
+    FROM (SELECT id FROM opp_hits UNION SELECT opportunity_id FROM ev_hits) ids
+    ORDER BY score DESC, ids.id
+    LIMIT $3
+)
+SELECT
+    o.id AS opportunity_id,
+    o.user_segment,
+    o.struggle,
+    t.name AS theme_name,
+    s.score,
+    ts_headline(o.search_config, o.struggle, query.q,
+        'StartSel=<mark>, StopSel=</mark>, HighlightAll=true')::text AS struggle_highlight,
+    ev.id AS evidence_id,
+    ev.meeting_id,
+    ev.quote,
+    COALESCE(ts_headline(ev.search_config, ev.quote, query.q,
+        'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MinWords=5, MaxWords=25'), '')::text AS quote_highlight,
+    COALESCE(ev.rank, 0)::float8 AS quote_rank
+FROM scored s
+CROSS JOIN query
+JOIN opportunities o ON o.id = s.id
+LEFT JOIN themes t ON t.id = o.theme_id
+LEFT JOIN ev_hits ev ON ev.opportunity_id = s.id
+ORDER BY s.score DESC, s.id, ev.rank DESC NULLS LAST
+`
+
+type SearchOpportunitiesParams struct {
+	Query            string `db:"query" json:"query"`
+	Language         string `db:"language" json:"language"`
+	MaxOpportunities int32  `db:"max_opportunities" json:"max_opportunities"`
 }
 
-func (q *Queries) ListRecentOpportunities(ctx context.Context) ([]ListRecentOpportunitiesRow, error) {
-	rows, err := q.db.QueryContext(ctx, listRecentOpportunities)
+type SearchOpportunitiesRow struct {
+	OpportunityID     uuid.UUID      `db:"opportunity_id" json:"opportunity_id"`
+	UserSegment       string         `db:"user_segment" json:"user_segment"`
+	Struggle          string         `db:"struggle" json:"struggle"`
+	ThemeName         sql.NullString `db:"theme_name" json:"theme_name"`
+	Score             float64        `db:"score" json:"score"`
+	StruggleHighlight string         `db:"struggle_highlight" json:"struggle_highlight"`
+	EvidenceID        uuid.NullUUID  `db:"evidence_id" json:"evidence_id"`
+	MeetingID         uuid.NullUUID  `db:"meeting_id" json:"meeting_id"`
+	Quote             sql.NullString `db:"quote" json:"quote"`
+	QuoteHighlight    string         `db:"quote_highlight" json:"quote_highlight"`
+	QuoteRank         float64        `db:"quote_rank" json:"quote_rank"`
+}
+
+// Full-text search over opportunities and their quotes, one row per matching quote
+// (or a single row with no quote when only the opportunity itself matched).
+// Opportunities are ranked by their own match plus the matches of their quotes.
+func (q *Queries) SearchOpportunities(ctx context.Context, arg SearchOpportunitiesParams) ([]SearchOpportunitiesRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchOpportunities, arg.Query, arg.Language, arg.MaxOpportunities)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ListRecentOpportunitiesRow
+	var items []SearchOpportunitiesRow
 	for rows.Next() {
-		var i ListRecentOpportunitiesRow
+		var i SearchOpportunitiesRow
 		if err := rows.Scan(
-			&i.ID,
+			&i.OpportunityID,
 			&i.UserSegment,
 			&i.Struggle,
-			&i.WhyItMatters,
-			&i.Workaround,
-			&i.ThemeID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
 			&i.ThemeName,
-			&i.EvidenceCount,
+			&i.Score,
+			&i.StruggleHighlight,
+			&i.EvidenceID,
+			&i.MeetingID,
+			&i.Quote,
+			&i.QuoteHighlight,
+			&i.QuoteRank,
 		); err != nil {
 			return nil, err
 		}
@@ -358,136 +3048,471 @@ func (q *Queries) ListRecentOpportunities(ctx context.Context) ([]ListRecentOppo
 	return items, nil
 }
 
-const listTopOpportunitiesByTheme = `-- name: ListTopOpportunitiesByTheme :many
-SELECT 
-    o.id,
-    o.user_segment,
-    o.struggle,
-    o.why_it_matters,
-    o.workaround,
-    o.created_at,
-    COUNT(oe.id) AS evidence_count
-FROM opportunities o
-LEFT JOIN opportunity_evidence oe ON oe.opportunity_id = o.id
-JOIN themes t ON o.theme_id = t.id
-WHERE LOWER(t.name) = LOWER($1)
-GROUP BY o.id, o.struggle, o.created_at
-ORDER BY evidence_count DESC, o.created_at DESC
-LIMIT $2
+const setMeetingCustomer = `-- name: SetMeetingCustomer :exec
+UPDATE meetings SET customer_id = $2 WHERE id = $1
 `
 
-type ListTopOpportunitiesByThemeParams struct {
-	Lower string `db:"lower" json:"lower"`
-	Limit int32  `db:"limit" json:"limit"`
+type SetMeetingCustomerParams struct {
+	ID         uuid.UUID     `db:"id" json:"id"`
+	CustomerID uuid.NullUUID `db:"customer_id" json:"customer_id"`
 }
 
-type ListTopOpportunitiesByThemeRow struct {
-	ID            uuid.UUID      `db:"id" json:"id"`
-	UserSegment   string         `db:"user_segment" json:"user_segment"`
-	Struggle      string         `db:"struggle" json:"struggle"`
-	WhyItMatters  sql.NullString `db:"why_it_matters" json:"why_it_matters"`
-	Workaround    sql.NullString `db:"workaround" json:"workaround"`
-	CreatedAt     sql.NullTime   `db:"created_at" json:"created_at"`
-	EvidenceCount int64          `db:"evidence_count" json:"evidence_count"`
+func (q *Queries) SetMeetingCustomer(ctx context.Context, arg SetMeetingCustomerParams) error {
+	_, err := q.db.ExecContext(ctx, setMeetingCustomer, arg.ID, arg.CustomerID)
+	return err
 }
 
-func (q *Queries) ListTopOpportunitiesByTheme(ctx context.Context, arg ListTopOpportunitiesByThemeParams) ([]ListTopOpportunitiesByThemeRow, error) {
-	rows, err := q.db.QueryContext(ctx, listTopOpportunitiesByTheme, arg.Lower, arg.Limit)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []ListTopOpportunitiesByThemeRow
-	for rows.Next() {
-		var i ListTopOpportunitiesByThemeRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.UserSegment,
-			&i.Struggle,
-			&i.WhyItMatters,
-			&i.Workaround,
-			&i.CreatedAt,
-			&i.EvidenceCount,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+const setMeetingValidationIssues = `-- name: SetMeetingValidationIssues :exec
+UPDATE meetings
+SET validation_issues = $2::jsonb, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetMeetingValidationIssuesParams struct {
+	ID     uuid.UUID       `db:"id" json:"id"`
+	Issues json.RawMessage `db:"issues" json:"issues"`
 }
 
-const listTopThemesThisWeek = `-- name: ListTopThemesThisWeek :many
-SELECT 
-    t.name AS theme_name,
-    COUNT(*) AS opportunity_count,
-    -- for better ranking
-    COUNT(*) + COUNT(*) FILTER (WHERE o.created_at >= NOW() - INTERVAL '3 days') AS score
-FROM opportunities o
-JOIN themes t ON o.theme_id = t.id
-WHERE o.created_at >= date_trunc('week', CURRENT_DATE)
-GROUP BY t.id, t.name
-HAVING COUNT(*) >= 1
-ORDER BY score DESC, opportunity_count DESC, t.name
-LIMIT 10
+func (q *Queries) SetMeetingValidationIssues(ctx context.Context, arg SetMeetingValidationIssuesParams) error {
+	_, err := q.db.ExecContext(ctx, setMeetingValidationIssues, arg.ID, arg.Issues)
+	return err
+}
+
+const setOpportunityParent = `-- name: SetOpportunityParent :exec
+UPDATE opportunities SET parent_id = $1::uuid, updated_at = NOW()
+WHERE id = $2
 `
 
-type ListTopThemesThisWeekRow struct {
-	ThemeName        string `db:"theme_name" json:"theme_name"`
-	OpportunityCount int64  `db:"opportunity_count" json:"opportunity_count"`
-	Score            int32  `db:"score" json:"score"`
+type SetOpportunityParentParams struct {
+	ParentID uuid.NullUUID `db:"parent_id" json:"parent_id"`
+	ID       uuid.UUID     `db:"id" json:"id"`
 }
 
-func (q *Queries) ListTopThemesThisWeek(ctx context.Context) ([]ListTopThemesThisWeekRow, error) {
-	rows, err := q.db.QueryContext(ctx, listTopThemesThisWeek)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []ListTopThemesThisWeekRow
-	for rows.Next() {
-		var i ListTopThemesThisWeekRow
-		if err := rows.Scan(&i.ThemeName, &i.OpportunityCount, &i.Score); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+// A NULL parent_id makes it a top-level opportunity.
+func (q *Queries) SetOpportunityParent(ctx context.Context, arg SetOpportunityParentParams) error {
+	_, err := q.db.ExecContext(ctx, setOpportunityParent, arg.ParentID, arg.ID)
+	return err
 }
 
-const updateMeetingStatus = `-- name: UpdateMeetingStatus :exec
+const startMeetingAttempt = `-- name: StartMeetingAttempt :one
 UPDATE meetings
-SET 
-  processing_status = $2,
-  processing_error = CASE 
-    WHEN $3::text = '' THEN NULL 
-    ELSE $3 
-  END,
-  processed_at = CASE 
-    WHEN $2 = 'done' OR $2 = 'failed' THEN NOW()
-    ELSE processed_at 
-  END
+SET
+  processing_status = 'processing',
+  processing_attempts = processing_attempts + 1,
+  next_attempt_at = NULL,
+  lease_expires_at = NOW() + make_interval(secs => $2::int),
+  updated_at = NOW()
 WHERE id = $1
+  AND (
+    processing_status IN ('pending', 'retrying')
+    OR (processing_status = 'processing' AND (lease_expires_at IS NULL OR lease_expires_at < NOW()))
+  )
+RETURNING processing_attempts
+`
+
+type StartMeetingAttemptParams struct {
+	ID           uuid.UUID `db:"id" json:"id"`
+	LeaseSeconds int32     `db:"lease_seconds" json:"lease_seconds"`
+}
+
+// Claims the meeting for one attempt. Returns no rows when the meeting is finished
+// or another worker still holds a valid lease on it.
+func (q *Queries) StartMeetingAttempt(ctx context.Context, arg StartMeetingAttemptParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, startMeetingAttempt, arg.ID, arg.LeaseSeconds)
+	var processing_attempts int32
+	err := row.Scan(&processing_attempts)
+	return processing_attempts, err
+}
+
+const takeMeetingSlackResponseURL = `-- name: TakeMeetingSlackResponseURL :one
+UPDATE meetings
+SET slack_response_url = NULL
+WHERE id = $1 AND slack_response_url IS NOT NULL
+RETURNING slack_response_url::text
+`
+
+// Returns the meeting's Slack response_url and clears it, so the result is posted once.
+func (q *Queries) TakeMeetingSlackResponseURL(ctx context.Context, id uuid.UUID) (string, error) {
+	row := q.db.QueryRowContext(ctx, takeMeetingSlackResponseURL, id)
+	var slack_response_url string
+	err := row.Scan(&slack_response_url)
+	return slack_response_url, err
+}
+
+const touchOpportunity = `-- name: TouchOpportunity :exec
+UPDATE opportunities SET updated_at = NOW() WHERE id = $1
+`
+
+func (q *Queries) TouchOpportunity(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, touchOpportunity, id)
+	return err
+}
+
+const updateExperiment = `-- name: UpdateExperiment :one
+UPDATE experiments
+SET
+  assumption = COALESCE($1::text, assumption),
+  method = CASE
+    WHEN $2::text IS NULL THEN method
+    ELSE NULLIF($2::text, '')
+  END,
+  status = COALESCE($3::text, status),
+  result = CASE
+    WHEN $4::text IS NULL THEN result
+    ELSE NULLIF($4::text, '')
+  END,
+  updated_at = NOW()
+WHERE id = $5
+RETURNING id, solution_id, assumption, method, status, result, created_at, updated_at
+`
+
+type UpdateExperimentParams struct {
+	Assumption sql.NullString `db:"assumption" json:"assumption"`
+	Method     sql.NullString `db:"method" json:"method"`
+	Status     sql.NullString `db:"status" json:"status"`
+	Result     sql.NullString `db:"result" json:"result"`
+	ID         uuid.UUID      `db:"id" json:"id"`
+}
+
+func (q *Queries) UpdateExperiment(ctx context.Context, arg UpdateExperimentParams) (Experiment, error) {
+	row := q.db.QueryRowContext(ctx, updateExperiment,
+		arg.Assumption,
+		arg.Method,
+		arg.Status,
+		arg.Result,
+		arg.ID,
+	)
+	var i Experiment
+	err := row.Scan(
+		&i.ID,
+		&i.SolutionID,
+		&i.Assumption,
+		&i.Method,
+		&i.Status,
+		&i.Result,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateOpportunity = `-- name: UpdateOpportunity :exec
+UPDATE opportunities
+SET
+  user_segment = COALESCE($1::text, user_segment),
+  struggle = COALESCE($2::text, struggle),
+  why_it_matters = CASE
+    WHEN $3::text IS NULL THEN why_it_matters
+    ELSE NULLIF($3::text, '')
+  END,
+  workaround = CASE
+    WHEN $4::text IS NULL THEN workaround
+    ELSE NULLIF($4::text, '')
+  END,
+  theme_id = CASE
+    WHEN $5::bool THEN $6::uuid
+    ELSE theme_id
+  END,
+  impact = COALESCE($7::float8, impact),
+  confidence = COALESCE($8::float8, confidence),
+  effort = COALESCE($9::float8, effort),
+  outcome_id = CASE
+    WHEN $10::bool THEN $11::uuid
+    ELSE outcome_id
+  END,
+  parent_id = CASE
+    WHEN $12::bool THEN $13::uuid
+    ELSE parent_id
+  END,
+  updated_at = NOW()
+WHERE id = $14
+`
+
+type UpdateOpportunityParams struct {
+	UserSegment  sql.NullString  `db:"user_segment" json:"user_segment"`
+	Struggle     sql.NullString  `db:"struggle" json:"struggle"`
+	WhyItMatters sql.NullString  `db:"why_it_matters" json:"why_it_matters"`
+	Workaround   sql.NullString  `db:"workaround" json:"workaround"`
+	SetTheme     bool            `db:"set_theme" json:"set_theme"`
+	ThemeID      uuid.NullUUID   `db:"theme_id" json:"theme_id"`
+	Impact       sql.NullFloat64 `db:"impact" json:"impact"`
+	Confidence   sql.NullFloat64 `db:"confidence" json:"confidence"`
+	Effort       sql.NullFloat64 `db:"effort" json:"effort"`
+	SetOutcome   bool            `db:"set_outcome" json:"set_outcome"`
+	OutcomeID    uuid.NullUUID   `db:"outcome_id" json:"outcome_id"`
+	SetParent    bool            `db:"set_parent" json:"set_parent"`
+	ParentID     uuid.NullUUID   `db:"parent_id" json:"parent_id"`
+	ID           uuid.UUID       `db:"id" json:"id"`
+}
+
+// NULL leaves a field as it is; an empty why_it_matters/workaround clears it.
+func (q *Queries) UpdateOpportunity(ctx context.Context, arg UpdateOpportunityParams) error {
+	_, err := q.db.ExecContext(ctx, updateOpportunity,
+		arg.UserSegment,
+		arg.Struggle,
+		arg.WhyItMatters,
+		arg.Workaround,
+		arg.SetTheme,
+		arg.ThemeID,
+		arg.Impact,
+		arg.Confidence,
+		arg.Effort,
+		arg.SetOutcome,
+		arg.OutcomeID,
+		arg.SetParent,
+		arg.ParentID,
+		arg.ID,
+	)
+	return err
+}
+
+const updateOutcome = `-- name: UpdateOutcome :one
+UPDATE outcomes
+SET
+  title = COALESCE($1::text, title),
+  description = CASE
+    WHEN $2::text IS NULL THEN description
+    ELSE NULLIF($2::text, '')
+  END,
+  metric = CASE
+    WHEN $3::text IS NULL THEN metric
+    ELSE NULLIF($3::text, '')
+  END,
+  updated_at = NOW()
+WHERE id = $4
+RETURNING id, title, description, metric, created_at, updated_at
+`
+
+type UpdateOutcomeParams struct {
+	Title       sql.NullString `db:"title" json:"title"`
+	Description sql.NullString `db:"description" json:"description"`
+	Metric      sql.NullString `db:"metric" json:"metric"`
+	ID          uuid.UUID      `db:"id" json:"id"`
+}
+
+// NULL leaves a field as it is; an empty description/metric clears it.
+func (q *Queries) UpdateOutcome(ctx context.Context, arg UpdateOutcomeParams) (Outcome, error) {
+	row := q.db.QueryRowContext(ctx, updateOutcome,
+		arg.Title,
+		arg.Description,
+		arg.Metric,
+		arg.ID,
+	)
+	var i Outcome
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Description,
+		&i.Metric,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateSolution = `-- name: UpdateSolution :one
+UPDATE solutions
+SET
+  title = COALESCE($1::text, title),
+  description = CASE
+    WHEN $2::text IS NULL THEN description
+    ELSE NULLIF($2::text, '')
+  END,
+  status = COALESCE($3::text, status),
+  updated_at = NOW()
+WHERE id = $4
+RETURNING id, opportunity_id, title, description, status, created_at, updated_at
+`
+
+type UpdateSolutionParams struct {
+	Title       sql.NullString `db:"title" json:"title"`
+	Description sql.NullString `db:"description" json:"description"`
+	Status      sql.NullString `db:"status" json:"status"`
+	ID          uuid.UUID      `db:"id" json:"id"`
+}
+
+func (q *Queries) UpdateSolution(ctx context.Context, arg UpdateSolutionParams) (Solution, error) {
+	row := q.db.QueryRowContext(ctx, updateSolution,
+		arg.Title,
+		arg.Description,
+		arg.Status,
+		arg.ID,
+	)
+	var i Solution
+	err := row.Scan(
+		&i.ID,
+		&i.OpportunityID,
+		&i.Title,
+		&i.Description,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateWebhook = `-- name: UpdateWebhook :one
+UPDATE webhooks
+SET
+  url = COALESCE($1::text, url),
+  events = COALESCE(string_to_array($2::text, ','), events),
+  description = CASE
+    WHEN $3::text IS NULL THEN description
+    ELSE NULLIF($3::text, '')
+  END,
+  active = COALESCE($4::bool, active),
+  updated_at = NOW()
+WHERE id = $5
+RETURNING id, url, secret, array_to_string(events, ',')::text AS events, description, active, created_at, updated_at
+`
+
+type UpdateWebhookParams struct {
+	Url         sql.NullString `db:"url" json:"url"`
+	Events      sql.NullString `db:"events" json:"events"`
+	Description sql.NullString `db:"description" json:"description"`
+	Active      sql.NullBool   `db:"active" json:"active"`
+	ID          uuid.UUID      `db:"id" json:"id"`
+}
+
+type UpdateWebhookRow struct {
+	ID          uuid.UUID      `db:"id" json:"id"`
+	Url         string         `db:"url" json:"url"`
+	Secret      string         `db:"secret" json:"secret"`
+	Events      string         `db:"events" json:"events"`
+	Description sql.NullString `db:"description" json:"description"`
+	Active      bool           `db:"active" json:"active"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+// NULL leaves a field as it is; an empty description clears it. events is a comma-separated list.
+func (q *Queries) UpdateWebhook(ctx context.Context, arg UpdateWebhookParams) (UpdateWebhookRow, error) {
+	row := q.db.QueryRowContext(ctx, updateWebhook,
+		arg.Url,
+		arg.Events,
+		arg.Description,
+		arg.Active,
+		arg.ID,
+	)
+	var i UpdateWebhookRow
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.Secret,
+		&i.Events,
+		&i.Description,
+		&i.Active,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertCustomer = `-- name: UpsertCustomer :one
+INSERT INTO customers (name, plan, arr)
+VALUES ($1, $2::text, $3::float8)
+ON CONFLICT ((LOWER(name))) DO UPDATE SET
+    plan = COALESCE(EXCLUDED.plan, customers.plan),
+    arr = COALESCE(EXCLUDED.arr, customers.arr),
+    updated_at = NOW()
+RETURNING id, name, plan, arr, created_at, updated_at
+`
+
+type UpsertCustomerParams struct {
+	Name string          `db:"name" json:"name"`
+	Plan sql.NullString  `db:"plan" json:"plan"`
+	Arr  sql.NullFloat64 `db:"arr" json:"arr"`
+}
+
+// Plan and ARR are overwritten by the latest meeting that reports them.
+func (q *Queries) UpsertCustomer(ctx context.Context, arg UpsertCustomerParams) (Customer, error) {
+	row := q.db.QueryRowContext(ctx, upsertCustomer, arg.Name, arg.Plan, arg.Arr)
+	var i Customer
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Plan,
+		&i.Arr,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertOpportunityEmbedding = `-- name: UpsertOpportunityEmbedding :exec
+INSERT INTO opportunity_embeddings (opportunity_id, embedding, model, content_hash)
+VALUES ($1, $2::text::vector, $3::text, $4::text)
+ON CONFLICT (opportunity_id) DO UPDATE
+SET embedding = EXCLUDED.embedding,
+    model = EXCLUDED.model,
+    content_hash = EXCLUDED.content_hash,
+    updated_at = NOW()
 `
 
-type UpdateMeetingStatusParams struct {
-	ID               uuid.UUID `db:"id" json:"id"`
-	ProcessingStatus string    `db:"processing_status" json:"processing_status"`
-	Column3          string    `db:"column_3" json:"column_3"`
+type UpsertOpportunityEmbeddingParams struct {
+	OpportunityID uuid.UUID `db:"opportunity_id" json:"opportunity_id"`
+	Embedding     string    `db:"embedding" json:"embedding"`
+	Model         string    `db:"model" json:"model"`
+	ContentHash   string    `db:"content_hash" json:"content_hash"`
 }
 
-func (q *Queries) UpdateMeetingStatus(ctx context.Context, arg UpdateMeetingStatusParams) error {
-	_, err := q.db.ExecContext(ctx, updateMeetingStatus, arg.ID, arg.ProcessingStatus, arg.Column3)
+// embedding is pgvector's text form: [0.1,0.2,...]
+func (q *Queries) UpsertOpportunityEmbedding(ctx context.Context, arg UpsertOpportunityEmbeddingParams) error {
+	_, err := q.db.ExecContext(ctx, upsertOpportunityEmbedding,
+		arg.OpportunityID,
+		arg.Embedding,
+		arg.Model,
+		arg.ContentHash,
+	)
 	return err
 }
+
+const upsertScoringWeights = `-- name: UpsertScoringWeights :one
+INSERT INTO scoring_weights (
+    workspace, reach_per_customer, reach_per_evidence,
+    reach_weight, impact_weight, confidence_weight, effort_weight
+) VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (workspace) DO UPDATE SET
+    reach_per_customer = EXCLUDED.reach_per_customer,
+    reach_per_evidence = EXCLUDED.reach_per_evidence,
+    reach_weight = EXCLUDED.reach_weight,
+    impact_weight = EXCLUDED.impact_weight,
+    confidence_weight = EXCLUDED.confidence_weight,
+    effort_weight = EXCLUDED.effort_weight,
+    updated_at = NOW()
+RETURNING workspace, reach_per_customer, reach_per_evidence, reach_weight, impact_weight, confidence_weight, effort_weight, updated_at
+`
+
+type UpsertScoringWeightsParams struct {
+	Workspace        string  `db:"workspace" json:"workspace"`
+	ReachPerCustomer float64 `db:"reach_per_customer" json:"reach_per_customer"`
+	ReachPerEvidence float64 `db:"reach_per_evidence" json:"reach_per_evidence"`
+	ReachWeight      float64 `db:"reach_weight" json:"reach_weight"`
+	ImpactWeight     float64 `db:"impact_weight" json:"impact_weight"`
+	ConfidenceWeight float64 `db:"confidence_weight" json:"confidence_weight"`
+	EffortWeight     float64 `db:"effort_weight" json:"effort_weight"`
+}
+
+func (q *Queries) UpsertScoringWeights(ctx context.Context, arg UpsertScoringWeightsParams) (ScoringWeight, error) {
+	row := q.db.QueryRowContext(ctx, upsertScoringWeights,
+		arg.Workspace,
+		arg.ReachPerCustomer,
+		arg.ReachPerEvidence,
+		arg.ReachWeight,
+		arg.ImpactWeight,
+		arg.ConfidenceWeight,
+		arg.EffortWeight,
+	)
+	var i ScoringWeight
+	err := row.Scan(
+		&i.Workspace,
+		&i.ReachPerCustomer,
+		&i.ReachPerEvidence,
+		&i.ReachWeight,
+		&i.ImpactWeight,
+		&i.ConfidenceWeight,
+		&i.EffortWeight,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/internal/webhook"
+	"github.com/pedy4000/noker/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrOpportunityNotFound = errors.New("opportunity not found")
+	ErrEvidenceNotFound    = errors.New("evidence not found")
+	ErrInvalidCuration     = errors.New("invalid curation request")
+)
+
+// MergedError is returned when a change names an opportunity that was merged
+// away. Only reads follow merge redirects; changes have to name Into themselves.
+type MergedError struct {
+	ID   uuid.UUID
+	Into uuid.UUID
+}
+
+func (e *MergedError) Error() string {
+	return fmt.Sprintf("opportunity %s was merged into %s", e.ID, e.Into)
+}
+
+// MergeResult summarizes a merge.
+type MergeResult struct {
+	TargetID          uuid.UUID
+	MergedIDs         []uuid.UUID
+	MovedEvidence     int
+	DroppedDuplicates int // quotes the target already had
+}
+
+// SplitRequest describes the opportunity to carve out of an existing one.
+// Empty UserSegment and Theme are copied from the source.
+type SplitRequest struct {
+	EvidenceIDs  []uuid.UUID
+	UserSegment  string
+	Struggle     string
+	WhyItMatters string
+	Workaround   string
+	Theme        string
+}
+
+// SplitResult summarizes a split.
+type SplitResult struct {
+	SourceID      uuid.UUID
+	OpportunityID uuid.UUID
+	MovedEvidence int
+}
+
+// MergeOpportunities moves all evidence, solutions and child opportunities of sources
+// into target and deletes the sources, leaving redirects behind so their old IDs keep resolving.
+func (s *OpportunityService) MergeOpportunities(ctx context.Context, target uuid.UUID, sources []uuid.UUID, actor string) (MergeResult, error) {
+	result := MergeResult{TargetID: target}
+
+	err := s.q.ExecTx(ctx, func(q *repository.Queries) error {
+		if err := q.LockOpportunityHierarchy(ctx); err != nil {
+			return err
+		}
+
+		merging := map[uuid.UUID]bool{}
+		locks := []uuid.UUID{target}
+		for _, source := range sources {
+			if source == target {
+				return fmt.Errorf("%w: cannot merge an opportunity into itself", ErrInvalidCuration)
+			}
+			if !merging[source] {
+				merging[source] = true
+				locks = append(locks, source)
+			}
+		}
+
+		// Row locks are taken in ID order, so merges over overlapping sets
+		// can't deadlock whatever order their sources were given in.
+		sort.Slice(locks, func(i, j int) bool { return bytes.Compare(locks[i][:], locks[j][:]) < 0 })
+		for _, id := range locks {
+			if err := lockOpportunity(ctx, q, id); err != nil {
+				return err
+			}
+		}
+		if err := adoptChildren(ctx, q, target, merging); err != nil {
+			return err
+		}
+
+		for _, source := range sources {
+			if !merging[source] {
+				continue
+			}
+			delete(merging, source)
+
+			dropped, err := q.DropDuplicateEvidence(ctx, repository.DropDuplicateEvidenceParams{SourceID: source, TargetID: target})
+			if err != nil {
+				return err
+			}
+			moved, err := q.MoveAllEvidence(ctx, repository.MoveAllEvidenceParams{SourceID: source, TargetID: target})
+			if err != nil {
+				return err
+			}
+			if err := q.MoveSolutions(ctx, repository.MoveSolutionsParams{SourceID: source, TargetID: target}); err != nil {
+				return err
+			}
+			if err := q.RedirectOpportunity(ctx, repository.RedirectOpportunityParams{FromID: source, ToID: target}); err != nil {
+				return err
+			}
+			if _, err := q.DeleteOpportunity(ctx, source); err != nil {
+				return err
+			}
+
+			result.MergedIDs = append(result.MergedIDs, source)
+			result.MovedEvidence += int(moved)
+			result.DroppedDuplicates += int(dropped)
+		}
+
+		if err := q.TouchOpportunity(ctx, target); err != nil {
+			return err
+		}
+
+		return audit(ctx, q, "opportunity.merged", "opportunity", target, actor, map[string]any{
+			"merged_ids":         result.MergedIDs,
+			"moved_evidence":     result.MovedEvidence,
+			"dropped_duplicates": result.DroppedDuplicates,
+		})
+	})
+
+	return result, err
+}
+
+// SplitOpportunity moves the selected evidence of source into a new opportunity,
+// created next to source under the same parent.
+// At least one quote has to stay behind; moving all of them is an edit, not a split.
+func (s *OpportunityService) SplitOpportunity(ctx context.Context, source uuid.UUID, req SplitRequest, actor string) (SplitResult, error) {
+	result := SplitResult{SourceID: source}
+
+	err := s.q.ExecTx(ctx, func(q *repository.Queries) error {
+		if err := lockOpportunity(ctx, q, source); err != nil {
+			return err
+		}
+
+		opp, err := q.GetOpportunity(ctx, source)
+		if err != nil {
+			return err
+		}
+
+		unique := map[uuid.UUID]bool{}
+		for _, id := range req.EvidenceIDs {
+			unique[id] = true
+		}
+		themeID := opp.ThemeID
+		if req.Theme != "" {
+			if themeID, err = resolveTheme(ctx, q, req.Theme); err != nil {
+				return err
+			}
+		}
+		if req.UserSegment == "" {
+			req.UserSegment = opp.UserSegment
+		}
+
+		created, err := q.CreateSplitOpportunity(ctx, repository.CreateSplitOpportunityParams{
+			UserSegment:  req.UserSegment,
+			Struggle:     req.Struggle,
+			WhyItMatters: utils.ToNullString(req.WhyItMatters),
+			Workaround:   utils.ToNullString(req.Workaround),
+			ThemeID:      themeID,
+			SourceID:     source,
+		})
+		if err != nil {
+			return err
+		}
+
+		for id := range unique {
+			n, err := q.MoveEvidence(ctx, repository.MoveEvidenceParams{ID: id, SourceID: source, TargetID: created.ID})
+			if err != nil {
+				return err
+			}
+			if n == 0 {
+				return fmt.Errorf("%w: %s", ErrEvidenceNotFound, id)
+			}
+		}
+
+		remaining, err := q.CountEvidence(ctx, source)
+		if err != nil {
+			return err
+		}
+		if remaining == 0 {
+			return fmt.Errorf("%w: a split must leave at least one quote on the source", ErrInvalidCuration)
+		}
+
+		if err := q.TouchOpportunity(ctx, source); err != nil {
+			return err
+		}
+		if err := webhook.Enqueue(ctx, q, webhook.EventOpportunityCreated, webhook.OpportunityData{
+			OpportunityID: created.ID,
+			UserSegment:   created.UserSegment,
+			Struggle:      created.Struggle,
+			WhyItMatters:  created.WhyItMatters.String,
+			Workaround:    created.Workaround.String,
+			ThemeID:       nullUUIDPtr(created.ThemeID),
+			ParentID:      nullUUIDPtr(created.ParentID),
+		}); err != nil {
+			return err
+		}
+
+		result.OpportunityID = created.ID
+		result.MovedEvidence = len(unique)
+
+		return audit(ctx, q, "opportunity.split", "opportunity", source, actor, map[string]any{
+			"opportunity_id": created.ID,
+			"evidence_ids":   req.EvidenceIDs,
+		})
+	})
+
+	return result, err
+}
+
+// ResolveOpportunityID follows redirects left by merges. IDs that were never
+// merged are returned unchanged.
+func (s *OpportunityService) ResolveOpportunityID(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	to, err := s.q.GetOpportunityRedirect(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return id, nil
+	}
+	return to, err
+}
+
+func lockOpportunity(ctx context.Context, q *repository.Queries, id uuid.UUID) error {
+	if _, err := q.LockOpportunity(ctx, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return opportunityNotFound(ctx, q, id)
+		}
+		return err
+	}
+	return nil
+}
+
+// opportunityNotFound explains why id is gone: a *MergedError if it was merged
+// away, ErrOpportunityNotFound otherwise.
+func opportunityNotFound(ctx context.Context, q *repository.Queries, id uuid.UUID) error {
+	to, err := q.GetOpportunityRedirect(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %s", ErrOpportunityNotFound, id)
+	}
+	if err != nil {
+		return err
+	}
+	return &MergedError{ID: id, Into: to}
+}
+
+// audit records a manual change in the audit log, in the same transaction as the change.
+func audit(ctx context.Context, q *repository.Queries, action, entityType string, entityID uuid.UUID, actor string, details any) error {
+	data, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+
+	return q.AddAuditEntry(ctx, repository.AddAuditEntryParams{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Actor:      utils.ToNullString(actor),
+		Details:    data,
+	})
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidAmount is returned for an ARR that reads as a number but can't be one:
+// negative, infinite or NaN. Summed up, any of these would corrupt the ARR totals.
+var ErrInvalidAmount = errors.New("invalid amount")
+
+// CustomerKeys are the meeting metadata keys customers are read from.
+type CustomerKeys struct {
+	Name []string // the first one present names the customer
+	Plan string
+	ARR  string
+}
+
+// CustomerInfo is what a meeting's metadata says about its customer.
+type CustomerInfo struct {
+	Name string
+	Plan string
+	ARR  sql.NullFloat64
+}
+
+// CustomerFromMetadata reads the customer out of meeting metadata. ok is false when
+// none of the name keys holds a non-empty value.
+func CustomerFromMetadata(metadata map[string]any, keys CustomerKeys) (info CustomerInfo, ok bool) {
+	for _, key := range keys.Name {
+		if name := strings.TrimSpace(metadataString(metadata[key])); name != "" {
+			info.Name = name
+			break
+		}
+	}
+	if info.Name == "" {
+		return info, false
+	}
+
+	info.Plan = strings.TrimSpace(metadataString(metadata[keys.Plan]))
+	if arr, err := parseAmount(metadata[keys.ARR]); err == nil {
+		info.ARR = sql.NullFloat64{Float64: arr, Valid: true}
+	}
+	return info, true
+}
+
+// ValidateCustomerMetadata checks the ARR in the metadata before a meeting is stored.
+// Values that aren't numbers at all are left alone, as CustomerFromMetadata ignores them.
+func ValidateCustomerMetadata(metadata map[string]any, keys CustomerKeys) error {
+	if _, err := parseAmount(metadata[keys.ARR]); errors.Is(err, ErrInvalidAmount) {
+		return fmt.Errorf("%s: %w", keys.ARR, err)
+	}
+	return nil
+}
+
+// LinkCustomer creates or updates the customer named in the metadata and returns its ID,
+// or a null ID if the metadata names no customer.
+func LinkCustomer(ctx context.Context, q *repository.Queries, metadata map[string]any, keys CustomerKeys) (uuid.NullUUID, error) {
+	info, ok := CustomerFromMetadata(metadata, keys)
+	if !ok {
+		return uuid.NullUUID{}, nil
+	}
+
+	customer, err := q.UpsertCustomer(ctx, repository.UpsertCustomerParams{
+		Name: info.Name,
+		Plan: utils.ToNullString(info.Plan),
+		Arr:  info.ARR,
+	})
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	return utils.ToNullUUID(customer.ID), nil
+}
+
+// backfillBatch is how many meetings BackfillCustomers links per transaction.
+const backfillBatch = 500
+
+// BackfillCustomers links meetings stored without a customer to the one their
+// metadata names under keys: meetings from before customers existed, or named
+// with a key that was added to the config later. Meetings are read oldest first,
+// so each customer ends up with its latest plan and ARR, as on ingest.
+// Returns how many meetings were linked.
+func (s *OpportunityService) BackfillCustomers(ctx context.Context, keys CustomerKeys) (int, error) {
+	linked := 0
+	params := repository.ListMeetingsWithoutCustomerParams{MaxRows: backfillBatch}
+	for {
+		var rows []repository.ListMeetingsWithoutCustomerRow
+		err := s.q.ExecTx(ctx, func(q *repository.Queries) error {
+			var err error
+			rows, err = q.ListMeetingsWithoutCustomer(ctx, params)
+			if err != nil {
+				return err
+			}
+
+			for _, row := range rows {
+				var metadata map[string]any
+				if err := json.Unmarshal(row.Metadata.RawMessage, &metadata); err != nil {
+					continue // not an object, so it names no customer
+				}
+				customerID, err := LinkCustomer(ctx, q, metadata, keys)
+				if err != nil {
+					return err
+				}
+				if !customerID.Valid {
+					continue
+				}
+				if err := q.SetMeetingCustomer(ctx, repository.SetMeetingCustomerParams{
+					ID:         row.ID,
+					CustomerID: customerID,
+				}); err != nil {
+					return err
+				}
+				linked++
+			}
+			return nil
+		})
+		if err != nil {
+			return linked, err
+		}
+
+		if len(rows) < backfillBatch {
+			return linked, nil
+		}
+		last := rows[len(rows)-1]
+		params.AfterID = utils.ToNullUUID(last.ID)
+		params.AfterTime = last.CreatedAt
+	}
+}
+
+func metadataString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+// parseAmount accepts JSON numbers and strings like "140000", "$140,000" or "140k".
+// Negative, infinite and NaN amounts are refused with ErrInvalidAmount.
+func parseAmount(v any) (float64, error) {
+	var n float64
+	switch v := v.(type) {
+	case float64:
+		n = v
+	case string:
+		s := strings.ToLower(strings.TrimSpace(v))
+		s = strings.NewReplacer("$", "", ",", "", " ", "").Replace(s)
+		multiplier := 1.0
+		switch {
+		case strings.HasSuffix(s, "k"):
+			multiplier, s = 1e3, strings.TrimSuffix(s, "k")
+		case strings.HasSuffix(s, "m"):
+			multiplier, s = 1e6, strings.TrimSuffix(s, "m")
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil && !errors.Is(err, strconv.ErrRange) {
+			return 0, err
+		}
+		n = f * multiplier
+	default:
+		return 0, fmt.Errorf("not an amount: %v", v)
+	}
+
+	if math.IsNaN(n) || math.IsInf(n, 0) || n < 0 {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidAmount, v)
+	}
+	return n, nil
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrThemeNotFound = errors.New("theme not found")
+	ErrThemeExists   = errors.New("theme already exists")
+)
+
+// OpportunityUpdate holds the fields to change; nil fields are left as they are.
+// An empty WhyItMatters, Workaround or Theme clears it.
+type OpportunityUpdate struct {
+	UserSegment  *string `json:"user_segment,omitempty"`
+	Struggle     *string `json:"struggle,omitempty"`
+	WhyItMatters *string `json:"why_it_matters,omitempty"`
+	Workaround   *string `json:"workaround,omitempty"`
+	Theme        *string `json:"theme,omitempty"`
+
+	// Scoring estimates, see ScoreOpportunity.
+	Impact     *float64 `json:"impact,omitempty"`
+	Confidence *float64 `json:"confidence,omitempty"`
+	Effort     *float64 `json:"effort,omitempty"`
+
+	OutcomeID *string `json:"outcome_id,omitempty"` // "" detaches it from its outcome
+	ParentID  *string `json:"parent_id,omitempty"`  // "" makes it a top-level opportunity
+}
+
+func (s *OpportunityService) UpdateOpportunity(ctx context.Context, id uuid.UUID, upd OpportunityUpdate, actor string) error {
+	return s.q.ExecTx(ctx, func(q *repository.Queries) error {
+		if upd.ParentID != nil {
+			if err := q.LockOpportunityHierarchy(ctx); err != nil {
+				return err
+			}
+		}
+		if err := lockOpportunity(ctx, q, id); err != nil {
+			return err
+		}
+
+		params := repository.UpdateOpportunityParams{
+			ID:           id,
+			UserSegment:  nullable(upd.UserSegment),
+			Struggle:     nullable(upd.Struggle),
+			WhyItMatters: nullable(upd.WhyItMatters),
+			Workaround:   nullable(upd.Workaround),
+			Impact:       utils.PtrToNullFloat64(upd.Impact),
+			Confidence:   utils.PtrToNullFloat64(upd.Confidence),
+			Effort:       utils.PtrToNullFloat64(upd.Effort),
+		}
+		if upd.Theme != nil {
+			themeID, err := resolveTheme(ctx, q, *upd.Theme)
+			if err != nil {
+				return err
+			}
+			params.SetTheme = true
+			params.ThemeID = themeID
+		}
+		if upd.OutcomeID != nil {
+			outcomeID, err := resolveOutcome(ctx, q, *upd.OutcomeID)
+			if err != nil {
+				return err
+			}
+			params.SetOutcome = true
+			params.OutcomeID = outcomeID
+		}
+		if upd.ParentID != nil {
+			parentID, err := resolveParent(ctx, q, id, *upd.ParentID)
+			if err != nil {
+				return err
+			}
+			params.SetParent = true
+			params.ParentID = parentID
+		}
+
+		if err := q.UpdateOpportunity(ctx, params); err != nil {
+			return err
+		}
+
+		return audit(ctx, q, "opportunity.updated", "opportunity", id, actor, upd)
+	})
+}
+
+// DeleteOpportunity deletes an opportunity and all of its evidence.
+// Its child opportunities move up to its parent.
+func (s *OpportunityService) DeleteOpportunity(ctx context.Context, id uuid.UUID, actor string) error {
+	return s.q.ExecTx(ctx, func(q *repository.Queries) error {
+		if err := q.LockOpportunityHierarchy(ctx); err != nil {
+			return err
+		}
+		opp, err := q.GetOpportunity(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return opportunityNotFound(ctx, q, id)
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := q.MoveChildOpportunities(ctx, repository.MoveChildOpportunitiesParams{
+			SourceID: id,
+			TargetID: opp.ParentID,
+		}); err != nil {
+			return err
+		}
+
+		n, err := q.DeleteOpportunity(ctx, id)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("%w: %s", ErrOpportunityNotFound, id)
+		}
+
+		return audit(ctx, q, "opportunity.deleted", "opportunity", id, actor, map[string]any{})
+	})
+}
+
+// DeleteEvidence removes one quote. The opportunity stays, even if it has no evidence left.
+func (s *OpportunityService) DeleteEvidence(ctx context.Context, id uuid.UUID, actor string) error {
+	return s.q.ExecTx(ctx, func(q *repository.Queries) error {
+		ev, err := q.DeleteEvidence(ctx, id)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: %s", ErrEvidenceNotFound, id)
+			}
+			return err
+		}
+
+		if err := q.TouchOpportunity(ctx, ev.OpportunityID); err != nil {
+			return err
+		}
+
+		return audit(ctx, q, "evidence.deleted", "evidence", id, actor, map[string]any{
+			"opportunity_id": ev.OpportunityID,
+			"meeting_id":     ev.MeetingID,
+			"quote":          ev.Quote,
+		})
+	})
+}
+
+// RenameTheme renames a theme. Renaming onto an existing theme is refused;
+// that's a merge, see MergeThemes.
+func (s *OpportunityService) RenameTheme(ctx context.Context, name, newName, actor string) error {
+	name, newName = utils.FormatTheme(name), utils.FormatTheme(newName)
+
+	return s.q.ExecTx(ctx, func(q *repository.Queries) error {
+		theme, err := getTheme(ctx, q, name)
+		if err != nil {
+			return err
+		}
+
+		if _, err := q.GetThemeByName(ctx, newName); err == nil {
+			return fmt.Errorf("%w: %s", ErrThemeExists, newName)
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		if err := q.RenameTheme(ctx, repository.RenameThemeParams{ID: theme.ID, Name: newName}); err != nil {
+			return err
+		}
+
+		return audit(ctx, q, "theme.renamed", "theme", theme.ID, actor, map[string]any{
+			"from": name,
+			"to":   newName,
+		})
+	})
+}
+
+// MergeThemes moves every opportunity of theme name into theme into, then deletes name.
+func (s *OpportunityService) MergeThemes(ctx context.Context, name, into, actor string) (int, error) {
+	name, into = utils.FormatTheme(name), utils.FormatTheme(into)
+	var moved int64
+
+	err := s.q.ExecTx(ctx, func(q *repository.Queries) error {
+		if name == into {
+			return fmt.Errorf("%w: cannot merge a theme into itself", ErrInvalidCuration)
+		}
+
+		source, err := getTheme(ctx, q, name)
+		if err != nil {
+			return err
+		}
+		target, err := getTheme(ctx, q, into)
+		if err != nil {
+			return err
+		}
+
+		moved, err = q.MoveThemeOpportunities(ctx, repository.MoveThemeOpportunitiesParams{
+			FromID: source.ID,
+			ToID:   utils.ToNullUUID(target.ID),
+		})
+		if err != nil {
+			return err
+		}
+		if err := q.DeleteTheme(ctx, source.ID); err != nil {
+			return err
+		}
+
+		return audit(ctx, q, "theme.merged", "theme", target.ID, actor, map[string]any{
+			"merged":              name,
+			"merged_id":           source.ID,
+			"moved_opportunities": moved,
+		})
+	})
+
+	return int(moved), err
+}
+
+// DeleteTheme deletes a theme; its opportunities are kept without a theme.
+func (s *OpportunityService) DeleteTheme(ctx context.Context, name, actor string) error {
+	name = utils.FormatTheme(name)
+
+	return s.q.ExecTx(ctx, func(q *repository.Queries) error {
+		theme, err := getTheme(ctx, q, name)
+		if err != nil {
+			return err
+		}
+
+		moved, err := q.MoveThemeOpportunities(ctx, repository.MoveThemeOpportunitiesParams{FromID: theme.ID})
+		if err != nil {
+			return err
+		}
+		if err := q.DeleteTheme(ctx, theme.ID); err != nil {
+			return err
+		}
+
+		return audit(ctx, q, "theme.deleted", "theme", theme.ID, actor, map[string]any{
+			"name":                   name,
+			"unthemed_opportunities": moved,
+		})
+	})
+}
+
+func getTheme(ctx context.Context, q *repository.Queries, name string) (repository.Theme, error) {
+	theme, err := q.GetThemeByName(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return theme, fmt.Errorf("%w: %s", ErrThemeNotFound, name)
+	}
+	return theme, err
+}
+
+func nullable(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// ErrHierarchyCycle is returned when an opportunity would end up below itself.
+var ErrHierarchyCycle = errors.New("opportunity hierarchy cycle")
+
+// resolveParent checks that parent idStr can hold opportunity id as a child: it exists
+// (merged IDs are followed to where they went) and isn't id or one of id's descendants.
+// An empty idStr means no parent. The caller must hold the hierarchy lock.
+func resolveParent(ctx context.Context, q *repository.Queries, id uuid.UUID, idStr string) (uuid.NullUUID, error) {
+	if idStr == "" {
+		return uuid.NullUUID{}, nil
+	}
+
+	parent, err := uuid.Parse(idStr)
+	if err != nil {
+		return uuid.NullUUID{}, fmt.Errorf("%w: invalid parent ID %q", ErrInvalidCuration, idStr)
+	}
+	if to, err := q.GetOpportunityRedirect(ctx, parent); err == nil {
+		parent = to
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return uuid.NullUUID{}, err
+	}
+	if _, err := q.GetOpportunity(ctx, parent); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.NullUUID{}, fmt.Errorf("%w: %s", ErrOpportunityNotFound, parent)
+		}
+		return uuid.NullUUID{}, err
+	}
+
+	if parent == id {
+		return uuid.NullUUID{}, fmt.Errorf("%w: an opportunity cannot be its own parent", ErrHierarchyCycle)
+	}
+	ancestors, err := q.ListOpportunityAncestors(ctx, parent)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	for _, a := range ancestors {
+		if a.ID == id {
+			return uuid.NullUUID{}, fmt.Errorf("%w: %s is below %s", ErrHierarchyCycle, parent, id)
+		}
+	}
+
+	return utils.ToNullUUID(parent), nil
+}
+
+// adoptChildren moves the children of sources under target before the sources are
+// deleted. If target sits below one of the sources it would become its own ancestor,
+// so it is first lifted to just above the highest of them.
+// The caller must hold the hierarchy lock.
+func adoptChildren(ctx context.Context, q *repository.Queries, target uuid.UUID, sources map[uuid.UUID]bool) error {
+	ancestors, err := q.ListOpportunityAncestors(ctx, target)
+	if err != nil {
+		return err
+	}
+	highest := -1
+	for i, a := range ancestors {
+		if sources[a.ID] {
+			highest = i
+		}
+	}
+	if highest >= 0 {
+		var parent uuid.NullUUID
+		if highest+1 < len(ancestors) {
+			parent = utils.ToNullUUID(ancestors[highest+1].ID)
+		}
+		if err := q.SetOpportunityParent(ctx, repository.SetOpportunityParentParams{ID: target, ParentID: parent}); err != nil {
+			return err
+		}
+	}
+
+	for source := range sources {
+		if _, err := q.MoveChildOpportunities(ctx, repository.MoveChildOpportunitiesParams{
+			SourceID: source,
+			TargetID: utils.ToNullUUID(target),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
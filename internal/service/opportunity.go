@@ -2,110 +2,251 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/pedy4000/noker/internal/models"
 	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/internal/webhook"
 	"github.com/pedy4000/noker/pkg/utils"
 
 	"github.com/google/uuid"
 )
 
+// ErrInvalidExtraction marks extraction results that can never be saved as they are,
+// so retrying the same meeting would not help.
+var ErrInvalidExtraction = errors.New("invalid extraction")
+
 type OpportunityService struct {
-	q *repository.Queries
+	q *repository.Store
 }
 
-func NewOpportunityService(q *repository.Queries) *OpportunityService {
+func NewOpportunityService(q *repository.Store) *OpportunityService {
 	return &OpportunityService{q: q}
 }
 
+// ProcessExtractedOpportunities applies a meeting's extraction result in one
+// transaction: either every theme, opportunity and quote is saved, or none is.
+// Replaying the same result is a no-op, so a retried meeting never duplicates evidence
+// (or sends the same webhook event twice).
 func (s *OpportunityService) ProcessExtractedOpportunities(
 	ctx context.Context,
 	meetingID uuid.UUID,
 	extracted []models.ExtractedOpportunity,
 ) error {
-	for _, opp := range extracted {
-		if opp.Type == "new" {
-			if err := s.createOpportunity(ctx, meetingID, opp); err != nil {
-				return err
-			}
-		} else {
-			if err := s.updateOpportunity(ctx, meetingID, opp); err != nil {
-				return err
+	return s.q.ExecTx(ctx, func(q *repository.Queries) error {
+		for _, opp := range extracted {
+			if opp.Type == "new" {
+				if err := s.createOpportunity(ctx, q, meetingID, opp); err != nil {
+					return err
+				}
+			} else {
+				if err := s.updateOpportunity(ctx, q, meetingID, opp); err != nil {
+					return err
+				}
 			}
 		}
-	}
-	return nil
+		return nil
+	})
 }
 
 func (s *OpportunityService) createOpportunity(
 	ctx context.Context,
+	q *repository.Queries,
 	meetingID uuid.UUID,
 	ext models.ExtractedOpportunity,
 ) error {
-	var themeID uuid.NullUUID
-	ext.Theme = utils.FormatTheme(ext.Theme)
+	// Same meeting, same struggle: this result was applied before.
+	existingID, err := q.FindOpportunityFromMeeting(ctx, repository.FindOpportunityFromMeetingParams{
+		MeetingID: meetingID,
+		Struggle:  ext.Struggle,
+	})
+	if err == nil {
+		return s.addEvidence(ctx, q, existingID, meetingID, ext.EvidenceQuotes)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
 
-	if ext.Theme != "" {
-		theme, err := s.q.GetThemeByName(ctx, ext.Theme)
+	themeID, err := resolveTheme(ctx, q, ext.Theme)
+	if err != nil {
+		return err
+	}
+
+	var parentID uuid.NullUUID
+	if ext.ParentOpportunityID != "" {
+		id, err := uuid.Parse(ext.ParentOpportunityID)
 		if err != nil {
-			// Create new theme
-			newTheme, err := s.q.CreateTheme(ctx, ext.Theme)
-			if err != nil {
-				return err
-			}
-			themeID = utils.ToNullUUID(newTheme.ID)
-		} else {
-			themeID = utils.ToNullUUID(theme.ID)
+			return fmt.Errorf("%w: invalid parent opportunity ID '%s': %v", ErrInvalidExtraction, ext.ParentOpportunityID, err)
+		}
+		// A parent deleted since the candidates were listed leaves it top-level.
+		if id, ok, err := keepOpportunity(ctx, q, id); err != nil {
+			return err
+		} else if ok {
+			parentID = utils.ToNullUUID(id)
 		}
 	}
 
 	// New opportunity
-	opp, err := s.q.CreateOpportunity(ctx, repository.CreateOpportunityParams{
+	opp, err := q.CreateOpportunity(ctx, repository.CreateOpportunityParams{
 		UserSegment:  ext.UserSegment,
 		Struggle:     ext.Struggle,
 		WhyItMatters: utils.ToNullString(ext.WhyItMatters),
 		Workaround:   utils.ToNullString(ext.Workaround),
 		ThemeID:      themeID,
+		ParentID:     parentID,
+		MeetingID:    utils.ToNullUUID(meetingID),
 	})
 	if err != nil {
 		return err
 	}
 
-	return s.addEvidence(ctx, opp.ID, meetingID, ext.EvidenceQuotes)
+	quotes, err := saveEvidence(ctx, q, opp.ID, meetingID, ext.EvidenceQuotes)
+	if err != nil {
+		return err
+	}
+
+	return webhook.Enqueue(ctx, q, webhook.EventOpportunityCreated, webhook.OpportunityData{
+		OpportunityID: opp.ID,
+		MeetingID:     &meetingID,
+		UserSegment:   opp.UserSegment,
+		Struggle:      opp.Struggle,
+		WhyItMatters:  opp.WhyItMatters.String,
+		Workaround:    opp.Workaround.String,
+		ThemeID:       nullUUIDPtr(opp.ThemeID),
+		ParentID:      nullUUIDPtr(opp.ParentID),
+		Quotes:        quotes,
+	})
+}
+
+// resolveTheme returns the ID of the named theme, creating it if needed.
+// An empty name means no theme.
+func resolveTheme(ctx context.Context, q *repository.Queries, name string) (uuid.NullUUID, error) {
+	name = utils.FormatTheme(name)
+	if name == "" {
+		return uuid.NullUUID{}, nil
+	}
+
+	theme, err := q.GetThemeByName(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Create new theme
+		if theme, err = q.CreateTheme(ctx, name); err == nil {
+			err = webhook.Enqueue(ctx, q, webhook.EventThemeCreated, webhook.ThemeData{ThemeID: theme.ID, Name: theme.Name})
+		}
+	}
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	return utils.ToNullUUID(theme.ID), nil
 }
 
 func (s *OpportunityService) updateOpportunity(
 	ctx context.Context,
+	q *repository.Queries,
 	meetingID uuid.UUID,
 	ext models.ExtractedOpportunity,
 ) error {
 	oppID, err := uuid.Parse(ext.ExistingOpportunityID)
 	if err != nil {
-		return fmt.Errorf("invalid existing opportunity ID '%s': %w", ext.ExistingOpportunityID, err)
+		return fmt.Errorf("%w: invalid existing opportunity ID '%s': %v", ErrInvalidExtraction, ext.ExistingOpportunityID, err)
 	}
 
-	return s.addEvidence(ctx, oppID, meetingID, ext.EvidenceQuotes)
+	// The match may have been merged or deleted since the candidates were listed.
+	oppID, ok, err := keepOpportunity(ctx, q, oppID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if ext.Struggle == "" {
+			return nil // nothing to recreate it from, like a match to an unknown ID
+		}
+		return s.createOpportunity(ctx, q, meetingID, ext)
+	}
+
+	return s.addEvidence(ctx, q, oppID, meetingID, ext.EvidenceQuotes)
+}
+
+// keepOpportunity follows id's merge redirect, if any, and keeps the opportunity
+// it ends at from being merged or deleted until the transaction ends.
+// ok is false when the opportunity was deleted. Redirects point straight at the
+// last merge target, so a second hop only happens when that was merged meanwhile.
+func keepOpportunity(ctx context.Context, q *repository.Queries, id uuid.UUID) (uuid.UUID, bool, error) {
+	for {
+		_, err := q.KeepOpportunity(ctx, id)
+		if err == nil {
+			return id, true, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, false, err
+		}
+
+		to, err := q.GetOpportunityRedirect(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, false, nil
+		}
+		if err != nil {
+			return uuid.Nil, false, err
+		}
+		id = to
+	}
 }
 
+// addEvidence adds quotes to an existing opportunity and announces the ones it didn't have yet.
 func (s *OpportunityService) addEvidence(
 	ctx context.Context,
+	q *repository.Queries,
 	oppID, meetingID uuid.UUID,
 	quotes []models.EvidenceQuote,
 ) error {
-	for _, q := range quotes {
-		if q.Quote == "" {
+	added, err := saveEvidence(ctx, q, oppID, meetingID, quotes)
+	if err != nil || len(added) == 0 {
+		return err
+	}
+
+	return webhook.Enqueue(ctx, q, webhook.EventOpportunityEvidenceAdded, webhook.OpportunityData{
+		OpportunityID: oppID,
+		MeetingID:     &meetingID,
+		Quotes:        added,
+	})
+}
+
+// saveEvidence stores quotes and returns the ones that weren't there already.
+func saveEvidence(
+	ctx context.Context,
+	q *repository.Queries,
+	oppID, meetingID uuid.UUID,
+	quotes []models.EvidenceQuote,
+) ([]string, error) {
+	var added []string
+	for _, quote := range quotes {
+		if quote.Quote == "" {
 			continue
 		}
-		err := s.q.AddEvidence(ctx, repository.AddEvidenceParams{
+		n, err := q.AddEvidence(ctx, repository.AddEvidenceParams{
 			OpportunityID: oppID,
 			MeetingID:     meetingID,
-			Quote:         q.Quote,
-			Context:       utils.ToNullString(q.Context),
+			Quote:         quote.Quote,
+			Context:       utils.ToNullString(quote.Context),
+			ChunkIndex:    int32(quote.ChunkIndex),
+			ChunkOffset:   int32(quote.ChunkOffset),
+			Verified:      quote.Verified,
+			QuoteStart:    sql.NullInt32{Int32: int32(quote.QuoteStart), Valid: quote.Verified},
+			QuoteEnd:      sql.NullInt32{Int32: int32(quote.QuoteEnd), Valid: quote.Verified},
 		})
 		if err != nil {
-			return err
+			return nil, err
+		}
+		if n > 0 {
+			added = append(added, quote.Quote)
 		}
 	}
-	return nil
+	return added, nil
+}
+
+func nullUUIDPtr(id uuid.NullUUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	return &id.UUID
 }
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/pedy4000/noker/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrMeetingNotFound = errors.New("meeting not found")
+	ErrMeetingBusy     = errors.New("meeting is being processed")
+)
+
+// ReprocessResult summarizes what a reprocess rolled back.
+type ReprocessResult struct {
+	MeetingIDs           []uuid.UUID
+	DeletedOpportunities int
+}
+
+// EnqueueFunc queues a reset meeting from inside the reset's transaction.
+type EnqueueFunc func(ctx context.Context, q *repository.Queries, meetingID uuid.UUID) error
+
+// ResetMeeting rolls back everything a meeting contributed to the tree and marks
+// it pending again. enqueue, when set, queues it in the same transaction;
+// otherwise the caller enqueues it once this returns.
+func (s *OpportunityService) ResetMeeting(ctx context.Context, meetingID uuid.UUID, enqueue EnqueueFunc) (ReprocessResult, error) {
+	var result ReprocessResult
+
+	err := s.q.ExecTx(ctx, func(q *repository.Queries) error {
+		if _, err := q.GetMeeting(ctx, meetingID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrMeetingNotFound
+			}
+			return err
+		}
+
+		deleted, err := s.resetMeeting(ctx, q, meetingID, enqueue)
+		if err != nil {
+			return err
+		}
+
+		result.MeetingIDs = []uuid.UUID{meetingID}
+		result.DeletedOpportunities = deleted
+		return nil
+	})
+
+	return result, err
+}
+
+// ResetMeetings does the same as ResetMeeting for every meeting matching the
+// filter, in a single transaction. Meetings being processed right now are skipped.
+func (s *OpportunityService) ResetMeetings(ctx context.Context, filter repository.ListMeetingsForReprocessParams, enqueue EnqueueFunc) (ReprocessResult, error) {
+	var result ReprocessResult
+
+	err := s.q.ExecTx(ctx, func(q *repository.Queries) error {
+		ids, err := q.ListMeetingsForReprocess(ctx, filter)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			deleted, err := s.resetMeeting(ctx, q, id, enqueue)
+			if errors.Is(err, ErrMeetingBusy) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			result.MeetingIDs = append(result.MeetingIDs, id)
+			result.DeletedOpportunities += deleted
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+// resetMeeting marks the meeting pending first: the row lock it takes keeps
+// workers from starting on it until the rollback is committed.
+func (s *OpportunityService) resetMeeting(ctx context.Context, q *repository.Queries, meetingID uuid.UUID, enqueue EnqueueFunc) (int, error) {
+	n, err := q.ResetMeetingForReprocess(ctx, meetingID)
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, ErrMeetingBusy
+	}
+
+	deleted, err := q.DeleteMeetingContribution(ctx, meetingID)
+	if err != nil {
+		return 0, fmt.Errorf("rollback evidence of meeting %s: %w", meetingID, err)
+	}
+
+	if enqueue != nil {
+		if err := enqueue(ctx, q, meetingID); err != nil {
+			return 0, fmt.Errorf("enqueue meeting %s: %w", meetingID, err)
+		}
+	}
+
+	return len(deleted), nil
+}
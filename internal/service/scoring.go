@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/pedy4000/noker/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	FrameworkRICE = "rice" // reach × impact × confidence / effort
+	FrameworkICE  = "ice"  // impact × confidence × ease, where ease is 1 / effort
+)
+
+// Values used for factors nobody has estimated yet, so new opportunities still rank
+// on their reach instead of dropping to the bottom.
+const (
+	DefaultImpact     = 1.0 // "medium" on the usual 0.25–3 RICE scale
+	DefaultConfidence = 0.5
+	DefaultEffort     = 1.0 // person-months
+)
+
+// ScoringWeights is how a workspace turns estimates into a score. Reach is
+// ReachPerCustomer × distinct linked customers + ReachPerEvidence × quotes; each
+// factor is then raised to its weight, so 1 is plain RICE/ICE and 0 ignores the factor.
+type ScoringWeights struct {
+	Workspace        string  `json:"workspace"`
+	ReachPerCustomer float64 `json:"reach_per_customer"`
+	ReachPerEvidence float64 `json:"reach_per_evidence"`
+	ReachWeight      float64 `json:"reach_weight"`
+	ImpactWeight     float64 `json:"impact_weight"`
+	ConfidenceWeight float64 `json:"confidence_weight"`
+	EffortWeight     float64 `json:"effort_weight"`
+}
+
+// DefaultScoringWeights are the weights of a workspace that never changed them.
+func DefaultScoringWeights(workspace string) ScoringWeights {
+	return ScoringWeights{
+		Workspace:        workspace,
+		ReachPerCustomer: 1,
+		ReachPerEvidence: 0.1,
+		ReachWeight:      1,
+		ImpactWeight:     1,
+		ConfidenceWeight: 1,
+		EffortWeight:     1,
+	}
+}
+
+// Score is an opportunity's factors, with defaults filled in, and both scores.
+type Score struct {
+	Reach      float64 `json:"reach"`
+	Impact     float64 `json:"impact"`
+	Confidence float64 `json:"confidence"`
+	Effort     float64 `json:"effort"`
+	RICE       float64 `json:"rice"`
+	ICE        float64 `json:"ice"`
+	Estimated  bool    `json:"estimated"` // impact, confidence and effort were all set by a person
+}
+
+// ScoreOpportunity computes the RICE and ICE scores of one opportunity.
+func ScoreOpportunity(customers, evidence int64, impact, confidence, effort sql.NullFloat64, w ScoringWeights) Score {
+	s := Score{
+		Reach:      w.ReachPerCustomer*float64(customers) + w.ReachPerEvidence*float64(evidence),
+		Impact:     orDefault(impact, DefaultImpact),
+		Confidence: orDefault(confidence, DefaultConfidence),
+		Effort:     orDefault(effort, DefaultEffort),
+		Estimated:  impact.Valid && confidence.Valid && effort.Valid,
+	}
+
+	s.ICE = math.Pow(s.Impact, w.ImpactWeight) *
+		math.Pow(s.Confidence, w.ConfidenceWeight) /
+		math.Pow(s.Effort, w.EffortWeight)
+	s.RICE = math.Pow(s.Reach, w.ReachWeight) * s.ICE
+	return s
+}
+
+func orDefault(n sql.NullFloat64, def float64) float64 {
+	if n.Valid && n.Float64 > 0 {
+		return n.Float64
+	}
+	return def
+}
+
+type PrioritizedOpportunity struct {
+	ID            uuid.UUID
+	UserSegment   string
+	Struggle      string
+	Theme         string
+	Created       sql.NullTime
+	EvidenceCount int64
+	CustomerCount int64
+	Score         Score
+}
+
+// ScoringWeights returns the workspace's weights, or the defaults if it never set any.
+func (s *OpportunityService) ScoringWeights(ctx context.Context, workspace string) (ScoringWeights, error) {
+	row, err := s.q.GetScoringWeights(ctx, workspace)
+	if errors.Is(err, sql.ErrNoRows) {
+		return DefaultScoringWeights(workspace), nil
+	}
+	if err != nil {
+		return ScoringWeights{}, err
+	}
+	return ScoringWeights{
+		Workspace:        row.Workspace,
+		ReachPerCustomer: row.ReachPerCustomer,
+		ReachPerEvidence: row.ReachPerEvidence,
+		ReachWeight:      row.ReachWeight,
+		ImpactWeight:     row.ImpactWeight,
+		ConfidenceWeight: row.ConfidenceWeight,
+		EffortWeight:     row.EffortWeight,
+	}, nil
+}
+
+func (s *OpportunityService) SetScoringWeights(ctx context.Context, w ScoringWeights) error {
+	_, err := s.q.UpsertScoringWeights(ctx, repository.UpsertScoringWeightsParams{
+		Workspace:        w.Workspace,
+		ReachPerCustomer: w.ReachPerCustomer,
+		ReachPerEvidence: w.ReachPerEvidence,
+		ReachWeight:      w.ReachWeight,
+		ImpactWeight:     w.ImpactWeight,
+		ConfidenceWeight: w.ConfidenceWeight,
+		EffortWeight:     w.EffortWeight,
+	})
+	return err
+}
+
+// PrioritizedOpportunities returns the best `limit` opportunities under the given
+// framework, scored with the workspace's weights. The database ranks them; the
+// scores shown are computed here with ScoreOpportunity.
+func (s *OpportunityService) PrioritizedOpportunities(ctx context.Context, workspace, framework string, limit int) ([]PrioritizedOpportunity, ScoringWeights, error) {
+	w, err := s.ScoringWeights(ctx, workspace)
+	if err != nil {
+		return nil, w, err
+	}
+
+	rows, err := s.q.ListPrioritizedOpportunities(ctx, repository.ListPrioritizedOpportunitiesParams{
+		Framework:         framework,
+		MaxRows:           int32(limit),
+		ReachPerCustomer:  w.ReachPerCustomer,
+		ReachPerEvidence:  w.ReachPerEvidence,
+		ReachWeight:       w.ReachWeight,
+		DefaultImpact:     DefaultImpact,
+		ImpactWeight:      w.ImpactWeight,
+		DefaultConfidence: DefaultConfidence,
+		ConfidenceWeight:  w.ConfidenceWeight,
+		DefaultEffort:     DefaultEffort,
+		EffortWeight:      w.EffortWeight,
+	})
+	if err != nil {
+		return nil, w, err
+	}
+
+	result := make([]PrioritizedOpportunity, len(rows))
+	for i, row := range rows {
+		result[i] = PrioritizedOpportunity{
+			ID:            row.ID,
+			UserSegment:   row.UserSegment,
+			Struggle:      row.Struggle,
+			Theme:         row.ThemeName.String,
+			Created:       row.CreatedAt,
+			EvidenceCount: row.EvidenceCount,
+			CustomerCount: row.CustomerCount,
+			Score:         ScoreOpportunity(row.CustomerCount, row.EvidenceCount, row.Impact, row.Confidence, row.Effort, w),
+		}
+	}
+
+	// Same order as the query, in case float rounding differs between the two.
+	SortByScore(result, framework)
+	return result, w, nil
+}
+
+// SortByScore orders opportunities best first; ties go to the one with more evidence.
+func SortByScore(opps []PrioritizedOpportunity, framework string) {
+	score := func(o PrioritizedOpportunity) float64 {
+		if framework == FrameworkICE {
+			return o.Score.ICE
+		}
+		return o.Score.RICE
+	}
+	sort.SliceStable(opps, func(i, j int) bool {
+		if a, b := score(opps[i]), score(opps[j]); a != b {
+			return a > b
+		}
+		return opps[i].EvidenceCount > opps[j].EvidenceCount
+	})
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrOutcomeNotFound    = errors.New("outcome not found")
+	ErrSolutionNotFound   = errors.New("solution not found")
+	ErrExperimentNotFound = errors.New("experiment not found")
+)
+
+// OutcomeChange creates or updates an outcome; nil fields are left as they are
+// on update, and an empty Description or Metric clears it.
+type OutcomeChange struct {
+	Title       *string `json:"title,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Metric      *string `json:"metric,omitempty"`
+}
+
+// SolutionChange creates or updates a solution, see OutcomeChange.
+type SolutionChange struct {
+	Title       *string `json:"title,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Status      *string `json:"status,omitempty"`
+}
+
+// ExperimentChange creates or updates an assumption test, see OutcomeChange.
+type ExperimentChange struct {
+	Assumption *string `json:"assumption,omitempty"`
+	Method     *string `json:"method,omitempty"`
+	Status     *string `json:"status,omitempty"`
+	Result     *string `json:"result,omitempty"`
+}
+
+func (s *OpportunityService) CreateOutcome(ctx context.Context, c OutcomeChange, actor string) (repository.Outcome, error) {
+	var outcome repository.Outcome
+	err := s.q.ExecTx(ctx, func(q *repository.Queries) error {
+		var err error
+		outcome, err = q.CreateOutcome(ctx, repository.CreateOutcomeParams{
+			Title:       deref(c.Title),
+			Description: utils.ToNullString(deref(c.Description)),
+			Metric:      utils.ToNullString(deref(c.Metric)),
+		})
+		if err != nil {
+			return err
+		}
+
+		return audit(ctx, q, "outcome.created", "outcome", outcome.ID, actor, c)
+	})
+	return outcome, err
+}
+
+func (s *OpportunityService) UpdateOutcome(ctx context.Context, id uuid.UUID, c OutcomeChange, actor string) (repository.Outcome, error) {
+	var outcome repository.Outcome
+	err := s.q.ExecTx(ctx, func(q *repository.Queries) error {
+		var err error
+		outcome, err = q.UpdateOutcome(ctx, repository.UpdateOutcomeParams{
+			ID:          id,
+			Title:       nullable(c.Title),
+			Description: nullable(c.Description),
+			Metric:      nullable(c.Metric),
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %s", ErrOutcomeNotFound, id)
+		}
+		if err != nil {
+			return err
+		}
+
+		return audit(ctx, q, "outcome.updated", "outcome", id, actor, c)
+	})
+	return outcome, err
+}
+
+// DeleteOutcome deletes an outcome; its opportunities are kept without one.
+func (s *OpportunityService) DeleteOutcome(ctx context.Context, id uuid.UUID, actor string) error {
+	return s.q.ExecTx(ctx, func(q *repository.Queries) error {
+		n, err := q.DeleteOutcome(ctx, id)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("%w: %s", ErrOutcomeNotFound, id)
+		}
+
+		return audit(ctx, q, "outcome.deleted", "outcome", id, actor, map[string]any{})
+	})
+}
+
+// CreateSolution adds a solution under an opportunity. A merged-away opportunity
+// is refused with a *MergedError.
+func (s *OpportunityService) CreateSolution(ctx context.Context, id uuid.UUID, c SolutionChange, actor string) (repository.Solution, error) {
+	var solution repository.Solution
+	err := s.q.ExecTx(ctx, func(q *repository.Queries) error {
+		if err := lockOpportunity(ctx, q, id); err != nil {
+			return err
+		}
+
+		var err error
+		solution, err = q.CreateSolution(ctx, repository.CreateSolutionParams{
+			OpportunityID: id,
+			Title:         deref(c.Title),
+			Description:   utils.ToNullString(deref(c.Description)),
+			Status:        nullable(c.Status),
+		})
+		if err != nil {
+			return err
+		}
+
+		return audit(ctx, q, "solution.created", "opportunity", id, actor, map[string]any{
+			"solution_id": solution.ID,
+			"title":       solution.Title,
+		})
+	})
+	return solution, err
+}
+
+func (s *OpportunityService) UpdateSolution(ctx context.Context, id uuid.UUID, c SolutionChange, actor string) (repository.Solution, error) {
+	var solution repository.Solution
+	err := s.q.ExecTx(ctx, func(q *repository.Queries) error {
+		var err error
+		solution, err = q.UpdateSolution(ctx, repository.UpdateSolutionParams{
+			ID:          id,
+			Title:       nullable(c.Title),
+			Description: nullable(c.Description),
+			Status:      nullable(c.Status),
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %s", ErrSolutionNotFound, id)
+		}
+		if err != nil {
+			return err
+		}
+
+		return audit(ctx, q, "solution.updated", "solution", id, actor, c)
+	})
+	return solution, err
+}
+
+// DeleteSolution deletes a solution and its experiments.
+func (s *OpportunityService) DeleteSolution(ctx context.Context, id uuid.UUID, actor string) error {
+	return s.q.ExecTx(ctx, func(q *repository.Queries) error {
+		oppID, err := q.DeleteSolution(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %s", ErrSolutionNotFound, id)
+		}
+		if err != nil {
+			return err
+		}
+
+		return audit(ctx, q, "solution.deleted", "opportunity", oppID, actor, map[string]any{
+			"solution_id": id,
+		})
+	})
+}
+
+func (s *OpportunityService) CreateExperiment(ctx context.Context, solutionID uuid.UUID, c ExperimentChange, actor string) (repository.Experiment, error) {
+	var experiment repository.Experiment
+	err := s.q.ExecTx(ctx, func(q *repository.Queries) error {
+		if _, err := q.GetSolution(ctx, solutionID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: %s", ErrSolutionNotFound, solutionID)
+			}
+			return err
+		}
+
+		var err error
+		experiment, err = q.CreateExperiment(ctx, repository.CreateExperimentParams{
+			SolutionID: solutionID,
+			Assumption: deref(c.Assumption),
+			Method:     utils.ToNullString(deref(c.Method)),
+			Status:     nullable(c.Status),
+			Result:     utils.ToNullString(deref(c.Result)),
+		})
+		if err != nil {
+			return err
+		}
+
+		return audit(ctx, q, "experiment.created", "solution", solutionID, actor, map[string]any{
+			"experiment_id": experiment.ID,
+			"assumption":    experiment.Assumption,
+		})
+	})
+	return experiment, err
+}
+
+func (s *OpportunityService) UpdateExperiment(ctx context.Context, id uuid.UUID, c ExperimentChange, actor string) (repository.Experiment, error) {
+	var experiment repository.Experiment
+	err := s.q.ExecTx(ctx, func(q *repository.Queries) error {
+		var err error
+		experiment, err = q.UpdateExperiment(ctx, repository.UpdateExperimentParams{
+			ID:         id,
+			Assumption: nullable(c.Assumption),
+			Method:     nullable(c.Method),
+			Status:     nullable(c.Status),
+			Result:     nullable(c.Result),
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %s", ErrExperimentNotFound, id)
+		}
+		if err != nil {
+			return err
+		}
+
+		return audit(ctx, q, "experiment.updated", "experiment", id, actor, c)
+	})
+	return experiment, err
+}
+
+func (s *OpportunityService) DeleteExperiment(ctx context.Context, id uuid.UUID, actor string) error {
+	return s.q.ExecTx(ctx, func(q *repository.Queries) error {
+		solutionID, err := q.DeleteExperiment(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %s", ErrExperimentNotFound, id)
+		}
+		if err != nil {
+			return err
+		}
+
+		return audit(ctx, q, "experiment.deleted", "solution", solutionID, actor, map[string]any{
+			"experiment_id": id,
+		})
+	})
+}
+
+// resolveOutcome parses an outcome ID for an opportunity update; "" means no outcome.
+func resolveOutcome(ctx context.Context, q *repository.Queries, idStr string) (uuid.NullUUID, error) {
+	if idStr == "" {
+		return uuid.NullUUID{}, nil
+	}
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return uuid.NullUUID{}, fmt.Errorf("%w: invalid outcome ID %q", ErrInvalidCuration, idStr)
+	}
+	if _, err := q.GetOutcome(ctx, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.NullUUID{}, fmt.Errorf("%w: %s", ErrOutcomeNotFound, id)
+		}
+		return uuid.NullUUID{}, err
+	}
+	return utils.ToNullUUID(id), nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,70 @@
+package slack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pedy4000/noker/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// maxOpportunities keeps a meeting's result well under MaxBlocks.
+const maxOpportunities = 15
+
+// MeetingProcessed lists what was extracted from a meeting added with /ost add.
+// evidence is the meeting's ListMeetingEvidence, grouped by opportunity.
+func MeetingProcessed(meetingID uuid.UUID, title string, evidence []repository.ListMeetingEvidenceRow) Message {
+	type found struct {
+		row    repository.ListMeetingEvidenceRow
+		quotes []string
+	}
+	var opps []*found
+	for _, ev := range evidence {
+		if len(opps) == 0 || opps[len(opps)-1].row.OpportunityID != ev.OpportunityID {
+			opps = append(opps, &found{row: ev})
+		}
+		last := opps[len(opps)-1]
+		last.quotes = append(last.quotes, ev.Quote)
+	}
+
+	if len(opps) == 0 {
+		text := fmt.Sprintf("No opportunities found in \"%s\".", title)
+		return InChannel(text, Section(text), Context("Meeting `"+meetingID.String()+"`"))
+	}
+
+	text := fmt.Sprintf("Found %d opportunities in \"%s\"", len(opps), title)
+	blocks := []Block{Header(text)}
+	for i, opp := range opps {
+		if i == maxOpportunities {
+			blocks = append(blocks, Context(fmt.Sprintf("…and %d more, see `GET /api/meetings/%s`", len(opps)-i, meetingID)))
+			break
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "*%s*\n%s", opp.row.UserSegment, opp.row.Struggle)
+		if opp.row.WhyItMatters.Valid && opp.row.WhyItMatters.String != "" {
+			fmt.Fprintf(&b, "\n_Why it matters:_ %s", opp.row.WhyItMatters.String)
+		}
+		for _, quote := range opp.quotes {
+			fmt.Fprintf(&b, "\n> %s", quote)
+		}
+
+		small := []string{"`" + opp.row.OpportunityID.String() + "`"}
+		if opp.row.ThemeName.Valid && opp.row.ThemeName.String != "" {
+			small = append(small, "Theme: "+opp.row.ThemeName.String)
+		}
+		blocks = append(blocks, Section(b.String()), Context(small...))
+	}
+	blocks = append(blocks, Divider(), Context("Meeting `"+meetingID.String()+"`"))
+
+	return InChannel(text, blocks...)
+}
+
+// MeetingFailed tells the user nothing could be extracted.
+func MeetingFailed(meetingID uuid.UUID, title, reason string) Message {
+	text := fmt.Sprintf("Couldn't extract opportunities from \"%s\": %s", title, reason)
+	return Ephemeral(text,
+		Section(":warning: "+text),
+		Context("Meeting `"+meetingID.String()+"` · retry with `POST /api/meetings/"+meetingID.String()+"/reprocess`"))
+}
@@ -0,0 +1,153 @@
+// Package slack verifies requests sent by Slack and builds the Block Kit messages
+// Noker answers with. The slash command itself is handled by api.SlackSlashCommand.
+package slack
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers Slack signs every request with.
+const (
+	HeaderTimestamp = "X-Slack-Request-Timestamp"
+	HeaderSignature = "X-Slack-Signature"
+)
+
+var (
+	ErrMissingSignature = errors.New("missing signature headers")
+	ErrStaleRequest     = errors.New("request timestamp is too old")
+	ErrBadSignature     = errors.New("signature mismatch")
+)
+
+// Sign returns the signature Slack sends for body: "v0=" followed by the hex
+// HMAC-SHA256 of "v0:<timestamp>:<body>", keyed with the app's signing secret.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + strconv.FormatInt(timestamp, 10) + ":"))
+	mac.Write(body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks that body was signed by Slack. Requests older than maxSkew are
+// rejected even when the signature matches, so a captured request can't be replayed.
+func Verify(secret string, header http.Header, body []byte, now time.Time, maxSkew time.Duration) error {
+	tsHeader, sig := header.Get(HeaderTimestamp), header.Get(HeaderSignature)
+	if tsHeader == "" || sig == "" {
+		return ErrMissingSignature
+	}
+
+	timestamp, err := strconv.ParseInt(tsHeader, 10, 64)
+	if err != nil {
+		return ErrMissingSignature
+	}
+	if skew := now.Sub(time.Unix(timestamp, 0)); skew > maxSkew || skew < -maxSkew {
+		return ErrStaleRequest
+	}
+
+	if !hmac.Equal([]byte(sig), []byte(Sign(secret, timestamp, body))) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// Message is a slash command reply, or a message posted to its response_url.
+type Message struct {
+	ResponseType string  `json:"response_type,omitempty"` // ephemeral (default) | in_channel
+	Text         string  `json:"text"`                    // shown in notifications, and where blocks can't be
+	Blocks       []Block `json:"blocks,omitempty"`
+}
+
+type Block struct {
+	Type     string `json:"type"`
+	Text     *Text  `json:"text,omitempty"`
+	Fields   []Text `json:"fields,omitempty"`
+	Elements []Text `json:"elements,omitempty"`
+}
+
+type Text struct {
+	Type string `json:"type"` // plain_text | mrkdwn
+	Text string `json:"text"`
+}
+
+// Slack rejects messages over these limits.
+const (
+	maxHeaderLen  = 150
+	maxSectionLen = 3000
+	MaxBlocks     = 50
+)
+
+func Header(text string) Block {
+	return Block{Type: "header", Text: &Text{Type: "plain_text", Text: truncate(text, maxHeaderLen)}}
+}
+
+func Section(mrkdwn string) Block {
+	return Block{Type: "section", Text: &Text{Type: "mrkdwn", Text: truncate(mrkdwn, maxSectionLen)}}
+}
+
+// Context is a line of small print, such as IDs and dates.
+func Context(mrkdwn ...string) Block {
+	elements := make([]Text, len(mrkdwn))
+	for i, text := range mrkdwn {
+		elements[i] = Text{Type: "mrkdwn", Text: truncate(text, maxSectionLen)}
+	}
+	return Block{Type: "context", Elements: elements}
+}
+
+func Divider() Block {
+	return Block{Type: "divider"}
+}
+
+// Ephemeral is only shown to the user who ran the command.
+func Ephemeral(text string, blocks ...Block) Message {
+	return Message{ResponseType: "ephemeral", Text: text, Blocks: blocks}
+}
+
+// InChannel is shown to everyone in the channel the command was run in.
+func InChannel(text string, blocks ...Block) Message {
+	return Message{ResponseType: "in_channel", Text: text, Blocks: blocks}
+}
+
+// Post sends msg to a slash command's response_url.
+func Post(ctx context.Context, client *http.Client, responseURL string, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, responseURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return nil
+}
+
+func truncate(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max-1]) + "…"
+}
@@ -0,0 +1,104 @@
+// Package webhook defines the events Noker sends to registered webhooks, and how
+// they are queued and signed. Sending is done by queue.WebhookDispatcher.
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/pedy4000/noker/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EventMeetingProcessed         = "meeting.processed"
+	EventMeetingFailed            = "meeting.failed"
+	EventOpportunityCreated       = "opportunity.created"
+	EventOpportunityEvidenceAdded = "opportunity.evidence_added"
+	EventThemeCreated             = "theme.created"
+
+	// AllEvents subscribes a webhook to every event, including ones added later.
+	AllEvents = "*"
+)
+
+// Events lists every event a webhook can subscribe to.
+var Events = []string{
+	EventMeetingProcessed,
+	EventMeetingFailed,
+	EventOpportunityCreated,
+	EventOpportunityEvidenceAdded,
+	EventThemeCreated,
+}
+
+// Headers sent with every delivery.
+const (
+	HeaderEvent     = "X-Noker-Event"
+	HeaderDelivery  = "X-Noker-Delivery"
+	HeaderTimestamp = "X-Noker-Timestamp"
+	HeaderSignature = "X-Noker-Signature"
+)
+
+// Payload is the body of every delivery.
+type Payload struct {
+	Event      string    `json:"event"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
+
+type MeetingData struct {
+	MeetingID     uuid.UUID `json:"meeting_id"`
+	Title         string    `json:"title,omitempty"`
+	Status        string    `json:"status"`                  // done, failed or dead
+	Opportunities int       `json:"opportunities,omitempty"` // extracted items saved
+	Attempts      int       `json:"attempts,omitempty"`
+	Error         string    `json:"error,omitempty"`
+}
+
+type OpportunityData struct {
+	OpportunityID uuid.UUID  `json:"opportunity_id"`
+	MeetingID     *uuid.UUID `json:"meeting_id,omitempty"` // the meeting it was found in, if any
+	UserSegment   string     `json:"user_segment,omitempty"`
+	Struggle      string     `json:"struggle,omitempty"`
+	WhyItMatters  string     `json:"why_it_matters,omitempty"`
+	Workaround    string     `json:"workaround,omitempty"`
+	ThemeID       *uuid.UUID `json:"theme_id,omitempty"`
+	ParentID      *uuid.UUID `json:"parent_id,omitempty"`
+	Quotes        []string   `json:"quotes,omitempty"`
+}
+
+type ThemeData struct {
+	ThemeID uuid.UUID `json:"theme_id"`
+	Name    string    `json:"name"`
+}
+
+// Enqueue queues event for every webhook subscribed to it. Call it with the
+// transaction that makes the change, so the event is sent if and only if it commits.
+func Enqueue(ctx context.Context, q *repository.Queries, event string, data any) error {
+	payload, err := json.Marshal(Payload{Event: event, OccurredAt: time.Now().UTC(), Data: data})
+	if err != nil {
+		return err
+	}
+
+	_, err = q.EnqueueWebhookDeliveries(ctx, repository.EnqueueWebhookDeliveriesParams{
+		Event:   event,
+		Payload: payload,
+	})
+	return err
+}
+
+// Sign returns the X-Noker-Signature value for body sent at timestamp (Unix seconds):
+// "sha256=" and the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with secret.
+// Receivers should recompute it and reject old timestamps to stop replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
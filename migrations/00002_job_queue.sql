@@ -0,0 +1,22 @@
+-- migrations/00002_job_queue.sql
+-- +goose Up
+-- Durable queue used by queue.type = "postgres".
+-- A row lives here from enqueue until a worker finishes the meeting.
+CREATE TABLE jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    meeting_id UUID NOT NULL UNIQUE REFERENCES meetings(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running')),
+    run_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_by TEXT,
+    locked_at TIMESTAMPTZ,
+    -- Set when the meeting is enqueued again while its job is running, so the job
+    -- is queued for another run when it finishes instead of being deleted.
+    rerun BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_jobs_claim ON jobs(run_at) WHERE status = 'queued';
+
+-- +goose Down
+DROP TABLE jobs;
@@ -0,0 +1,20 @@
+-- migrations/00003_meeting_retries.sql
+-- +goose Up
+ALTER TABLE meetings DROP CONSTRAINT meetings_processing_status_check;
+ALTER TABLE meetings ADD CONSTRAINT meetings_processing_status_check
+    CHECK (processing_status IN ('pending', 'processing', 'retrying', 'done', 'failed', 'dead'));
+
+ALTER TABLE meetings ADD COLUMN processing_attempts INT NOT NULL DEFAULT 0;
+-- [{"attempt": 1, "error": "...", "at": "..."}, ...]
+ALTER TABLE meetings ADD COLUMN error_history JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE meetings ADD COLUMN next_attempt_at TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE meetings DROP COLUMN next_attempt_at;
+ALTER TABLE meetings DROP COLUMN error_history;
+ALTER TABLE meetings DROP COLUMN processing_attempts;
+
+UPDATE meetings SET processing_status = 'failed' WHERE processing_status IN ('retrying', 'dead');
+ALTER TABLE meetings DROP CONSTRAINT meetings_processing_status_check;
+ALTER TABLE meetings ADD CONSTRAINT meetings_processing_status_check
+    CHECK (processing_status IN ('pending', 'processing', 'done', 'failed'));
@@ -0,0 +1,12 @@
+-- migrations/00004_processing_lease.sql
+-- +goose Up
+-- A worker owns a "processing" meeting only until its lease expires.
+-- After that the recovery sweep hands the meeting to another worker.
+ALTER TABLE meetings ADD COLUMN lease_expires_at TIMESTAMPTZ;
+
+CREATE INDEX idx_meetings_unfinished ON meetings(created_at)
+    WHERE processing_status IN ('pending', 'processing', 'retrying');
+
+-- +goose Down
+DROP INDEX idx_meetings_unfinished;
+ALTER TABLE meetings DROP COLUMN lease_expires_at;
@@ -0,0 +1,15 @@
+-- migrations/00005_idempotent_evidence.sql
+-- +goose Up
+-- Replaying a meeting must not duplicate its quotes.
+DELETE FROM opportunity_evidence a
+USING opportunity_evidence b
+WHERE a.opportunity_id = b.opportunity_id
+  AND a.meeting_id = b.meeting_id
+  AND md5(a.quote) = md5(b.quote)
+  AND (a.created_at, a.id) > (b.created_at, b.id);
+
+CREATE UNIQUE INDEX idx_evidence_unique_quote
+    ON opportunity_evidence(opportunity_id, meeting_id, md5(quote));
+
+-- +goose Down
+DROP INDEX idx_evidence_unique_quote;
@@ -0,0 +1,7 @@
+-- migrations/00006_validation_issues.sql
+-- +goose Up
+-- Problems found in the model's output for the latest attempt, and what was done about them.
+ALTER TABLE meetings ADD COLUMN validation_issues JSONB NOT NULL DEFAULT '[]';
+
+-- +goose Down
+ALTER TABLE meetings DROP COLUMN validation_issues;
@@ -0,0 +1,21 @@
+-- migrations/00007_opportunity_embeddings.sql
+-- +goose Up
+-- Needs the pgvector extension (the pgvector/pgvector image ships it).
+CREATE EXTENSION IF NOT EXISTS vector;
+
+-- One embedding per opportunity, of "struggle | theme". content_hash and model tell
+-- the worker when the text or the embedding model changed and it must be embedded again.
+CREATE TABLE opportunity_embeddings (
+    opportunity_id UUID PRIMARY KEY REFERENCES opportunities(id) ON DELETE CASCADE,
+    embedding vector(1536) NOT NULL,
+    model TEXT NOT NULL,
+    content_hash TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_opportunity_embeddings_hnsw ON opportunity_embeddings
+    USING hnsw (embedding vector_cosine_ops);
+
+-- +goose Down
+DROP TABLE opportunity_embeddings;
+DROP EXTENSION IF EXISTS vector;
@@ -0,0 +1,13 @@
+-- migrations/00008_evidence_chunks.sql
+-- +goose Up
+-- Long notes are extracted in chunks; each quote remembers which chunk it came from
+-- and where the quote starts in the raw notes (in characters).
+-- Evidence from before chunking came from the whole notes, i.e. chunk 0 at offset 0.
+ALTER TABLE opportunity_evidence
+    ADD COLUMN chunk_index INT NOT NULL DEFAULT 0,
+    ADD COLUMN chunk_offset INT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE opportunity_evidence
+    DROP COLUMN chunk_index,
+    DROP COLUMN chunk_offset;
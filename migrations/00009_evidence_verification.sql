@@ -0,0 +1,22 @@
+-- migrations/00009_evidence_verification.sql
+-- +goose Up
+-- Where the quote was found in the meeting's raw notes (character offsets, end
+-- exclusive). NULL when it wasn't found: the model paraphrased or invented it.
+ALTER TABLE opportunity_evidence
+    ADD COLUMN verified BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN quote_start INT,
+    ADD COLUMN quote_end INT;
+
+-- Existing quotes that appear verbatim are verified; the rest stay unverified.
+UPDATE opportunity_evidence oe
+SET verified = TRUE,
+    quote_start = strpos(m.raw_notes, oe.quote) - 1,
+    quote_end = strpos(m.raw_notes, oe.quote) - 1 + length(oe.quote)
+FROM meetings m
+WHERE m.id = oe.meeting_id AND strpos(m.raw_notes, oe.quote) > 0;
+
+-- +goose Down
+ALTER TABLE opportunity_evidence
+    DROP COLUMN verified,
+    DROP COLUMN quote_start,
+    DROP COLUMN quote_end;
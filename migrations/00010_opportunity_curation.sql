@@ -0,0 +1,28 @@
+-- migrations/00010_opportunity_curation.sql
+-- +goose Up
+-- Opportunities merged away by a human keep answering at their old ID.
+-- from_id has no foreign key: that opportunity no longer exists.
+CREATE TABLE opportunity_redirects (
+    from_id UUID PRIMARY KEY,
+    to_id UUID NOT NULL REFERENCES opportunities(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_opportunity_redirects_to ON opportunity_redirects(to_id);
+
+-- Every manual change to the tree, newest last.
+CREATE TABLE audit_log (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    action TEXT NOT NULL,
+    entity_type TEXT NOT NULL,
+    entity_id UUID NOT NULL,
+    actor TEXT,
+    details JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_log_entity ON audit_log(entity_type, entity_id, created_at);
+
+-- +goose Down
+DROP TABLE audit_log;
+DROP TABLE opportunity_redirects;
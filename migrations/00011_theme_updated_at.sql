@@ -0,0 +1,7 @@
+-- migrations/00011_theme_updated_at.sql
+-- +goose Up
+ALTER TABLE themes ADD COLUMN updated_at TIMESTAMPTZ DEFAULT NOW();
+UPDATE themes SET updated_at = created_at;
+
+-- +goose Down
+ALTER TABLE themes DROP COLUMN updated_at;
@@ -0,0 +1,7 @@
+-- migrations/00012_meeting_listing.sql
+-- +goose Up
+-- Meetings are listed newest first, paginated by (created_at, id).
+CREATE INDEX idx_meetings_created ON meetings(created_at DESC, id DESC);
+
+-- +goose Down
+DROP INDEX idx_meetings_created;
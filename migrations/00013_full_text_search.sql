@@ -0,0 +1,27 @@
+-- migrations/00013_full_text_search.sql
+-- +goose Up
+-- The text search config (language) a meeting is written in. Evidence and the
+-- opportunities a meeting creates inherit it. 'simple' doesn't stem, which is also
+-- the right choice for languages Postgres has no dictionary for (e.g. Persian).
+ALTER TABLE meetings ADD COLUMN language regconfig NOT NULL DEFAULT 'simple';
+ALTER TABLE opportunities ADD COLUMN search_config regconfig NOT NULL DEFAULT 'simple';
+ALTER TABLE opportunity_evidence ADD COLUMN search_config regconfig NOT NULL DEFAULT 'simple';
+
+-- Each document is indexed in its own language and in 'simple', so exact words match
+-- whatever language the query is parsed in. The search queries repeat these expressions.
+CREATE INDEX idx_opportunities_search ON opportunities USING GIN ((
+    setweight(to_tsvector(search_config, struggle), 'A') ||
+    setweight(to_tsvector(search_config, coalesce(why_it_matters, '') || ' ' || coalesce(workaround, '')), 'B') ||
+    to_tsvector('simple', struggle || ' ' || coalesce(why_it_matters, '') || ' ' || coalesce(workaround, ''))
+));
+
+CREATE INDEX idx_evidence_search ON opportunity_evidence USING GIN ((
+    to_tsvector(search_config, quote) || to_tsvector('simple', quote)
+));
+
+-- +goose Down
+DROP INDEX idx_evidence_search;
+DROP INDEX idx_opportunities_search;
+ALTER TABLE opportunity_evidence DROP COLUMN search_config;
+ALTER TABLE opportunities DROP COLUMN search_config;
+ALTER TABLE meetings DROP COLUMN language;
@@ -0,0 +1,27 @@
+-- migrations/00014_opportunity_scoring.sql
+-- +goose Up
+-- Human estimates for RICE/ICE. Reach isn't stored: it's derived from the customers
+-- and evidence behind each opportunity. NULL means nobody has estimated it yet.
+ALTER TABLE opportunities
+    ADD COLUMN impact DOUBLE PRECISION CHECK (impact > 0),
+    ADD COLUMN confidence DOUBLE PRECISION CHECK (confidence > 0 AND confidence <= 1),
+    ADD COLUMN effort DOUBLE PRECISION CHECK (effort > 0);
+
+-- How each workspace weighs the score factors. Workspaces without a row use the defaults.
+CREATE TABLE scoring_weights (
+    workspace TEXT PRIMARY KEY,
+    reach_per_customer DOUBLE PRECISION NOT NULL DEFAULT 1,
+    reach_per_evidence DOUBLE PRECISION NOT NULL DEFAULT 0.1,
+    reach_weight DOUBLE PRECISION NOT NULL DEFAULT 1,
+    impact_weight DOUBLE PRECISION NOT NULL DEFAULT 1,
+    confidence_weight DOUBLE PRECISION NOT NULL DEFAULT 1,
+    effort_weight DOUBLE PRECISION NOT NULL DEFAULT 1,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE scoring_weights;
+ALTER TABLE opportunities
+    DROP COLUMN impact,
+    DROP COLUMN confidence,
+    DROP COLUMN effort;
@@ -0,0 +1,23 @@
+-- migrations/00015_customers.sql
+-- +goose Up
+-- Customers are read from meeting metadata on ingest (see config customers.*).
+CREATE TABLE customers (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name TEXT NOT NULL,
+    plan TEXT,
+    arr DOUBLE PRECISION, -- annual recurring revenue, as last reported
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_customers_name ON customers (LOWER(name));
+
+ALTER TABLE meetings ADD COLUMN customer_id UUID REFERENCES customers(id) ON DELETE SET NULL;
+CREATE INDEX idx_meetings_customer ON meetings(customer_id);
+
+-- Existing meetings are linked on startup by service.BackfillCustomers, which reads
+-- the configured customers.* keys rather than assuming the defaults.
+
+-- +goose Down
+ALTER TABLE meetings DROP COLUMN customer_id;
+DROP TABLE customers;
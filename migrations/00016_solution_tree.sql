@@ -0,0 +1,45 @@
+-- migrations/00016_solution_tree.sql
+-- +goose Up
+-- The rest of the Opportunity Solution Tree: desired outcomes at the root, which
+-- opportunities roll up to, and solutions with their assumption tests under each opportunity.
+CREATE TABLE outcomes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    title TEXT NOT NULL,
+    description TEXT,
+    metric TEXT, -- how progress is measured, e.g. "weekly active exporters"
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE opportunities ADD COLUMN outcome_id UUID REFERENCES outcomes(id) ON DELETE SET NULL;
+CREATE INDEX idx_opportunities_outcome ON opportunities(outcome_id);
+
+CREATE TABLE solutions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    opportunity_id UUID NOT NULL REFERENCES opportunities(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    description TEXT,
+    status TEXT NOT NULL DEFAULT 'idea' CHECK (status IN ('idea', 'exploring', 'building', 'shipped', 'discarded')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX idx_solutions_opportunity ON solutions(opportunity_id);
+
+-- Assumption tests: the riskiest assumption behind a solution and how it's being tested.
+CREATE TABLE experiments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    solution_id UUID NOT NULL REFERENCES solutions(id) ON DELETE CASCADE,
+    assumption TEXT NOT NULL,
+    method TEXT,
+    status TEXT NOT NULL DEFAULT 'planned' CHECK (status IN ('planned', 'running', 'validated', 'invalidated')),
+    result TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX idx_experiments_solution ON experiments(solution_id);
+
+-- +goose Down
+DROP TABLE experiments;
+DROP TABLE solutions;
+ALTER TABLE opportunities DROP COLUMN outcome_id;
+DROP TABLE outcomes;
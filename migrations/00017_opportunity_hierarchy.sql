@@ -0,0 +1,10 @@
+-- migrations/00017_opportunity_hierarchy.sql
+-- +goose Up
+-- Opportunities break down into smaller child opportunities. Cycles are refused by
+-- the service, which walks the ancestors before setting a parent.
+ALTER TABLE opportunities ADD COLUMN parent_id UUID REFERENCES opportunities(id) ON DELETE SET NULL;
+ALTER TABLE opportunities ADD CONSTRAINT opportunities_parent_not_self CHECK (parent_id <> id);
+CREATE INDEX idx_opportunities_parent ON opportunities(parent_id);
+
+-- +goose Down
+ALTER TABLE opportunities DROP COLUMN parent_id;
@@ -0,0 +1,13 @@
+-- migrations/00019_slack.sql
+-- +goose Up
+-- Meetings added with the /ost add slash command. The extraction result is posted
+-- back to the command's response_url, which is cleared once used.
+ALTER TABLE meetings DROP CONSTRAINT meetings_source_check;
+ALTER TABLE meetings ADD CONSTRAINT meetings_source_check CHECK (source IN ('notion', 'file', 'manual', 'slack'));
+ALTER TABLE meetings ADD COLUMN slack_response_url TEXT;
+
+-- +goose Down
+ALTER TABLE meetings DROP COLUMN slack_response_url;
+UPDATE meetings SET source = 'manual' WHERE source = 'slack';
+ALTER TABLE meetings DROP CONSTRAINT meetings_source_check;
+ALTER TABLE meetings ADD CONSTRAINT meetings_source_check CHECK (source IN ('notion', 'file', 'manual'));
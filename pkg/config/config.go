@@ -29,13 +29,83 @@ type Config struct {
 		Model       string  `yaml:"model" env-default:"gpt-4o-mini"`
 		Temperature float64 `yaml:"temperature" env-default:"0.3"`
 		APIKey      string  `yaml:"api_key"`
+		TimeoutSec  int     `yaml:"timeout_sec" env-default:"90"`
+		ChunkSize   int     `yaml:"chunk_size" env-default:"12000"` // characters of notes per prompt
+		// What to do with evidence quotes not found in the notes: flag (keep, unverified) | drop
+		UnverifiedQuotes string `yaml:"unverified_quotes" env-default:"flag"`
+		OpenAI           struct {
+			BaseURL string `yaml:"base_url" env-default:"https://api.openai.com/v1"`
+		} `yaml:"openai"`
+		Anthropic struct {
+			APIKey    string `yaml:"api_key"`
+			BaseURL   string `yaml:"base_url" env-default:"https://api.anthropic.com"`
+			Version   string `yaml:"version" env-default:"2023-06-01"`
+			MaxTokens int    `yaml:"max_tokens" env-default:"4096"`
+		} `yaml:"anthropic"`
+		// Any server speaking the OpenAI chat completions API: Ollama, vLLM, Azure OpenAI...
+		Compatible struct {
+			BaseURL    string `yaml:"base_url" env-default:"http://localhost:11434/v1"`
+			APIKey     string `yaml:"api_key"`
+			AuthHeader string `yaml:"auth_header" env-default:"Authorization"` // Azure uses "api-key"
+			APIVersion string `yaml:"api_version"`                             // Azure only, sent as ?api-version=
+			// Some local servers reject response_format; the prompt still asks for JSON.
+			SkipResponseFormat bool `yaml:"skip_response_format"`
+		} `yaml:"compatible"`
+		// Offline provider for tests and demos, see ai.FakeProvider.
+		Fake struct {
+			RulesFile   string `yaml:"rules_file"`
+			FixturesDir string `yaml:"fixtures_dir"`
+		} `yaml:"fake"`
+		// Embeddings narrow the existing opportunities sent to the model down to the
+		// nearest TopK, see ai.Embedder. Dimensions must match the opportunity_embeddings column.
+		Embedding struct {
+			Disabled      bool   `yaml:"disabled"`
+			Provider      string `yaml:"provider"` // openai | compatible | fake; empty uses ai.provider
+			Model         string `yaml:"model" env-default:"text-embedding-3-small"`
+			Dimensions    int    `yaml:"dimensions" env-default:"1536"`
+			TopK          int    `yaml:"top_k" env-default:"50"`
+			MaxInputChars int    `yaml:"max_input_chars" env-default:"24000"`
+		} `yaml:"embedding"`
+		// Record/replay of AI calls, see ai.CassetteProvider.
+		Cassette struct {
+			Mode string `yaml:"mode" env:"AI_CASSETTE_MODE"` // record | replay | passthrough; empty disables
+			Dir  string `yaml:"dir" env-default:"cassettes"`
+		} `yaml:"cassette"`
 	} `yaml:"ai"`
+	// Meeting metadata keys customers are read from on ingest.
+	Customers struct {
+		NameKeys []string `yaml:"name_keys" env-default:"customer,account,company"` // the first one present wins
+		PlanKey  string   `yaml:"plan_key" env-default:"plan"`
+		ARRKey   string   `yaml:"arr_key" env-default:"arr"`
+	} `yaml:"customers"`
 	Queue struct {
-		WorkerCount    int    `yaml:"worker_count" env-default:"1"`
-		PollIntervalMs int    `yaml:"poll_interval_ms" env-default:"1000"`
-		Type           string `yaml:"type" env-default:"inmemory"`
-		BufferSize     int    `yaml:"buffer_size" env-default:"100"`
+		WorkerCount        int    `yaml:"worker_count" env-default:"1"`
+		PollIntervalMs     int    `yaml:"poll_interval_ms" env-default:"1000"`
+		Type               string `yaml:"type" env-default:"inmemory"`
+		BufferSize         int    `yaml:"buffer_size" env-default:"100"`
+		MaxAttempts        int    `yaml:"max_attempts" env-default:"5"`
+		RetryBaseMs        int    `yaml:"retry_base_ms" env-default:"2000"`
+		RetryMaxMs         int    `yaml:"retry_max_ms" env-default:"300000"`
+		LeaseSeconds       int    `yaml:"lease_seconds" env-default:"300"`
+		ShutdownTimeoutSec int    `yaml:"shutdown_timeout_sec" env-default:"30"`
 	} `yaml:"queue"`
+	// Outbound webhooks, sent by queue.WebhookDispatcher.
+	Webhooks struct {
+		Enabled        bool `yaml:"enabled" env-default:"true"`
+		WorkerCount    int  `yaml:"worker_count" env-default:"2"`
+		PollIntervalMs int  `yaml:"poll_interval_ms" env-default:"1000"`
+		BatchSize      int  `yaml:"batch_size" env-default:"20"`
+		TimeoutSec     int  `yaml:"timeout_sec" env-default:"10"`
+		MaxAttempts    int  `yaml:"max_attempts" env-default:"8"` // then the delivery is marked "failed"
+		RetryBaseMs    int  `yaml:"retry_base_ms" env-default:"10000"`
+		RetryMaxMs     int  `yaml:"retry_max_ms" env-default:"3600000"`
+	} `yaml:"webhooks"`
+	// Slack slash commands (POST /api/slack/commands), see api.SlackSlashCommand.
+	Slack struct {
+		SigningSecret string `yaml:"signing_secret" env:"SLACK_SIGNING_SECRET"` // empty disables the endpoint
+		MaxSkewSec    int    `yaml:"max_skew_sec" env-default:"300"`            // older requests are rejected as replays
+		TimeoutSec    int    `yaml:"timeout_sec" env-default:"10"`              // for posting results to response_url
+	} `yaml:"slack"`
 }
 
 func Load(path ...string) (*Config, error) {
@@ -54,6 +124,9 @@ func Load(path ...string) (*Config, error) {
 	if cfg.AI.APIKey == "" {
 		cfg.AI.APIKey = os.Getenv("OPENAI_API_KEY")
 	}
+	if cfg.AI.Anthropic.APIKey == "" {
+		cfg.AI.Anthropic.APIKey = os.Getenv("ANTHROPIC_API_KEY")
+	}
 
 	return &cfg, nil
 }
@@ -28,3 +28,36 @@ func FormatTime(nt sql.NullTime, fallback string) string {
 func FormatTheme(theme string) string {
 	return strings.ToLower(strings.ReplaceAll(theme, " ", "-"))
 }
+
+// NullInt32ToPtr converts sql.NullInt32 to *int (invalid → nil)
+func NullInt32ToPtr(n sql.NullInt32) *int {
+	if !n.Valid {
+		return nil
+	}
+	v := int(n.Int32)
+	return &v
+}
+
+// NullFloat64ToPtr converts sql.NullFloat64 to *float64 (invalid → nil)
+func NullFloat64ToPtr(n sql.NullFloat64) *float64 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Float64
+}
+
+// PtrToNullFloat64 converts *float64 to sql.NullFloat64 (nil → invalid)
+func PtrToNullFloat64(f *float64) sql.NullFloat64 {
+	if f == nil {
+		return sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: *f, Valid: true}
+}
+
+// PtrToNullString converts *string to sql.NullString (nil → invalid, "" stays valid)
+func PtrToNullString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
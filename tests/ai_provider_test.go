@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pedy4000/noker/internal/ai"
+	"github.com/pedy4000/noker/internal/models"
+	"github.com/pedy4000/noker/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fakeExtraction = `{"results":[{"type":"new","user_segment":"Finance teams","struggle":"CSV export corrupts non-Latin text","theme":"export-issues","evidence_quotes":[{"quote":"Persian text becomes garbage"}]}]}`
+
+var testMeeting = &models.Meeting{
+	Title:    "Acme Corp – Export Hell",
+	RawNotes: "Every Monday we spend 3 hours cleaning exported CSVs. Persian text becomes garbage.",
+	Source:   models.SourceManual,
+}
+
+func TestCompatibleProviderAgainstLocalServer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/openai/deployments/ost/chat/completions", r.URL.Path)
+		assert.Equal(t, "2024-06-01", r.URL.Query().Get("api-version"))
+		assert.Equal(t, "azure-key", r.Header.Get("api-key"))
+
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "gpt-4o-mini", body["model"])
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"content": fakeExtraction}}},
+		})
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	cfg.AI.Model = "gpt-4o-mini"
+	cfg.AI.TimeoutSec = 5
+	cfg.AI.Compatible.BaseURL = srv.URL + "/openai/deployments/ost"
+	cfg.AI.Compatible.APIKey = "azure-key"
+	cfg.AI.Compatible.AuthHeader = "api-key"
+	cfg.AI.Compatible.APIVersion = "2024-06-01"
+
+	results, err := ai.NewCompatibleProvider(cfg).Extract(context.Background(), testMeeting, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "CSV export corrupts non-Latin text", results[0].Struggle)
+}
+
+func TestAnthropicProviderAgainstLocalServer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/messages", r.URL.Path)
+		assert.Equal(t, "ant-key", r.Header.Get("x-api-key"))
+		assert.Equal(t, "2023-06-01", r.Header.Get("anthropic-version"))
+
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, ai.SystemPrompt, body["system"])
+
+		// Models without a JSON mode like to wrap their answer in a code fence.
+		json.NewEncoder(w).Encode(map[string]any{
+			"content":     []map[string]string{{"type": "text", "text": "```json\n" + fakeExtraction + "\n```"}},
+			"stop_reason": "end_turn",
+		})
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	cfg.AI.Model = "claude-sonnet"
+	cfg.AI.TimeoutSec = 5
+	cfg.AI.Anthropic.BaseURL = srv.URL
+	cfg.AI.Anthropic.APIKey = "ant-key"
+	cfg.AI.Anthropic.Version = "2023-06-01"
+	cfg.AI.Anthropic.MaxTokens = 1024
+
+	results, err := ai.NewAnthropicProvider(cfg).Extract(context.Background(), testMeeting, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "Persian text becomes garbage", results[0].EvidenceQuotes[0].Quote)
+}
+
+func TestProviderRateLimitIsRetryable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":{"message":"rate limited"}}`))
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	cfg.AI.TimeoutSec = 5
+	cfg.AI.OpenAI.BaseURL = srv.URL
+
+	_, err := ai.NewOpenAIProvider(cfg).Extract(context.Background(), testMeeting, nil)
+	require.Error(t, err)
+	assert.True(t, ai.IsRetryable(err))
+}
@@ -0,0 +1,142 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pedy4000/noker/internal/ai"
+	"github.com/pedy4000/noker/internal/models"
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingProvider stands in for a real provider and counts how often it's called.
+type countingProvider struct {
+	calls   int
+	results []models.ExtractedOpportunity
+}
+
+func (p *countingProvider) Extract(ctx context.Context, meeting *models.Meeting, opps []repository.ListAllOpportunitiesForDeduplicationRow) ([]models.ExtractedOpportunity, error) {
+	p.calls++
+	return p.results, nil
+}
+
+func TestCassetteRecordThenReplay(t *testing.T) {
+	dir := t.TempDir()
+	meeting := &models.Meeting{Title: "Acme Follow-up", RawNotes: "Fonts broken again.", Source: models.SourceManual}
+
+	recordedCandidates := []repository.ListAllOpportunitiesForDeduplicationRow{
+		{OpportunityID: "11111111-1111-1111-1111-111111111111", Struggle: "CSV export corrupts data", ThemeName: "export-issues"},
+	}
+	real := &countingProvider{results: []models.ExtractedOpportunity{{
+		Type:                  "match",
+		ExistingOpportunityID: recordedCandidates[0].OpportunityID,
+		EvidenceQuotes:        []models.EvidenceQuote{{Quote: "Fonts broken again"}},
+	}}}
+
+	cfg := &config.Config{}
+	cfg.AI.Cassette.Dir = dir
+	cfg.AI.Cassette.Mode = ai.CassetteRecord
+	_, err := ai.NewCassetteProvider(real, cfg).Extract(context.Background(), meeting, recordedCandidates)
+	require.NoError(t, err)
+	assert.Equal(t, 1, real.calls)
+
+	// Fresh database: same opportunity, new ID.
+	cfg.AI.Cassette.Mode = ai.CassetteReplay
+	currentCandidates := []repository.ListAllOpportunitiesForDeduplicationRow{
+		{OpportunityID: "22222222-2222-2222-2222-222222222222", Struggle: "CSV export corrupts data", ThemeName: "export-issues"},
+	}
+	replayed, err := ai.NewCassetteProvider(real, cfg).Extract(context.Background(), meeting, currentCandidates)
+	require.NoError(t, err)
+	assert.Equal(t, 1, real.calls, "replay must not call the wrapped provider")
+	require.Len(t, replayed, 1)
+	assert.Equal(t, currentCandidates[0].OpportunityID, replayed[0].ExistingOpportunityID)
+
+	// Different notes were never recorded.
+	_, err = ai.NewCassetteProvider(real, cfg).Extract(context.Background(),
+		&models.Meeting{Title: "Unknown", RawNotes: "Never seen"}, nil)
+	assert.ErrorIs(t, err, ai.ErrCassetteMiss)
+}
+
+// A cassette recorded with another model or prompt is a miss, not a stale answer.
+func TestCassetteReplayMissesOnModelChange(t *testing.T) {
+	meeting := &models.Meeting{Title: "Acme Follow-up", RawNotes: "Fonts broken again.", Source: models.SourceManual}
+	real := &countingProvider{results: []models.ExtractedOpportunity{{Type: "new", Struggle: "Fonts break"}}}
+
+	cfg := &config.Config{}
+	cfg.AI.Provider = "openai"
+	cfg.AI.Model = "gpt-4o"
+	cfg.AI.Cassette.Dir = t.TempDir()
+	cfg.AI.Cassette.Mode = ai.CassetteRecord
+	_, err := ai.NewCassetteProvider(real, cfg).Extract(context.Background(), meeting, nil)
+	require.NoError(t, err)
+
+	cfg.AI.Cassette.Mode = ai.CassetteReplay
+	_, err = ai.NewCassetteProvider(real, cfg).Extract(context.Background(), meeting, nil)
+	require.NoError(t, err)
+
+	cfg.AI.Model = "gpt-4o-mini"
+	_, err = ai.NewCassetteProvider(real, cfg).Extract(context.Background(), meeting, nil)
+	assert.ErrorIs(t, err, ai.ErrCassetteMiss)
+	assert.Equal(t, 1, real.calls)
+}
+
+// The raw exchange is recorded, a malformed answer included, and replay runs it
+// through the provider again without a network.
+func TestCassetteRecordsRawExchanges(t *testing.T) {
+	answer := "not json"
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"content": answer}}},
+		})
+	}))
+
+	meeting := &models.Meeting{Title: "Acme", RawNotes: "CSV export breaks Persian text.", Source: models.SourceManual}
+	cfg := &config.Config{}
+	cfg.AI.Provider = "compatible"
+	cfg.AI.Model = "local-model"
+	cfg.AI.TimeoutSec = 5
+	cfg.AI.Compatible.BaseURL = srv.URL
+	cfg.AI.Compatible.AuthHeader = "Authorization"
+	cfg.AI.Compatible.APIKey = "secret-key"
+	cfg.AI.Cassette.Dir = t.TempDir()
+	extract := func() ([]models.ExtractedOpportunity, error) {
+		return ai.NewCassetteProvider(ai.NewCompatibleProvider(cfg), cfg).Extract(context.Background(), meeting, nil)
+	}
+
+	cfg.AI.Cassette.Mode = ai.CassetteRecord
+	_, err := extract()
+	require.ErrorIs(t, err, ai.ErrMalformedOutput)
+
+	files, err := filepath.Glob(filepath.Join(cfg.AI.Cassette.Dir, "*.json"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "not json", "the raw response is kept")
+	assert.Contains(t, string(data), "CSV export breaks Persian text", "so is the raw request")
+	assert.NotContains(t, string(data), "secret-key")
+
+	answer = `{"results": [{"type": "new", "user_segment": "Finance", "struggle": "CSV export corrupts text"}]}`
+	_, err = extract()
+	require.NoError(t, err)
+	srv.Close()
+
+	cfg.AI.Cassette.Mode = ai.CassetteReplay
+	results, err := extract()
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "CSV export corrupts text", results[0].Struggle)
+	assert.EqualValues(t, 2, calls.Load(), "replay never reaches the server")
+}
@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pedy4000/noker/internal/ai"
+	"github.com/pedy4000/noker/internal/models"
+	"github.com/pedy4000/noker/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitNotesPrefersSpeakerTurns(t *testing.T) {
+	notes := "Sara: We export CSVs every Monday.\nThe Persian text breaks.\nAli: Search is slow.\nIt takes ages to find an invoice.\n"
+
+	chunks := ai.SplitNotes(notes, 60)
+	require.Len(t, chunks, 2)
+	assert.True(t, strings.HasPrefix(chunks[1].Text, "Ali:"))
+
+	// Chunks cover the notes exactly, so offsets point back into them.
+	var rebuilt string
+	for i, c := range chunks {
+		assert.Equal(t, i, c.Index)
+		assert.Equal(t, len([]rune(rebuilt)), c.Offset)
+		rebuilt += c.Text
+	}
+	assert.Equal(t, notes, rebuilt)
+}
+
+func TestSplitNotesFallsBackToSpaces(t *testing.T) {
+	notes := strings.Repeat("word ", 50) // one long line, no boundaries
+
+	chunks := ai.SplitNotes(notes, 40)
+	require.Greater(t, len(chunks), 1)
+	for _, c := range chunks {
+		assert.LessOrEqual(t, len([]rune(c.Text)), 40)
+		assert.False(t, strings.HasPrefix(c.Text, "ord"), "cut inside a word")
+	}
+}
+
+func TestChunkedExtractionMergesAcrossChunks(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.AI.Provider = "fake"
+	cfg.AI.Fake.RulesFile = "testdata/fake_rules.json"
+	cfg.AI.ChunkSize = 80
+
+	notes := "Sara: Every Monday we clean exported CSVs by hand.\n\n" +
+		"Ali: Unrelated small talk about the weather and lunch plans today.\n\n" +
+		"Sara: One more thing. The CSV export also breaks Persian text."
+
+	results, err := ai.NewExtractor(cfg).Extract(context.Background(), &models.Meeting{Title: "Acme", RawNotes: notes}, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 1, "the CSV pain from both chunks is one opportunity")
+
+	quotes := results[0].EvidenceQuotes
+	require.Len(t, quotes, 2)
+	assert.Equal(t, 0, quotes[0].ChunkIndex)
+	assert.Equal(t, 2, quotes[1].ChunkIndex)
+	// Offsets point at the quote in the full notes, not at the start of its chunk.
+	assert.Equal(t, strings.Index(notes, quotes[0].Quote), quotes[0].ChunkOffset)
+	assert.Equal(t, strings.Index(notes, quotes[1].Quote), quotes[1].ChunkOffset)
+	assert.Greater(t, quotes[1].ChunkOffset, strings.Index(notes, "Sara: One more"))
+}
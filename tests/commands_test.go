@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pedy4000/noker/internal/api"
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/internal/slack"
+	"github.com/pedy4000/noker/pkg/config"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runCmd sends text to GET /api/cmd and returns the plain text answer.
+func runCmd(t *testing.T, router http.Handler, text string) string {
+	req := httptest.NewRequest("GET", "/api/cmd?text="+url.QueryEscape(text), nil)
+	req.Header.Set("X-API-Key", "noker-dev-key-2025")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	return w.Body.String()
+}
+
+// postCmd sends text to POST /api/cmd, which also runs commands that change data.
+func postCmd(t *testing.T, router http.Handler, text string) string {
+	req := httptest.NewRequest("POST", "/api/cmd", strings.NewReader(url.Values{"text": {text}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-API-Key", "noker-dev-key-2025")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	return w.Body.String()
+}
+
+func TestCommandHelpAndUsage(t *testing.T) {
+	cfg, _ := config.Load("../config.yaml")
+	router := api.NewRouter(api.NewHandler(repository.NewStore(nil), nil, cfg), cfg)
+
+	list := runCmd(t, router, "")
+	for _, usage := range []string{"search <query>", "theme <name> [limit]", "opp <opportunity-id>",
+		"status <meeting-id>", "top <n> [since <date>]", "reprocess <meeting-id>"} {
+		assert.Contains(t, list, "`"+usage+"`")
+	}
+	assert.Equal(t, list, runCmd(t, router, "help"))
+
+	// Every command has its own help, also as "<command> help".
+	help := runCmd(t, router, "help top")
+	assert.True(t, strings.HasPrefix(help, "`top <n> [since <date>]`"), help)
+	assert.Contains(t, help, "`top 10 since 2025-06-01`")
+	assert.Equal(t, help, runCmd(t, router, "TOP help"))
+
+	assert.True(t, strings.HasPrefix(runCmd(t, router, "frobnicate"), "Unknown command `frobnicate`."))
+
+	// Arguments that don't fit answer with the command's usage, before touching the database.
+	for text, problem := range map[string]string{
+		"search":                        "What should I search for?",
+		"theme export 0":                "The limit must be between 1 and 50.",
+		"opp 42":                        "`42` is not a valid opportunity ID.",
+		"status":                        "Which meeting?",
+		"top many":                      "N must be a number between 1 and 50.",
+		"top 5 since tomorrow":          "`tomorrow` is not a date.",
+		"top 5 until 2w":                "Expected `since <date>` after the number.",
+		"reprocess " + uuid.NewString(): "`reprocess` changes data, send it with `POST /api/cmd`.",
+		"add some notes here":           "`add` only works from the Slack slash command.",
+	} {
+		answer := runCmd(t, router, text)
+		assert.True(t, strings.HasPrefix(answer, problem), "%s: %s", text, answer)
+		assert.Contains(t, answer, "Usage: `", text)
+	}
+
+	reprocess := postCmd(t, router, "reprocess not-an-id")
+	assert.True(t, strings.HasPrefix(reprocess, "`not-an-id` is not a valid meeting ID."), reprocess)
+
+	// The same reply as Block Kit.
+	req := httptest.NewRequest("GET", "/api/cmd?format=blocks&text="+url.QueryEscape("help theme"), nil)
+	req.Header.Set("X-API-Key", "noker-dev-key-2025")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var msg slack.Message
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &msg))
+	require.Len(t, msg.Blocks, 3)
+	assert.Equal(t, "section", msg.Blocks[0].Type)
+	assert.Contains(t, msg.Blocks[0].Text.Text, "*`theme <name> [limit]`*")
+	assert.Equal(t, "context", msg.Blocks[2].Type)
+	assert.Contains(t, msg.Blocks[2].Elements[0].Text, "`theme export-issues 3`")
+}
+
+func TestCommands(t *testing.T) {
+	cfg := loadOfflineConfig(t)
+	env := newTestRouterWith(t, cfg)
+	env.start(t)
+
+	_, err := env.db.Exec("TRUNCATE TABLE opportunity_evidence, meetings, opportunities, themes CASCADE")
+	require.NoError(t, err)
+
+	resp := postMeeting(t, env.router, map[string]any{
+		"title": "Finance call",
+		"notes": "CSV export fails, dates are wrong.",
+	})
+	require.Equal(t, http.StatusAccepted, resp.Code)
+	var created api.CreateMeetingResponse
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+	meetingID := created.MeetingID.String()
+
+	require.Eventually(t, func() bool {
+		return strings.HasPrefix(runCmd(t, env.router, "status "+meetingID), "Finance call: done")
+	}, 10*time.Second, 200*time.Millisecond)
+
+	var oppID string
+	require.NoError(t, env.db.QueryRow("SELECT id FROM opportunities").Scan(&oppID))
+
+	assert.Contains(t, runCmd(t, env.router, "status "+meetingID), "*Found:* 1 opportunity")
+
+	search := runCmd(t, env.router, "search csv")
+	assert.True(t, strings.HasPrefix(search, `1 opportunities match "csv"`), search)
+	assert.Contains(t, search, "CSV export corrupts data")
+	assert.Contains(t, search, oppID)
+	assert.True(t, strings.HasPrefix(runCmd(t, env.router, `search "no such thing"`), `Nothing matches "no such thing".`))
+
+	theme := runCmd(t, env.router, "theme export-issues 5")
+	assert.True(t, strings.HasPrefix(theme, "Top opportunities in export-issues"), theme)
+	assert.Contains(t, theme, "1 quote")
+	assert.True(t, strings.HasPrefix(runCmd(t, env.router, "theme nothing here"), `No opportunities in theme "nothing here".`))
+
+	opp := runCmd(t, env.router, "opp "+oppID)
+	assert.True(t, strings.HasPrefix(opp, "CSV export corrupts data"), opp)
+	assert.Contains(t, opp, "*Segment:* Finance teams")
+	assert.True(t, strings.HasPrefix(runCmd(t, env.router, "opp 00000000-0000-0000-0000-000000000000"), "Opportunity not found."))
+
+	top := runCmd(t, env.router, "top 3 since 1d")
+	assert.True(t, strings.HasPrefix(top, "Top 1 opportunities since"), top)
+	assert.Contains(t, top, "1 quote from 1 meeting")
+	future := time.Now().AddDate(0, 0, 2).Format("2006-01-02")
+	assert.True(t, strings.HasPrefix(runCmd(t, env.router, "top 3 since "+future), "No new evidence since"))
+
+	assert.True(t, strings.HasPrefix(runCmd(t, env.router, "new"), "Found 1 new opportunities in the last 24h"))
+	assert.Contains(t, runCmd(t, env.router, "themes_this_week"), "*export-issues* — 1 opportunity")
+
+	reprocess := postCmd(t, env.router, "reprocess "+meetingID)
+	assert.True(t, strings.HasPrefix(reprocess, "Meeting queued for extraction again."), reprocess)
+	assert.Contains(t, reprocess, "Removed 1 opportunity only this meeting had found.")
+	require.Eventually(t, func() bool {
+		return strings.HasPrefix(runCmd(t, env.router, "status "+meetingID), "Finance call: done")
+	}, 10*time.Second, 200*time.Millisecond)
+}
@@ -0,0 +1,226 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pedy4000/noker/internal/models"
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// A merge moves evidence and leaves a redirect; a split carves selected quotes back out.
+// Both land in the audit log.
+func TestMergeAndSplitOpportunities(t *testing.T) {
+	env := newTestRouter(t)
+	svc := service.NewOpportunityService(env.queries)
+	ctx := context.Background()
+
+	_, err := env.db.Exec("TRUNCATE TABLE opportunity_evidence, meetings, opportunities, themes, opportunity_redirects, audit_log CASCADE")
+	require.NoError(t, err)
+
+	meeting, err := env.queries.CreateMeeting(ctx, repository.CreateMeetingParams{
+		Title:    "Acme Corp – Export Hell",
+		RawNotes: "CSV export breaks Persian text. Exported dates are wrong. Excel opens it garbled.",
+		Source:   "manual",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.ProcessExtractedOpportunities(ctx, meeting.ID, []models.ExtractedOpportunity{
+		{Type: "new", UserSegment: "Finance", Struggle: "CSV export corrupts text", Theme: "export",
+			EvidenceQuotes: []models.EvidenceQuote{{Quote: "CSV export breaks Persian text"}, {Quote: "Excel opens it garbled"}}},
+		{Type: "new", UserSegment: "Finance", Struggle: "Exported dates are wrong", Theme: "export",
+			EvidenceQuotes: []models.EvidenceQuote{{Quote: "Exported dates are wrong"}}},
+	}))
+
+	var target, source uuid.UUID
+	env.db.QueryRow("SELECT id FROM opportunities WHERE struggle = 'CSV export corrupts text'").Scan(&target)
+	env.db.QueryRow("SELECT id FROM opportunities WHERE struggle = 'Exported dates are wrong'").Scan(&source)
+
+	merged, err := svc.MergeOpportunities(ctx, target, []uuid.UUID{source}, "pm@acme.test")
+	require.NoError(t, err)
+	assert.Equal(t, 1, merged.MovedEvidence)
+
+	resolved, err := svc.ResolveOpportunityID(ctx, source)
+	require.NoError(t, err)
+	assert.Equal(t, target, resolved)
+
+	_, err = svc.MergeOpportunities(ctx, target, []uuid.UUID{target}, "")
+	assert.ErrorIs(t, err, service.ErrInvalidCuration)
+
+	var datesQuote uuid.UUID
+	env.db.QueryRow("SELECT id FROM opportunity_evidence WHERE quote = 'Exported dates are wrong'").Scan(&datesQuote)
+
+	// The split-off opportunity keeps the source's outcome and search language.
+	var outcome uuid.UUID
+	require.NoError(t, env.db.QueryRow("INSERT INTO outcomes (title) VALUES ('Close the month faster') RETURNING id").Scan(&outcome))
+	_, err = env.db.Exec("UPDATE opportunities SET outcome_id = $1, search_config = 'english' WHERE id = $2", outcome, target)
+	require.NoError(t, err)
+
+	split, err := svc.SplitOpportunity(ctx, target, service.SplitRequest{
+		EvidenceIDs: []uuid.UUID{datesQuote},
+		Struggle:    "Exported dates are wrong",
+	}, "pm@acme.test")
+	require.NoError(t, err)
+
+	opp, err := env.queries.GetOpportunity(ctx, split.OpportunityID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), opp.EvidenceCount)
+	assert.Equal(t, "Finance", opp.UserSegment, "segment defaults to the source's")
+	assert.Equal(t, uuid.NullUUID{UUID: outcome, Valid: true}, opp.OutcomeID)
+	assert.Equal(t, "english", opp.SearchConfig)
+
+	// Moving every remaining quote out isn't a split.
+	var rest []uuid.UUID
+	rows, err := env.db.Query("SELECT id FROM opportunity_evidence WHERE opportunity_id = $1", target)
+	require.NoError(t, err)
+	for rows.Next() {
+		var id uuid.UUID
+		rows.Scan(&id)
+		rest = append(rest, id)
+	}
+	rows.Close()
+	_, err = svc.SplitOpportunity(ctx, target, service.SplitRequest{EvidenceIDs: rest, Struggle: "Everything"}, "")
+	assert.ErrorIs(t, err, service.ErrInvalidCuration)
+
+	entries, err := env.queries.ListAuditEntries(ctx, repository.ListAuditEntriesParams{EntityType: "opportunity", EntityID: target})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "opportunity.merged", entries[0].Action)
+	assert.Equal(t, "opportunity.split", entries[1].Action)
+}
+
+func TestUpdateOpportunityRequiresAField(t *testing.T) {
+	router := newRouterWithoutDB(t)
+
+	req := httptest.NewRequest("PATCH", "/api/opportunities/"+uuid.NewString(), strings.NewReader(`{"actor": "pm"}`))
+	req.Header.Set("X-API-Key", "noker-dev-key-2025")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Nothing to update")
+}
+
+// Edits bump updated_at, renaming onto an existing theme is refused, and merging
+// themes moves their opportunities.
+func TestEditOpportunitiesAndThemes(t *testing.T) {
+	env := newTestRouter(t)
+	svc := service.NewOpportunityService(env.queries)
+	ctx := context.Background()
+
+	_, err := env.db.Exec("TRUNCATE TABLE opportunity_evidence, meetings, opportunities, themes, opportunity_redirects, audit_log CASCADE")
+	require.NoError(t, err)
+
+	meeting, err := env.queries.CreateMeeting(ctx, repository.CreateMeetingParams{
+		Title: "Acme", RawNotes: "CSV export breaks Persian text. Search is slow.", Source: "manual",
+	})
+	require.NoError(t, err)
+	require.NoError(t, svc.ProcessExtractedOpportunities(ctx, meeting.ID, []models.ExtractedOpportunity{
+		{Type: "new", UserSegment: "Finance", Struggle: "CSV export corrupts text", Theme: "export",
+			EvidenceQuotes: []models.EvidenceQuote{{Quote: "CSV export breaks Persian text"}}},
+		{Type: "new", UserSegment: "Ops", Struggle: "Search is slow", Theme: "exports",
+			EvidenceQuotes: []models.EvidenceQuote{{Quote: "Search is slow"}}},
+	}))
+
+	var id uuid.UUID
+	env.db.QueryRow("SELECT id FROM opportunities WHERE struggle = 'CSV export corrupts text'").Scan(&id)
+	before, err := env.queries.GetOpportunity(ctx, id)
+	require.NoError(t, err)
+
+	struggle, empty := "Exported CSVs lose non-Latin text", ""
+	require.NoError(t, svc.UpdateOpportunity(ctx, id, service.OpportunityUpdate{Struggle: &struggle, Theme: &empty}, "pm"))
+
+	after, err := env.queries.GetOpportunity(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, struggle, after.Struggle)
+	assert.False(t, after.ThemeID.Valid)
+	assert.True(t, after.UpdatedAt.Time.After(before.UpdatedAt.Time))
+
+	err = svc.RenameTheme(ctx, "exports", "search", "pm")
+	require.NoError(t, err)
+	_, err = env.queries.CreateTheme(ctx, "performance")
+	require.NoError(t, err)
+	assert.ErrorIs(t, svc.RenameTheme(ctx, "search", "performance", "pm"), service.ErrThemeExists)
+
+	moved, err := svc.MergeThemes(ctx, "search", "performance", "pm")
+	require.NoError(t, err)
+	assert.Equal(t, 1, moved)
+	_, err = env.queries.GetThemeByName(ctx, "search")
+	assert.Error(t, err)
+
+	assert.ErrorIs(t, svc.DeleteTheme(ctx, "search", "pm"), service.ErrThemeNotFound)
+}
+
+// Changes addressed to a merged-away ID are refused with the ID it was merged
+// into; only reads follow the redirect.
+func TestCurationRefusesMergedIDs(t *testing.T) {
+	env := newTestRouter(t)
+	svc := service.NewOpportunityService(env.queries)
+	ctx := context.Background()
+
+	_, err := env.db.Exec("TRUNCATE TABLE opportunity_evidence, meetings, opportunities, themes, opportunity_redirects, audit_log CASCADE")
+	require.NoError(t, err)
+
+	meeting, err := env.queries.CreateMeeting(ctx, repository.CreateMeetingParams{
+		Title: "Acme", RawNotes: "CSV export breaks Persian text. Exported dates are wrong. Search is slow.", Source: "manual",
+	})
+	require.NoError(t, err)
+	require.NoError(t, svc.ProcessExtractedOpportunities(ctx, meeting.ID, []models.ExtractedOpportunity{
+		{Type: "new", UserSegment: "Finance", Struggle: "CSV export corrupts text",
+			EvidenceQuotes: []models.EvidenceQuote{{Quote: "CSV export breaks Persian text"}}},
+		{Type: "new", UserSegment: "Finance", Struggle: "Exported dates are wrong",
+			EvidenceQuotes: []models.EvidenceQuote{{Quote: "Exported dates are wrong"}}},
+		{Type: "new", UserSegment: "Ops", Struggle: "Search is slow",
+			EvidenceQuotes: []models.EvidenceQuote{{Quote: "Search is slow"}}},
+	}))
+
+	var target, source, other uuid.UUID
+	env.db.QueryRow("SELECT id FROM opportunities WHERE struggle = 'CSV export corrupts text'").Scan(&target)
+	env.db.QueryRow("SELECT id FROM opportunities WHERE struggle = 'Exported dates are wrong'").Scan(&source)
+	env.db.QueryRow("SELECT id FROM opportunities WHERE struggle = 'Search is slow'").Scan(&other)
+	_, err = svc.MergeOpportunities(ctx, target, []uuid.UUID{source}, "pm")
+	require.NoError(t, err)
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", "noker-dev-key-2025")
+		w := httptest.NewRecorder()
+		env.router.ServeHTTP(w, req)
+		return w
+	}
+
+	var datesQuote uuid.UUID
+	env.db.QueryRow("SELECT id FROM opportunity_evidence WHERE quote = 'Exported dates are wrong'").Scan(&datesQuote)
+	requests := []struct{ method, path, body string }{
+		{"PATCH", "/api/opportunities/" + source.String(), `{"struggle": "Exports mangle data"}`},
+		{"POST", "/api/opportunities/" + source.String() + "/split", `{"evidence_ids": ["` + datesQuote.String() + `"], "struggle": "Dates"}`},
+		{"POST", "/api/opportunities/" + source.String() + "/merge", `{"source_ids": ["` + other.String() + `"]}`},
+		{"POST", "/api/opportunities/" + other.String() + "/merge", `{"source_ids": ["` + source.String() + `"]}`},
+		{"DELETE", "/api/opportunities/" + source.String(), ""},
+	}
+	for _, tc := range requests {
+		w := send(tc.method, tc.path, tc.body)
+		assert.Equal(t, http.StatusConflict, w.Code, "%s %s: %s", tc.method, tc.path, w.Body.String())
+		assert.Contains(t, w.Body.String(), `"merged_into":"`+target.String()+`"`)
+	}
+
+	opp, err := env.queries.GetOpportunity(ctx, target)
+	require.NoError(t, err, "the target survives")
+	assert.Equal(t, "CSV export corrupts text", opp.Struggle)
+	assert.Equal(t, int64(2), opp.EvidenceCount)
+	_, err = env.queries.GetOpportunity(ctx, other)
+	assert.NoError(t, err)
+
+	w := send("GET", "/api/opportunities/"+source.String(), "")
+	assert.Equal(t, http.StatusOK, w.Code, "reads still follow the redirect")
+	assert.Contains(t, w.Body.String(), target.String())
+}
@@ -0,0 +1,180 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pedy4000/noker/internal/api"
+	"github.com/pedy4000/noker/internal/models"
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/sqlc-dev/pqtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomerFromMetadata(t *testing.T) {
+	keys := service.CustomerKeys{Name: []string{"customer", "account"}, Plan: "plan", ARR: "arr"}
+
+	info, ok := service.CustomerFromMetadata(map[string]any{"customer": " MegaStore ", "plan": "enterprise", "arr": 140000.0}, keys)
+	require.True(t, ok)
+	assert.Equal(t, "MegaStore", info.Name)
+	assert.Equal(t, "enterprise", info.Plan)
+	assert.Equal(t, 140000.0, info.ARR.Float64)
+
+	info, ok = service.CustomerFromMetadata(map[string]any{"account": "Acme", "arr": "$1.5k"}, keys)
+	require.True(t, ok)
+	assert.Equal(t, "Acme", info.Name)
+	assert.Equal(t, 1500.0, info.ARR.Float64)
+
+	info, ok = service.CustomerFromMetadata(map[string]any{"customer": "Acme", "arr": "lots"}, keys)
+	require.True(t, ok)
+	assert.False(t, info.ARR.Valid)
+
+	_, ok = service.CustomerFromMetadata(map[string]any{"feature": "knowledge-base"}, keys)
+	assert.False(t, ok)
+
+	// Numbers that can't be an ARR are refused on ingest and never stored.
+	for _, arr := range []any{-1000.0, "-5k", "inf", "-Infinity", "NaN", "1e309"} {
+		metadata := map[string]any{"customer": "Acme", "arr": arr}
+		assert.ErrorIs(t, service.ValidateCustomerMetadata(metadata, keys), service.ErrInvalidAmount, arr)
+		info, ok := service.CustomerFromMetadata(metadata, keys)
+		require.True(t, ok)
+		assert.False(t, info.ARR.Valid, arr)
+	}
+	assert.NoError(t, service.ValidateCustomerMetadata(map[string]any{"customer": "Acme", "arr": "lots"}, keys))
+	assert.NoError(t, service.ValidateCustomerMetadata(map[string]any{"customer": "Acme"}, keys))
+}
+
+func TestCreateMeetingRejectsInvalidARR(t *testing.T) {
+	router := newRouterWithoutDB(t)
+
+	for _, arr := range []any{-140000, "nan", "inf"} {
+		w := postMeeting(t, router, map[string]any{
+			"title": "Customer call", "notes": "CSV exports keep breaking.",
+			"metadata": map[string]any{"customer": "Acme", "arr": arr},
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code, arr)
+		assert.Contains(t, w.Body.String(), "arr must be a non-negative amount")
+	}
+}
+
+func TestCustomersLinkedOnIngest(t *testing.T) {
+	env := newTestRouter(t)
+
+	_, err := env.db.Exec("TRUNCATE TABLE opportunity_evidence, meetings, opportunities, themes, customers CASCADE")
+	require.NoError(t, err)
+
+	var meetingIDs []uuid.UUID
+	for _, metadata := range []map[string]any{
+		{"customer": "MegaStore", "plan": "enterprise", "arr": 140000},
+		{"customer": "megastore"}, // same customer, keeps plan and ARR
+		{"customer": "ShopFast", "plan": "growth", "arr": "$20k"},
+	} {
+		w := postMeeting(t, env.router, map[string]any{
+			"title": "Customer call", "notes": "CSV exports keep breaking.", "metadata": metadata,
+		})
+		require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+		var resp api.CreateMeetingResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		meetingIDs = append(meetingIDs, resp.MeetingID)
+	}
+
+	// Attach evidence directly, so the test doesn't depend on the extractor.
+	ctx := context.Background()
+	opps := service.NewOpportunityService(env.queries)
+	for i, id := range meetingIDs {
+		require.NoError(t, opps.ProcessExtractedOpportunities(ctx, id, []models.ExtractedOpportunity{{
+			Type: "new", UserSegment: "ops", Struggle: "CSV exports break",
+			EvidenceQuotes: []models.EvidenceQuote{{Quote: "CSV exports keep breaking " + string(rune('a'+i))}},
+		}}))
+	}
+
+	get := func(path string, v any) {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("X-API-Key", "noker-dev-key-2025")
+		w := httptest.NewRecorder()
+		env.router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), v))
+	}
+
+	var customers []api.CustomerResponse
+	get("/api/customers", &customers)
+	require.Len(t, customers, 2)
+	assert.Equal(t, "MegaStore", customers[0].Name)
+	assert.Equal(t, 2, customers[0].MeetingCount)
+	require.NotNil(t, customers[0].ARR)
+	assert.Equal(t, 140000.0, *customers[0].ARR)
+
+	var list api.OpportunityListResponse
+	get("/api/opportunities", &list)
+	require.Len(t, list.Opportunities, 1)
+	summary := list.Opportunities[0].Customers
+	require.NotNil(t, summary)
+	assert.Equal(t, 2, summary.Count)
+	assert.Equal(t, 160000.0, summary.ARR)
+	assert.Equal(t, map[string]int{"enterprise": 1, "growth": 1}, summary.Plans)
+
+	var byCustomer api.CustomerOpportunitiesResponse
+	get("/api/customers/"+customers[0].ID.String()+"/opportunities", &byCustomer)
+	require.Len(t, byCustomer.Opportunities, 1)
+	assert.Equal(t, 2, byCustomer.Opportunities[0].EvidenceCount)
+}
+
+// Meetings stored before their name key was configured are linked on startup,
+// whichever of the name keys names them.
+func TestBackfillCustomers(t *testing.T) {
+	env := newTestRouter(t)
+	ctx := context.Background()
+
+	_, err := env.db.Exec("TRUNCATE TABLE opportunity_evidence, meetings, opportunities, themes, customers CASCADE")
+	require.NoError(t, err)
+
+	var meetingIDs []uuid.UUID
+	for _, metadata := range []string{
+		`{"account": "Acme", "arr": 1000}`,
+		`{"company": "Globex"}`,
+		`{"customer": "acme", "plan": "pro"}`,
+		`{"topic": "no customer here"}`,
+	} {
+		meeting, err := env.queries.CreateMeeting(ctx, repository.CreateMeetingParams{
+			Title: "Old call", RawNotes: "notes", Source: "manual",
+			Metadata: pqtype.NullRawMessage{RawMessage: json.RawMessage(metadata), Valid: true},
+		})
+		require.NoError(t, err)
+		meetingIDs = append(meetingIDs, meeting.ID)
+	}
+
+	keys := service.CustomerKeys{Name: []string{"customer", "account", "company"}, Plan: "plan", ARR: "arr"}
+	svc := service.NewOpportunityService(env.queries)
+	linked, err := svc.BackfillCustomers(ctx, keys)
+	require.NoError(t, err)
+	assert.Equal(t, 3, linked)
+
+	customerOf := func(id uuid.UUID) uuid.NullUUID {
+		var customerID uuid.NullUUID
+		require.NoError(t, env.db.QueryRow("SELECT customer_id FROM meetings WHERE id = $1", id).Scan(&customerID))
+		return customerID
+	}
+	acme := customerOf(meetingIDs[0])
+	require.True(t, acme.Valid)
+	assert.Equal(t, acme, customerOf(meetingIDs[2]), "names match case-insensitively")
+	assert.True(t, customerOf(meetingIDs[1]).Valid)
+	assert.False(t, customerOf(meetingIDs[3]).Valid)
+
+	customer, err := env.queries.GetCustomer(ctx, acme.UUID)
+	require.NoError(t, err)
+	assert.Equal(t, "pro", customer.Plan.String, "latest meeting's plan")
+	assert.Equal(t, 1000.0, customer.Arr.Float64, "kept from the meeting that reported it")
+
+	linked, err = svc.BackfillCustomers(ctx, keys)
+	require.NoError(t, err)
+	assert.Zero(t, linked, "already linked meetings are left alone")
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pedy4000/noker/internal/ai"
+	"github.com/pedy4000/noker/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAIEmbeddingsAgainstLocalServer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/embeddings", r.URL.Path)
+
+		var body struct {
+			Model      string   `json:"model"`
+			Input      []string `json:"input"`
+			Dimensions int      `json:"dimensions"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "text-embedding-3-small", body.Model)
+		assert.Equal(t, 3, body.Dimensions)
+
+		// Answer out of order; Embed must put vectors back by index.
+		json.NewEncoder(w).Encode(map[string]any{
+			"data": []map[string]any{
+				{"index": 1, "embedding": []float32{0, 1, 0}},
+				{"index": 0, "embedding": []float32{1, 0, 0}},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	cfg.AI.TimeoutSec = 5
+	cfg.AI.OpenAI.BaseURL = srv.URL
+	cfg.AI.Embedding.Model = "text-embedding-3-small"
+	cfg.AI.Embedding.Dimensions = 3
+
+	vectors, err := ai.NewOpenAIProvider(cfg).Embed(context.Background(), []string{"first", "second"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1, 0, 0}, {0, 1, 0}}, vectors)
+}
+
+func TestFakeEmbeddingsRankSharedWordsCloser(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.AI.Embedding.Dimensions = 256
+
+	vectors, err := ai.NewFakeProvider(cfg).Embed(context.Background(), []string{
+		"Persian text in CSV export becomes garbage",
+		"CSV export corrupts data | export-issues",
+		"Search is too slow | search",
+	})
+	require.NoError(t, err)
+	require.Len(t, vectors[0], 256)
+
+	dot := func(a, b []float32) (s float32) {
+		for i := range a {
+			s += a[i] * b[i]
+		}
+		return s
+	}
+	assert.Greater(t, dot(vectors[0], vectors[1]), dot(vectors[0], vectors[2]))
+}
+
+func TestEmbedderIsOffDuringReplay(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.AI.Provider = "openai"
+	assert.NotNil(t, ai.NewEmbedder(cfg))
+
+	cfg.AI.Cassette.Mode = ai.CassetteReplay
+	assert.Nil(t, ai.NewEmbedder(cfg))
+}
+
+// A configured size the opportunity_embeddings column can't hold is refused on start.
+func TestCheckEmbeddingDimensions(t *testing.T) {
+	cfg := loadOfflineConfig(t)
+	env := newTestRouterWith(t, cfg)
+	ctx := context.Background()
+
+	cfg.AI.Embedding.Dimensions = 1536
+	assert.NoError(t, ai.CheckEmbeddingDimensions(ctx, env.queries.Queries, cfg))
+
+	cfg.AI.Embedding.Dimensions = 768
+	err := ai.CheckEmbeddingDimensions(ctx, env.queries.Queries, cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "vector(1536)")
+
+	cfg.AI.Embedding.Disabled = true
+	assert.NoError(t, ai.CheckEmbeddingDimensions(ctx, env.queries.Queries, cfg), "nothing to check without embeddings")
+}
@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/pedy4000/noker/internal/ai"
+	"github.com/pedy4000/noker/internal/models"
+	"github.com/pedy4000/noker/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateExtractionRepairsAndDrops(t *testing.T) {
+	known := "0b7f8f5e-8f2a-4a55-9a49-6f7f0d1c2b3a"
+	candidates := []repository.ListAllOpportunitiesForDeduplicationRow{
+		{OpportunityID: known, Struggle: "CSV export corrupts data"},
+	}
+	quote := []models.EvidenceQuote{{Quote: "Persian text becomes garbage"}}
+
+	items := []models.ExtractedOpportunity{
+		// 0: valid match
+		{Type: "match", ExistingOpportunityID: known, EvidenceQuotes: quote},
+		// 1: match against an ID the model made up, but with enough to stand alone
+		{Type: "match", ExistingOpportunityID: "not-a-real-id", UserSegment: "Ops", Struggle: "Manual invoice matching", EvidenceQuotes: quote},
+		// 2: new without a struggle
+		{Type: "new", UserSegment: "Ops", EvidenceQuotes: quote},
+		// 3: unknown type, too-long struggle
+		{Type: "opportunity", UserSegment: "Ops", Struggle: "one two three four five six seven eight nine ten eleven twelve", EvidenceQuotes: quote},
+		// 4: no evidence
+		{Type: "new", UserSegment: "Ops", Struggle: "Slow search", EvidenceQuotes: []models.EvidenceQuote{{Quote: "  "}}},
+	}
+
+	valid, issues := ai.ValidateExtraction(items, candidates)
+	require.Len(t, valid, 3)
+
+	assert.Equal(t, "match", valid[0].Type)
+	assert.Equal(t, known, valid[0].ExistingOpportunityID)
+
+	assert.Equal(t, "new", valid[1].Type)
+	assert.Empty(t, valid[1].ExistingOpportunityID)
+
+	assert.Equal(t, "new", valid[2].Type)
+	assert.Equal(t, "one two three four five six seven eight nine ten", valid[2].Struggle)
+
+	byIndex := map[int][]ai.ValidationIssue{}
+	for _, issue := range issues {
+		byIndex[issue.Index] = append(byIndex[issue.Index], issue)
+	}
+	assert.Empty(t, byIndex[0])
+	require.Len(t, byIndex[1], 1)
+	assert.Equal(t, "repaired", byIndex[1][0].Action)
+	require.Len(t, byIndex[2], 1)
+	assert.Equal(t, "dropped", byIndex[2][0].Action)
+	assert.Equal(t, "struggle", byIndex[2][0].Field)
+	assert.Len(t, byIndex[3], 2)
+	require.Len(t, byIndex[4], 1)
+	assert.Equal(t, "evidence_quotes", byIndex[4][0].Field)
+}
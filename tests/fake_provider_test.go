@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pedy4000/noker/internal/ai"
+	"github.com/pedy4000/noker/internal/models"
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeProviderRulesAreDeterministic(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.AI.Provider = "fake"
+	cfg.AI.Fake.RulesFile = "testdata/fake_rules.json"
+	provider := ai.NewExtractor(cfg)
+
+	meeting := &models.Meeting{
+		Title:    "Acme Corp – Export Hell",
+		RawNotes: "Every Monday we spend 3 hours cleaning exported CSVs. Persian text becomes garbage, dates are wrong.",
+	}
+
+	first, err := provider.Extract(context.Background(), meeting, nil)
+	require.NoError(t, err)
+	require.Len(t, first, 1)
+	assert.Equal(t, "new", first[0].Type)
+	assert.Equal(t, "CSV export corrupts data", first[0].Struggle)
+	assert.Len(t, first[0].EvidenceQuotes, 1)
+
+	// Once the opportunity exists, the same pain becomes a match.
+	existing := []repository.ListAllOpportunitiesForDeduplicationRow{
+		{OpportunityID: "0b7f8f5e-8f2a-4a55-9a49-6f7f0d1c2b3a", Struggle: "CSV export corrupts data", ThemeName: "export-issues"},
+	}
+	second, err := provider.Extract(context.Background(), &models.Meeting{
+		Title:    "Acme Follow-up",
+		RawNotes: "Fonts broken again. Nobody can search invoices.",
+	}, existing)
+	require.NoError(t, err)
+	require.Len(t, second, 2)
+	assert.Equal(t, "match", second[0].Type)
+	assert.Equal(t, existing[0].OpportunityID, second[0].ExistingOpportunityID)
+	assert.Equal(t, "new", second[1].Type)
+	assert.Equal(t, "search-issues", second[1].Theme)
+}
+
+func TestFakeProviderPrefersFixtureForMeetingHash(t *testing.T) {
+	dir := t.TempDir()
+	meeting := &models.Meeting{Title: "Recorded call", RawNotes: "Search returns nothing."}
+
+	fixture := `{"results":[{"type":"new","struggle":"Recorded struggle","theme":"recorded","evidence_quotes":[{"quote":"Search returns nothing"}]}]}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ai.MeetingHash(meeting)+".json"), []byte(fixture), 0o644))
+
+	cfg := &config.Config{}
+	cfg.AI.Fake.RulesFile = "testdata/fake_rules.json"
+	cfg.AI.Fake.FixturesDir = dir
+
+	results, err := ai.NewFakeProvider(cfg).Extract(context.Background(), meeting, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "Recorded struggle", results[0].Struggle)
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/pedy4000/noker/internal/api"
+	"github.com/pedy4000/noker/internal/queue"
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/pkg/config"
+	"github.com/pedy4000/noker/pkg/db"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testEnv is the API wired to the test database, plus what a test needs to
+// seed and inspect it directly.
+type testEnv struct {
+	cfg       *config.Config
+	db        *sql.DB
+	queries   *repository.Store
+	processor queue.Processor
+	router    http.Handler
+}
+
+// newTestRouter connects to the test database with config.yaml's settings.
+// The processor isn't started; call start when the test needs a worker.
+func newTestRouter(t *testing.T) *testEnv {
+	t.Helper()
+	cfg, err := config.Load("../config.yaml")
+	require.NoError(t, err)
+	cfg.Database.URL = testDB
+	return newTestRouterWith(t, cfg)
+}
+
+// newTestRouterWith is newTestRouter for a config the test has tweaked.
+func newTestRouterWith(t *testing.T, cfg *config.Config) *testEnv {
+	t.Helper()
+	dbConn, err := db.Connect(testDB, cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { dbConn.Close() })
+
+	queries := repository.NewStore(dbConn)
+	processor := queue.NewProcessor(queries, cfg)
+	return &testEnv{
+		cfg:       cfg,
+		db:        dbConn,
+		queries:   queries,
+		processor: processor,
+		router:    api.NewRouter(api.NewHandler(queries, processor, cfg), cfg),
+	}
+}
+
+// start runs the processor until the test ends, stopping it before the
+// database is closed.
+func (e *testEnv) start(t *testing.T) {
+	t.Helper()
+	e.processor.Start()
+	t.Cleanup(e.processor.Stop)
+}
+
+// newRouterWithoutDB is for tests that are answered before the database is
+// touched, such as request validation.
+func newRouterWithoutDB(t *testing.T) http.Handler {
+	t.Helper()
+	cfg, err := config.Load("../config.yaml")
+	require.NoError(t, err)
+	return api.NewRouter(api.NewHandler(repository.NewStore(nil), nil, cfg), cfg)
+}
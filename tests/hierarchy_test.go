@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pedy4000/noker/internal/ai"
+	"github.com/pedy4000/noker/internal/api"
+	"github.com/pedy4000/noker/internal/models"
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateExtractionParent(t *testing.T) {
+	known := "0b7f8f5e-8f2a-4a55-9a49-6f7f0d1c2b3a"
+	candidates := []repository.ListAllOpportunitiesForDeduplicationRow{
+		{OpportunityID: known, Struggle: "Reporting is unreliable"},
+	}
+	quote := []models.EvidenceQuote{{Quote: "the totals never match"}}
+
+	valid, issues := ai.ValidateExtraction([]models.ExtractedOpportunity{
+		{Type: "new", UserSegment: "Finance", Struggle: "Report totals are wrong", ParentOpportunityID: known, EvidenceQuotes: quote},
+		{Type: "new", UserSegment: "Finance", Struggle: "Reports load slowly", ParentOpportunityID: "made-up", EvidenceQuotes: quote},
+		{Type: "match", ExistingOpportunityID: known, ParentOpportunityID: known, EvidenceQuotes: quote},
+	}, candidates)
+
+	require.Len(t, valid, 3)
+	assert.Equal(t, known, valid[0].ParentOpportunityID)
+	assert.Empty(t, valid[1].ParentOpportunityID)
+	assert.Empty(t, valid[2].ParentOpportunityID)
+
+	require.Len(t, issues, 1)
+	assert.Equal(t, 1, issues[0].Index)
+	assert.Equal(t, "parent_opportunity_id", issues[0].Field)
+	assert.Equal(t, "repaired", issues[0].Action)
+}
+
+func TestOpportunityHierarchy(t *testing.T) {
+	env := newTestRouter(t)
+
+	_, err := env.db.Exec("TRUNCATE TABLE opportunity_evidence, meetings, opportunities, themes CASCADE")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	opps := service.NewOpportunityService(env.queries)
+	meeting, err := env.queries.CreateMeeting(ctx, repository.CreateMeetingParams{Title: "Call", RawNotes: "notes", Source: "manual"})
+	require.NoError(t, err)
+	require.NoError(t, opps.ProcessExtractedOpportunities(ctx, meeting.ID, []models.ExtractedOpportunity{
+		{Type: "new", UserSegment: "finance", Struggle: "Reporting is unreliable", EvidenceQuotes: []models.EvidenceQuote{{Quote: "can't trust reports"}}},
+		{Type: "new", UserSegment: "finance", Struggle: "Report totals are wrong", EvidenceQuotes: []models.EvidenceQuote{{Quote: "totals never match"}}},
+		{Type: "new", UserSegment: "finance", Struggle: "Currency rounding differs", EvidenceQuotes: []models.EvidenceQuote{{Quote: "cents are off"}}},
+	}))
+
+	do := func(method, path, body string, v any) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("X-API-Key", "noker-dev-key-2025")
+		w := httptest.NewRecorder()
+		env.router.ServeHTTP(w, req)
+		if v != nil && w.Code < 300 {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), v), w.Body.String())
+		}
+		return w.Code
+	}
+
+	var list api.OpportunityListResponse
+	require.Equal(t, http.StatusOK, do("GET", "/api/opportunities", "", &list))
+	ids := map[string]string{}
+	for _, op := range list.Opportunities {
+		ids[op.Struggle] = op.ID.String()
+	}
+	root, totals, rounding := ids["Reporting is unreliable"], ids["Report totals are wrong"], ids["Currency rounding differs"]
+
+	// A new extracted opportunity can land under an existing one.
+	require.NoError(t, opps.ProcessExtractedOpportunities(ctx, meeting.ID, []models.ExtractedOpportunity{
+		{Type: "new", UserSegment: "finance", Struggle: "Exports drop columns", ParentOpportunityID: root, EvidenceQuotes: []models.EvidenceQuote{{Quote: "columns vanish"}}},
+	}))
+
+	require.Equal(t, http.StatusOK, do("PATCH", "/api/opportunities/"+totals, `{"parent_id": "`+root+`"}`, nil))
+	require.Equal(t, http.StatusOK, do("PATCH", "/api/opportunities/"+rounding, `{"parent_id": "`+totals+`"}`, nil))
+
+	// root → totals → rounding: putting root under rounding would close a loop.
+	assert.Equal(t, http.StatusConflict, do("PATCH", "/api/opportunities/"+root, `{"parent_id": "`+rounding+`"}`, nil))
+	assert.Equal(t, http.StatusConflict, do("PATCH", "/api/opportunities/"+root, `{"parent_id": "`+root+`"}`, nil))
+
+	var subtree api.OpportunityResponse
+	require.Equal(t, http.StatusOK, do("GET", "/api/opportunities/"+root+"/subtree", "", &subtree))
+	require.Len(t, subtree.Children, 2)
+	var totalsNode api.OpportunityResponse
+	for _, c := range subtree.Children {
+		if c.ID.String() == totals {
+			totalsNode = c
+		}
+	}
+	require.Len(t, totalsNode.Children, 1)
+	assert.Equal(t, rounding, totalsNode.Children[0].ID.String())
+
+	var ancestors []api.OpportunityResponse
+	require.Equal(t, http.StatusOK, do("GET", "/api/opportunities/"+rounding+"/ancestors", "", &ancestors))
+	require.Len(t, ancestors, 2)
+	assert.Equal(t, root, ancestors[0].ID.String())
+	assert.Equal(t, totals, ancestors[1].ID.String())
+
+	// Merging root into rounding, which sits below it, lifts rounding to the top
+	// and hands it root's children.
+	require.Equal(t, http.StatusOK, do("POST", "/api/opportunities/"+rounding+"/merge", `{"source_ids": ["`+root+`"]}`, nil))
+	var merged api.OpportunityResponse
+	require.Equal(t, http.StatusOK, do("GET", "/api/opportunities/"+rounding, "", &merged))
+	assert.Nil(t, merged.ParentID)
+	require.Equal(t, http.StatusOK, do("GET", "/api/opportunities/"+rounding+"/subtree", "", &subtree))
+	assert.Len(t, subtree.Children, 2)
+	require.Equal(t, http.StatusOK, do("GET", "/api/opportunities/"+totals, "", &merged))
+	require.NotNil(t, merged.ParentID)
+	assert.Equal(t, rounding, merged.ParentID.String())
+
+	// Deleting a parent moves its children up.
+	require.Equal(t, http.StatusNoContent, do("DELETE", "/api/opportunities/"+rounding, "", nil))
+	require.Equal(t, http.StatusOK, do("GET", "/api/opportunities/"+totals, "", &merged))
+	assert.Nil(t, merged.ParentID)
+}
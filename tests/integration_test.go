@@ -32,13 +32,16 @@ func TestFullOSTFlow(t *testing.T) {
 	cfg, err := config.Load("../config.yaml")
 	require.NoError(t, err)
 	cfg.Database.URL = testDB
+	// AI_CASSETTE_MODE=record saves the real provider's answers here,
+	// AI_CASSETTE_MODE=replay runs this test offline from them.
+	cfg.AI.Cassette.Dir = "testdata/cassettes"
 
 	testDB, err := db.Connect(testDB, cfg)
 	require.NoError(t, err)
 
-	queries := repository.New(testDB)
+	queries := repository.NewStore(testDB)
 	processor := queue.NewProcessor(queries, cfg)
-	handler := api.NewHandler(queries, processor)
+	handler := api.NewHandler(queries, processor, cfg)
 	processor.Start()
 
 	router := api.NewRouter(handler, cfg)
@@ -164,3 +167,15 @@ func postMeeting(t *testing.T, router http.Handler, payload map[string]any) *htt
 
 	return w
 }
+
+// Helper: load the test config with the offline fake AI provider, so the
+// pipeline runs end to end without an API key or network access
+func loadOfflineConfig(t *testing.T) *config.Config {
+	cfg, err := config.Load("../config.yaml")
+	require.NoError(t, err)
+
+	cfg.Database.URL = testDB
+	cfg.AI.Provider = "fake"
+	cfg.AI.Fake.RulesFile = "testdata/fake_rules.json"
+	return cfg
+}
@@ -0,0 +1,151 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/pedy4000/noker/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newQueuedMeetings truncates the queue and enqueues a fresh meeting per title.
+func newQueuedMeetings(t *testing.T, env *testEnv, titles ...string) []uuid.UUID {
+	t.Helper()
+	ctx := context.Background()
+	_, err := env.db.Exec("TRUNCATE TABLE jobs, opportunity_evidence, meetings, opportunities CASCADE")
+	require.NoError(t, err)
+
+	var ids []uuid.UUID
+	for _, title := range titles {
+		meeting, err := env.queries.CreateMeeting(ctx, repository.CreateMeetingParams{
+			Title: title, RawNotes: "CSV export breaks Persian text.", Source: "manual",
+		})
+		require.NoError(t, err)
+		require.NoError(t, env.queries.EnqueueJob(ctx, meeting.ID))
+		ids = append(ids, meeting.ID)
+	}
+	return ids
+}
+
+func jobStatus(t *testing.T, env *testEnv, meetingID uuid.UUID) (status string, rerun bool) {
+	t.Helper()
+	err := env.db.QueryRow("SELECT status, rerun FROM jobs WHERE meeting_id = $1", meetingID).Scan(&status, &rerun)
+	if err == sql.ErrNoRows {
+		return "", false
+	}
+	require.NoError(t, err)
+	return status, rerun
+}
+
+// A job claimed in an open transaction is skipped, not waited on, by other workers.
+func TestClaimNextJobSkipsLockedJobs(t *testing.T) {
+	env := newTestRouter(t)
+	ctx := context.Background()
+	ids := newQueuedMeetings(t, env, "First", "Second")
+
+	tx, err := env.db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	held, err := repository.New(tx).ClaimNextJob(ctx, sql.NullString{String: "worker-a", Valid: true})
+	require.NoError(t, err)
+
+	other, err := env.queries.ClaimNextJob(ctx, sql.NullString{String: "worker-b", Valid: true})
+	require.NoError(t, err)
+	assert.NotEqual(t, held.MeetingID, other.MeetingID)
+	assert.ElementsMatch(t, ids, []uuid.UUID{held.MeetingID, other.MeetingID})
+
+	_, err = env.queries.ClaimNextJob(ctx, sql.NullString{String: "worker-b", Valid: true})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestRetryJobWaitsOutBackoff(t *testing.T) {
+	env := newTestRouter(t)
+	ctx := context.Background()
+	ids := newQueuedMeetings(t, env, "Flaky")
+
+	job, err := env.queries.ClaimNextJob(ctx, sql.NullString{String: "worker", Valid: true})
+	require.NoError(t, err)
+	require.NoError(t, env.queries.RetryJob(ctx, repository.RetryJobParams{ID: job.ID, RunAt: time.Now().Add(time.Hour)}))
+
+	status, _ := jobStatus(t, env, ids[0])
+	assert.Equal(t, "queued", status)
+	_, err = env.queries.ClaimNextJob(ctx, sql.NullString{String: "worker", Valid: true})
+	assert.ErrorIs(t, err, sql.ErrNoRows, "not claimable before run_at")
+
+	require.NoError(t, env.queries.RetryJob(ctx, repository.RetryJobParams{ID: job.ID, RunAt: time.Now().Add(-time.Second)}))
+	again, err := env.queries.ClaimNextJob(ctx, sql.NullString{String: "worker", Valid: true})
+	require.NoError(t, err)
+	assert.Equal(t, job.ID, again.ID)
+}
+
+// Enqueueing a meeting while its job runs isn't lost: the job is queued again
+// when it completes, and without that it's deleted.
+func TestCompleteJobKeepsReenqueuedJob(t *testing.T) {
+	env := newTestRouter(t)
+	ctx := context.Background()
+	ids := newQueuedMeetings(t, env, "Busy")
+
+	job, err := env.queries.ClaimNextJob(ctx, sql.NullString{String: "worker", Valid: true})
+	require.NoError(t, err)
+
+	require.NoError(t, env.queries.EnqueueJob(ctx, ids[0]))
+	status, rerun := jobStatus(t, env, ids[0])
+	assert.Equal(t, "running", status, "still held by its worker")
+	assert.True(t, rerun)
+
+	deleted, err := env.queries.CompleteJob(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+	require.NoError(t, env.queries.RerunJob(ctx, job.ID))
+	status, rerun = jobStatus(t, env, ids[0])
+	assert.Equal(t, "queued", status)
+	assert.False(t, rerun)
+
+	job, err = env.queries.ClaimNextJob(ctx, sql.NullString{String: "worker", Valid: true})
+	require.NoError(t, err)
+	deleted, err = env.queries.CompleteJob(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	status, _ = jobStatus(t, env, ids[0])
+	assert.Empty(t, status, "job row is gone")
+}
+
+// Enqueueing an already queued job doesn't mark it for a rerun.
+func TestEnqueueJobIsIdempotentWhileQueued(t *testing.T) {
+	env := newTestRouter(t)
+	ctx := context.Background()
+	ids := newQueuedMeetings(t, env, "Twice")
+
+	require.NoError(t, env.queries.EnqueueJob(ctx, ids[0]))
+	status, rerun := jobStatus(t, env, ids[0])
+	assert.Equal(t, "queued", status)
+	assert.False(t, rerun)
+}
+
+func TestRequeueStaleJobs(t *testing.T) {
+	env := newTestRouter(t)
+	ctx := context.Background()
+	ids := newQueuedMeetings(t, env, "Abandoned")
+
+	_, err := env.queries.ClaimNextJob(ctx, sql.NullString{String: "dead-worker", Valid: true})
+	require.NoError(t, err)
+
+	n, err := env.queries.RequeueStaleJobs(ctx, sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true})
+	require.NoError(t, err)
+	assert.Zero(t, n, "lease hasn't expired yet")
+
+	n, err = env.queries.RequeueStaleJobs(ctx, sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	status, _ := jobStatus(t, env, ids[0])
+	assert.Equal(t, "queued", status)
+	_, err = env.queries.ClaimNextJob(ctx, sql.NullString{String: "worker", Valid: true})
+	assert.NoError(t, err)
+}
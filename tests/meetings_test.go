@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pedy4000/noker/internal/api"
+	"github.com/pedy4000/noker/internal/repository"
+
+	"github.com/sqlc-dev/pqtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListMeetingsRejectsBadParams(t *testing.T) {
+	router := newRouterWithoutDB(t)
+
+	for _, query := range []string{"status=bogus", "cursor=not-a-cursor", "created_after=yesterday"} {
+		req := httptest.NewRequest("GET", "/api/meetings?"+query, nil)
+		req.Header.Set("X-API-Key", "noker-dev-key-2025")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestListMeetingsPaginatesAndFilters(t *testing.T) {
+	env := newTestRouter(t)
+
+	_, err := env.db.Exec("TRUNCATE TABLE opportunity_evidence, meetings, opportunities, themes CASCADE")
+	require.NoError(t, err)
+
+	for i, customer := range []string{"acme", "acme", "globex"} {
+		_, err := env.queries.CreateMeeting(context.Background(), repository.CreateMeetingParams{
+			Title:    "Call " + string(rune('A'+i)),
+			RawNotes: "Notes about exports",
+			Source:   "manual",
+			Metadata: pqtype.NullRawMessage{RawMessage: json.RawMessage(`{"customer":"` + customer + `"}`), Valid: true},
+		})
+		require.NoError(t, err)
+	}
+
+	list := func(query string) api.MeetingListResponse {
+		req := httptest.NewRequest("GET", "/api/meetings?"+query, nil)
+		req.Header.Set("X-API-Key", "noker-dev-key-2025")
+		w := httptest.NewRecorder()
+		env.router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp api.MeetingListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	first := list("limit=2")
+	require.Len(t, first.Meetings, 2)
+	require.NotEmpty(t, first.NextCursor)
+
+	second := list("limit=2&cursor=" + first.NextCursor)
+	require.Len(t, second.Meetings, 1)
+	assert.Empty(t, second.NextCursor)
+	assert.NotEqual(t, first.Meetings[1].ID, second.Meetings[0].ID)
+
+	assert.Len(t, list("metadata=customer:acme").Meetings, 2)
+	assert.Len(t, list("metadata=customer").Meetings, 3)
+	assert.Len(t, list("q=call%20c").Meetings, 1)
+}
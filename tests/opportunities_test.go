@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pedy4000/noker/internal/api"
+	"github.com/pedy4000/noker/internal/models"
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListOpportunitiesRejectsBadParams(t *testing.T) {
+	router := newRouterWithoutDB(t)
+
+	for _, query := range []string{"sort=popular", "min_evidence=-1", "since=last-week", "cursor=nope"} {
+		req := httptest.NewRequest("GET", "/api/opportunities?"+query, nil)
+		req.Header.Set("X-API-Key", "noker-dev-key-2025")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestListOpportunitiesSortsFiltersAndPaginates(t *testing.T) {
+	env := newTestRouter(t)
+
+	_, err := env.db.Exec("TRUNCATE TABLE opportunity_evidence, meetings, opportunities, themes CASCADE")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	meeting, err := env.queries.CreateMeeting(ctx, repository.CreateMeetingParams{
+		Title: "Acme call", RawNotes: "notes", Source: "manual",
+	})
+	require.NoError(t, err)
+
+	quotes := func(qs ...string) []models.EvidenceQuote {
+		var out []models.EvidenceQuote
+		for _, q := range qs {
+			out = append(out, models.EvidenceQuote{Quote: q})
+		}
+		return out
+	}
+	err = service.NewOpportunityService(env.queries).ProcessExtractedOpportunities(ctx, meeting.ID, []models.ExtractedOpportunity{
+		{Type: "new", UserSegment: "enterprise", Struggle: "CSV exports break", Theme: "exports", EvidenceQuotes: quotes("a", "b", "c")},
+		{Type: "new", UserSegment: "smb", Struggle: "Slow dashboard", Theme: "performance", EvidenceQuotes: quotes("d")},
+		{Type: "new", UserSegment: "enterprise", Struggle: "No SSO", Theme: "auth", EvidenceQuotes: quotes("e", "f")},
+	})
+	require.NoError(t, err)
+
+	list := func(query string) api.OpportunityListResponse {
+		req := httptest.NewRequest("GET", "/api/opportunities?"+query, nil)
+		req.Header.Set("X-API-Key", "noker-dev-key-2025")
+		w := httptest.NewRecorder()
+		env.router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp api.OpportunityListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	first := list("sort=evidence&limit=2&include_evidence=true")
+	require.Len(t, first.Opportunities, 2)
+	require.NotEmpty(t, first.NextCursor)
+	assert.Equal(t, "CSV exports break", first.Opportunities[0].Struggle)
+	assert.Len(t, first.Opportunities[0].Evidences, 3)
+	assert.Equal(t, "No SSO", first.Opportunities[1].Struggle)
+	assert.Len(t, first.Opportunities[1].Evidences, 2)
+
+	second := list("sort=evidence&limit=2&cursor=" + first.NextCursor)
+	require.Len(t, second.Opportunities, 1)
+	assert.Equal(t, "Slow dashboard", second.Opportunities[0].Struggle)
+	assert.Empty(t, second.NextCursor)
+
+	// A cursor only continues the listing it came from.
+	for _, query := range []string{"sort=recent", "sort=evidence&segment=smb", "sort=evidence&min_evidence=2"} {
+		req := httptest.NewRequest("GET", "/api/opportunities?limit=2&cursor="+first.NextCursor+"&"+query, nil)
+		req.Header.Set("X-API-Key", "noker-dev-key-2025")
+		w := httptest.NewRecorder()
+		env.router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+
+	assert.Len(t, list("segment=Enterprise").Opportunities, 2)
+	assert.Len(t, list("theme=auth").Opportunities, 1)
+	assert.Len(t, list("min_evidence=2").Opportunities, 2)
+	assert.Empty(t, list("since=2100-01-01T00:00:00Z").Opportunities)
+}
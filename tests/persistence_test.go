@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pedy4000/noker/internal/models"
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Replaying the same extraction for a meeting (e.g. after a retry) must not
+// duplicate opportunities or evidence, and a failing item must roll back the rest.
+func TestProcessExtractedOpportunitiesIsIdempotent(t *testing.T) {
+	env := newTestRouter(t)
+	svc := service.NewOpportunityService(env.queries)
+	ctx := context.Background()
+
+	_, err := env.db.Exec("TRUNCATE TABLE opportunity_evidence, meetings, opportunities, themes CASCADE")
+	require.NoError(t, err)
+
+	meeting, err := env.queries.CreateMeeting(ctx, repository.CreateMeetingParams{
+		Title:    "Acme Corp – Export Hell",
+		RawNotes: "Persian text becomes garbage. Dates are wrong.",
+		Source:   "manual",
+	})
+	require.NoError(t, err)
+
+	extracted := []models.ExtractedOpportunity{{
+		Type:        "new",
+		UserSegment: "Finance teams",
+		Struggle:    "CSV export corrupts non-Latin text",
+		Theme:       "Export Issues",
+		EvidenceQuotes: []models.EvidenceQuote{
+			{Quote: "Persian text becomes garbage"},
+			{Quote: "Dates are wrong"},
+		},
+	}}
+
+	require.NoError(t, svc.ProcessExtractedOpportunities(ctx, meeting.ID, extracted))
+	require.NoError(t, svc.ProcessExtractedOpportunities(ctx, meeting.ID, extracted))
+
+	var count int
+	env.db.QueryRow("SELECT COUNT(*) FROM opportunities").Scan(&count)
+	assert.Equal(t, 1, count)
+	env.db.QueryRow("SELECT COUNT(*) FROM opportunity_evidence").Scan(&count)
+	assert.Equal(t, 2, count)
+
+	// A bad item later in the result rolls back the good one before it.
+	broken := []models.ExtractedOpportunity{
+		{Type: "new", UserSegment: "Ops", Struggle: "Search fails", Theme: "search",
+			EvidenceQuotes: []models.EvidenceQuote{{Quote: "Search returns nothing"}}},
+		{Type: "match", ExistingOpportunityID: "not-a-uuid",
+			EvidenceQuotes: []models.EvidenceQuote{{Quote: "Still broken"}}},
+	}
+	err = svc.ProcessExtractedOpportunities(ctx, meeting.ID, broken)
+	assert.ErrorIs(t, err, service.ErrInvalidExtraction)
+
+	env.db.QueryRow("SELECT COUNT(*) FROM opportunities").Scan(&count)
+	assert.Equal(t, 1, count)
+	env.db.QueryRow("SELECT COUNT(*) FROM themes WHERE name = 'search'").Scan(&count)
+	assert.Equal(t, 0, count)
+}
+
+// A match whose opportunity was merged away lands on the merge target; one whose
+// opportunity was deleted becomes a new opportunity instead of failing the meeting.
+func TestProcessExtractedOpportunitiesFollowsMergesAndDeletes(t *testing.T) {
+	env := newTestRouter(t)
+	svc := service.NewOpportunityService(env.queries)
+	ctx := context.Background()
+
+	_, err := env.db.Exec("TRUNCATE TABLE opportunity_evidence, meetings, opportunities, themes, opportunity_redirects, audit_log CASCADE")
+	require.NoError(t, err)
+
+	var opps []repository.Opportunity
+	for _, struggle := range []string{"CSV export corrupts text", "Exported dates are wrong", "Search is slow"} {
+		opp, err := env.queries.CreateOpportunity(ctx, repository.CreateOpportunityParams{UserSegment: "Finance", Struggle: struggle})
+		require.NoError(t, err)
+		opps = append(opps, opp)
+	}
+	target, merged, deleted := opps[0], opps[1], opps[2]
+
+	// The model was shown all three, then a PM merged one and deleted another.
+	_, err = svc.MergeOpportunities(ctx, target.ID, []uuid.UUID{merged.ID}, "pm")
+	require.NoError(t, err)
+	require.NoError(t, svc.DeleteOpportunity(ctx, deleted.ID, "pm"))
+
+	meeting, err := env.queries.CreateMeeting(ctx, repository.CreateMeetingParams{
+		Title: "Acme", RawNotes: "Dates are off by a day. Search takes forever.", Source: "manual",
+	})
+	require.NoError(t, err)
+	require.NoError(t, svc.ProcessExtractedOpportunities(ctx, meeting.ID, []models.ExtractedOpportunity{
+		{Type: "match", ExistingOpportunityID: merged.ID.String(),
+			EvidenceQuotes: []models.EvidenceQuote{{Quote: "Dates are off by a day"}}},
+		{Type: "match", ExistingOpportunityID: deleted.ID.String(), UserSegment: "Ops", Struggle: "Search is slow",
+			EvidenceQuotes: []models.EvidenceQuote{{Quote: "Search takes forever"}}},
+	}))
+
+	var onTarget uuid.UUID
+	require.NoError(t, env.db.QueryRow("SELECT opportunity_id FROM opportunity_evidence WHERE quote = 'Dates are off by a day'").Scan(&onTarget))
+	assert.Equal(t, target.ID, onTarget)
+
+	var recreated uuid.UUID
+	require.NoError(t, env.db.QueryRow(
+		"SELECT o.id FROM opportunities o JOIN opportunity_evidence e ON e.opportunity_id = o.id WHERE e.quote = 'Search takes forever'",
+	).Scan(&recreated))
+	assert.NotEqual(t, deleted.ID, recreated)
+}
@@ -0,0 +1,216 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pedy4000/noker/internal/api"
+	"github.com/pedy4000/noker/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func meetingStatus(t *testing.T, env *testEnv, meetingID uuid.UUID) (status string, attempts int) {
+	t.Helper()
+	require.NoError(t, env.db.QueryRow(
+		"SELECT processing_status, processing_attempts FROM meetings WHERE id = $1", meetingID,
+	).Scan(&status, &attempts))
+	return status, attempts
+}
+
+// Renewing only works for the attempt that holds the lease.
+func TestExtendMeetingLease(t *testing.T) {
+	env := newTestRouter(t)
+	ctx := context.Background()
+	ids := newQueuedMeetings(t, env, "Long call")
+
+	attempt, err := env.queries.StartMeetingAttempt(ctx, repository.StartMeetingAttemptParams{ID: ids[0], LeaseSeconds: 60})
+	require.NoError(t, err)
+
+	n, err := env.queries.ExtendMeetingLease(ctx, repository.ExtendMeetingLeaseParams{ID: ids[0], LeaseSeconds: 600, Attempt: attempt})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	var remaining float64
+	require.NoError(t, env.db.QueryRow(
+		"SELECT EXTRACT(EPOCH FROM lease_expires_at - NOW()) FROM meetings WHERE id = $1", ids[0],
+	).Scan(&remaining))
+	assert.Greater(t, remaining, 500.0)
+
+	n, err = env.queries.ExtendMeetingLease(ctx, repository.ExtendMeetingLeaseParams{ID: ids[0], LeaseSeconds: 600, Attempt: attempt + 1})
+	require.NoError(t, err)
+	assert.Zero(t, n, "a stale attempt can't renew a lease taken over by another worker")
+}
+
+// A worker whose lease was taken over can't overwrite the newer attempt's status.
+func TestStaleAttemptCannotFinishMeeting(t *testing.T) {
+	env := newTestRouter(t)
+	ctx := context.Background()
+	ids := newQueuedMeetings(t, env, "Slow call")
+
+	stale, err := env.queries.StartMeetingAttempt(ctx, repository.StartMeetingAttemptParams{ID: ids[0], LeaseSeconds: 60})
+	require.NoError(t, err)
+	_, err = env.db.Exec("UPDATE meetings SET lease_expires_at = NOW() - INTERVAL '1 second' WHERE id = $1", ids[0])
+	require.NoError(t, err)
+	current, err := env.queries.StartMeetingAttempt(ctx, repository.StartMeetingAttemptParams{ID: ids[0], LeaseSeconds: 60})
+	require.NoError(t, err)
+
+	n, err := env.queries.CompleteMeetingAttempt(ctx, repository.CompleteMeetingAttemptParams{ID: ids[0], Attempt: stale})
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	n, err = env.queries.RecordMeetingFailure(ctx, repository.RecordMeetingFailureParams{
+		ID: ids[0], ProcessingStatus: "failed", Error: "late failure", Attempt: stale,
+	})
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	status, _ := meetingStatus(t, env, ids[0])
+	assert.Equal(t, "processing", status)
+
+	n, err = env.queries.CompleteMeetingAttempt(ctx, repository.CompleteMeetingAttemptParams{ID: ids[0], Attempt: current})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	status, _ = meetingStatus(t, env, ids[0])
+	assert.Equal(t, "done", status)
+}
+
+// A meeting and job left behind by a worker that died mid-attempt are picked up
+// by the reaper and finished.
+func TestPostgresQueueRecoversStaleLease(t *testing.T) {
+	cfg := loadOfflineConfig(t)
+	cfg.Queue.Type = "postgres"
+	cfg.Queue.PollIntervalMs = 50
+	env := newTestRouterWith(t, cfg)
+	ids := newQueuedMeetings(t, env, "Acme – CSV export")
+
+	_, err := env.db.Exec(`UPDATE meetings
+		SET processing_status = 'processing', processing_attempts = 1, lease_expires_at = NOW() - INTERVAL '1 minute'
+		WHERE id = $1`, ids[0])
+	require.NoError(t, err)
+	_, err = env.db.Exec(`UPDATE jobs
+		SET status = 'running', locked_by = 'dead-worker', locked_at = NOW() - INTERVAL '1 hour'
+		WHERE meeting_id = $1`, ids[0])
+	require.NoError(t, err)
+
+	env.start(t)
+
+	require.Eventually(t, func() bool {
+		status, _ := meetingStatus(t, env, ids[0])
+		return status == "done"
+	}, 10*time.Second, 50*time.Millisecond, "stale meeting was never recovered")
+
+	_, attempts := meetingStatus(t, env, ids[0])
+	assert.Equal(t, 2, attempts)
+	status, _ := jobStatus(t, env, ids[0])
+	assert.Empty(t, status, "finished job is deleted")
+}
+
+// Transient provider errors are retried with backoff until max_attempts, then
+// the meeting is dead and its job is gone.
+func TestPostgresQueueRetriesUntilDead(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":{"message":"rate limited"}}`))
+	}))
+	defer srv.Close()
+
+	cfg := loadOfflineConfig(t)
+	cfg.AI.Provider = "compatible"
+	cfg.AI.Compatible.BaseURL = srv.URL
+	cfg.AI.Embedding.Disabled = true
+	cfg.Queue.Type = "postgres"
+	cfg.Queue.PollIntervalMs = 50
+	cfg.Queue.MaxAttempts = 2
+	cfg.Queue.RetryBaseMs = 100
+	cfg.Queue.RetryMaxMs = 200
+	env := newTestRouterWith(t, cfg)
+
+	_, err := env.db.Exec("TRUNCATE TABLE jobs, opportunity_evidence, meetings, opportunities CASCADE")
+	require.NoError(t, err)
+	env.start(t)
+
+	resp := postMeeting(t, env.router, map[string]any{
+		"title": "Acme – CSV export",
+		"notes": "CSV export breaks Persian text.",
+	})
+	require.Equal(t, http.StatusAccepted, resp.Code)
+	var created api.CreateMeetingResponse
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+
+	require.Eventually(t, func() bool {
+		status, _ := meetingStatus(t, env, created.MeetingID)
+		return status == "dead"
+	}, 10*time.Second, 50*time.Millisecond, "meeting never went dead")
+
+	_, attempts := meetingStatus(t, env, created.MeetingID)
+	assert.Equal(t, 2, attempts)
+	assert.EqualValues(t, 2, calls.Load(), "one provider call per attempt")
+
+	var history int
+	require.NoError(t, env.db.QueryRow(
+		"SELECT jsonb_array_length(error_history) FROM meetings WHERE id = $1", created.MeetingID,
+	).Scan(&history))
+	assert.Equal(t, 2, history, "the retrying attempt and the final one are both recorded")
+
+	status, _ := jobStatus(t, env, created.MeetingID)
+	assert.Empty(t, status, "dead meeting's job is deleted")
+}
+
+// Stopping the in-memory queue mid-extraction interrupts the attempt without
+// counting it. Both the interrupted meeting and the one still buffered are left
+// pending for the next start's recovery sweep.
+func TestInMemoryStopLeavesMeetingsPending(t *testing.T) {
+	started := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-r.Context().Done() // never answers; shutdown has to interrupt it
+	}))
+	defer srv.Close()
+
+	cfg := loadOfflineConfig(t)
+	cfg.AI.Provider = "compatible"
+	cfg.AI.Compatible.BaseURL = srv.URL
+	cfg.AI.TimeoutSec = 60
+	cfg.AI.Embedding.Disabled = true
+	cfg.Queue.Type = "inmemory"
+	cfg.Queue.WorkerCount = 1
+	cfg.Queue.ShutdownTimeoutSec = 1
+	env := newTestRouterWith(t, cfg)
+
+	_, err := env.db.Exec("TRUNCATE TABLE jobs, opportunity_evidence, meetings, opportunities CASCADE")
+	require.NoError(t, err)
+	env.processor.Start()
+
+	var ids []uuid.UUID
+	for _, title := range []string{"In flight", "Still queued"} {
+		resp := postMeeting(t, env.router, map[string]any{"title": title, "notes": "CSV export breaks Persian text."})
+		require.Equal(t, http.StatusAccepted, resp.Code)
+		var created api.CreateMeetingResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+		ids = append(ids, created.MeetingID)
+	}
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker never called the provider")
+	}
+	env.processor.Stop()
+
+	for _, id := range ids {
+		status, attempts := meetingStatus(t, env, id)
+		assert.Equal(t, "pending", status)
+		assert.Zero(t, attempts, "an interrupted attempt isn't counted")
+	}
+}
@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/pedy4000/noker/internal/ai"
+	"github.com/pedy4000/noker/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const verificationNotes = "Sara: Every Monday we spend 3 hours cleaning exported CSVs.\nPersian text becomes garbage, dates are wrong."
+
+func TestLocateQuote(t *testing.T) {
+	notes := []rune(verificationNotes)
+
+	// Differences in case, punctuation and whitespace don't matter.
+	start, end, ok := ai.LocateQuote(verificationNotes, "persian text becomes  garbage")
+	require.True(t, ok)
+	assert.Equal(t, "Persian text becomes garbage", string(notes[start:end]))
+
+	// Light rewording is tolerated...
+	_, _, ok = ai.LocateQuote(verificationNotes, "every Monday we spend 3 hours cleaning the exported CSVs")
+	assert.True(t, ok)
+
+	// ...an invented quote is not.
+	_, _, ok = ai.LocateQuote(verificationNotes, "Our finance team hates the new dashboard")
+	assert.False(t, ok)
+}
+
+func TestVerifyQuotesPolicies(t *testing.T) {
+	items := func() []models.ExtractedOpportunity {
+		return []models.ExtractedOpportunity{{
+			Type:     "new",
+			Struggle: "CSV export corrupts data",
+			EvidenceQuotes: []models.EvidenceQuote{
+				{Quote: "Persian text becomes garbage"},
+				{Quote: "We would pay double for a fix"},
+			},
+		}}
+	}
+
+	flagged := items()
+	issues := ai.VerifyQuotes(verificationNotes, flagged, ai.UnverifiedFlag)
+	require.Len(t, flagged[0].EvidenceQuotes, 2)
+	assert.True(t, flagged[0].EvidenceQuotes[0].Verified)
+	assert.Greater(t, flagged[0].EvidenceQuotes[0].QuoteEnd, flagged[0].EvidenceQuotes[0].QuoteStart)
+	assert.False(t, flagged[0].EvidenceQuotes[1].Verified)
+	require.Len(t, issues, 1)
+	assert.Equal(t, "flagged", issues[0].Action)
+
+	dropped := items()
+	issues = ai.VerifyQuotes(verificationNotes, dropped, ai.UnverifiedDrop)
+	require.Len(t, dropped[0].EvidenceQuotes, 1)
+	assert.Equal(t, "Persian text becomes garbage", dropped[0].EvidenceQuotes[0].Quote)
+	require.Len(t, issues, 1)
+	assert.Equal(t, "dropped", issues[0].Action)
+}
@@ -0,0 +1,169 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pedy4000/noker/internal/api"
+	"github.com/pedy4000/noker/internal/models"
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReprocessMeetingNotFound(t *testing.T) {
+	env := newTestRouter(t)
+
+	req := httptest.NewRequest("POST", "/api/meetings/"+uuid.NewString()+"/reprocess", nil)
+	req.Header.Set("X-API-Key", "noker-dev-key-2025")
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "Meeting not found")
+}
+
+func TestReprocessMeetingInvalidID(t *testing.T) {
+	router := newRouterWithoutDB(t)
+
+	req := httptest.NewRequest("POST", "/api/meetings/not-a-uuid/reprocess", nil)
+	req.Header.Set("X-API-Key", "noker-dev-key-2025")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid meeting ID")
+}
+
+// seedSharedOpportunity has two meetings quote one opportunity, and the first
+// also create one of its own. Returns the meetings and the shared opportunity.
+func seedSharedOpportunity(t *testing.T, env *testEnv) (first, second, shared uuid.UUID) {
+	t.Helper()
+	ctx := context.Background()
+	svc := service.NewOpportunityService(env.queries)
+
+	_, err := env.db.Exec("TRUNCATE TABLE jobs, opportunity_evidence, meetings, opportunities, themes CASCADE")
+	require.NoError(t, err)
+
+	meeting := func(title, notes string) uuid.UUID {
+		m, err := env.queries.CreateMeeting(ctx, repository.CreateMeetingParams{Title: title, RawNotes: notes, Source: "manual"})
+		require.NoError(t, err)
+		return m.ID
+	}
+	first = meeting("Acme", "CSV export breaks Persian text. Exported dates are wrong.")
+	second = meeting("Globex", "Our CSV exports are unreadable.")
+
+	require.NoError(t, svc.ProcessExtractedOpportunities(ctx, first, []models.ExtractedOpportunity{
+		{Type: "new", UserSegment: "Finance", Struggle: "CSV export corrupts text", Theme: "export",
+			EvidenceQuotes: []models.EvidenceQuote{{Quote: "CSV export breaks Persian text"}}},
+		{Type: "new", UserSegment: "Finance", Struggle: "Exported dates are wrong", Theme: "export",
+			EvidenceQuotes: []models.EvidenceQuote{{Quote: "Exported dates are wrong"}}},
+	}))
+	require.NoError(t, env.db.QueryRow("SELECT id FROM opportunities WHERE struggle = 'CSV export corrupts text'").Scan(&shared))
+
+	require.NoError(t, svc.ProcessExtractedOpportunities(ctx, second, []models.ExtractedOpportunity{
+		{Type: "match", ExistingOpportunityID: shared.String(),
+			EvidenceQuotes: []models.EvidenceQuote{{Quote: "Our CSV exports are unreadable"}}},
+	}))
+
+	_, err = env.db.Exec("UPDATE meetings SET processing_status = 'done', processing_attempts = 1")
+	require.NoError(t, err)
+	return first, second, shared
+}
+
+func reprocess(t *testing.T, env *testEnv, meetingID uuid.UUID) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/api/meetings/"+meetingID.String()+"/reprocess", nil)
+	req.Header.Set("X-API-Key", "noker-dev-key-2025")
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+	return w
+}
+
+// Reprocessing removes the meeting's evidence and the opportunities only it
+// contributed to. Opportunities other meetings quote keep their other evidence.
+func TestReprocessRollsBackMeetingContribution(t *testing.T) {
+	env := newTestRouter(t)
+	first, second, shared := seedSharedOpportunity(t, env)
+
+	var sharedUpdated, secondQuoteAt time.Time
+	require.NoError(t, env.db.QueryRow("SELECT updated_at FROM opportunities WHERE id = $1", shared).Scan(&sharedUpdated))
+	require.NoError(t, env.db.QueryRow("SELECT created_at FROM opportunity_evidence WHERE meeting_id = $1", second).Scan(&secondQuoteAt))
+
+	w := reprocess(t, env, first)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var resp api.ReprocessResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []uuid.UUID{first}, resp.MeetingIDs)
+	assert.Equal(t, 1, resp.DeletedOpportunities)
+
+	var count int
+	require.NoError(t, env.db.QueryRow("SELECT COUNT(*) FROM opportunity_evidence WHERE meeting_id = $1", first).Scan(&count))
+	assert.Zero(t, count, "meeting's evidence is rolled back")
+	require.NoError(t, env.db.QueryRow("SELECT COUNT(*) FROM opportunities WHERE struggle = 'Exported dates are wrong'").Scan(&count))
+	assert.Zero(t, count, "opportunity only this meeting quoted is deleted")
+
+	rows, err := env.queries.ListOpportunities(context.Background(), repository.ListOpportunitiesParams{
+		SortBy: "last_evidence", MaxRows: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, shared, rows[0].ID)
+	assert.Equal(t, int64(1), rows[0].EvidenceCount, "shared opportunity keeps the other meeting's quote")
+	assert.WithinDuration(t, secondQuoteAt, rows[0].SortTime, time.Millisecond, "last evidence is the other meeting's again")
+
+	var updated time.Time
+	require.NoError(t, env.db.QueryRow("SELECT updated_at FROM opportunities WHERE id = $1", shared).Scan(&updated))
+	assert.True(t, updated.Equal(sharedUpdated), "losing a meeting's quote isn't an edit of the opportunity")
+
+	status, attempts := meetingStatus(t, env, first)
+	assert.Equal(t, "pending", status)
+	assert.Zero(t, attempts)
+	status, _ = meetingStatus(t, env, second)
+	assert.Equal(t, "done", status, "other meetings are untouched")
+}
+
+// A meeting a worker holds the lease on can't be reprocessed, and nothing is rolled back.
+func TestReprocessMeetingBusy(t *testing.T) {
+	env := newTestRouter(t)
+	first, _, _ := seedSharedOpportunity(t, env)
+
+	_, err := env.db.Exec("UPDATE meetings SET processing_status = 'processing', lease_expires_at = NOW() + INTERVAL '5 minutes' WHERE id = $1", first)
+	require.NoError(t, err)
+
+	w := reprocess(t, env, first)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var count int
+	require.NoError(t, env.db.QueryRow("SELECT COUNT(*) FROM opportunity_evidence WHERE meeting_id = $1", first).Scan(&count))
+	assert.Equal(t, 2, count)
+	status, _ := meetingStatus(t, env, first)
+	assert.Equal(t, "processing", status)
+}
+
+// With the postgres queue the job is inserted in the rollback's transaction:
+// it's there once the request returns, and absent when the rollback is refused.
+func TestReprocessEnqueuesInTransaction(t *testing.T) {
+	cfg := loadOfflineConfig(t)
+	cfg.Queue.Type = "postgres"
+	env := newTestRouterWith(t, cfg)
+	first, second, _ := seedSharedOpportunity(t, env)
+
+	_, err := env.db.Exec("UPDATE meetings SET processing_status = 'processing', lease_expires_at = NOW() + INTERVAL '5 minutes' WHERE id = $1", second)
+	require.NoError(t, err)
+
+	require.Equal(t, http.StatusAccepted, reprocess(t, env, first).Code)
+	status, _ := jobStatus(t, env, first)
+	assert.Equal(t, "queued", status)
+
+	require.Equal(t, http.StatusConflict, reprocess(t, env, second).Code)
+	status, _ = jobStatus(t, env, second)
+	assert.Empty(t, status, "refused reprocess enqueues nothing")
+}
@@ -0,0 +1,36 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/pedy4000/noker/internal/ai"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProviderErrorsRetryClassification(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"rate limited", &ai.ProviderError{Provider: "openai", StatusCode: http.StatusTooManyRequests}, true},
+		{"provider outage", &ai.ProviderError{Provider: "openai", StatusCode: http.StatusBadGateway}, true},
+		{"bad request", &ai.ProviderError{Provider: "openai", StatusCode: http.StatusBadRequest}, false},
+		{"unauthorized", &ai.ProviderError{Provider: "openai", StatusCode: http.StatusUnauthorized}, false},
+		{"malformed json", fmt.Errorf("%w: unexpected end of JSON input", ai.ErrMalformedOutput), true},
+		{"timeout", fmt.Errorf("request: %w", context.DeadlineExceeded), true},
+		{"canceled", context.Canceled, false},
+		{"unknown", errors.New("boom"), false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.want, ai.IsRetryable(c.err))
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pedy4000/noker/internal/api"
+	"github.com/pedy4000/noker/internal/models"
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/sqlc-dev/pqtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScoreOpportunity(t *testing.T) {
+	w := service.DefaultScoringWeights("default")
+	est := func(f float64) sql.NullFloat64 { return sql.NullFloat64{Float64: f, Valid: true} }
+
+	// 4 customers + 10 quotes × 0.1 = reach 5; 5 × 2 × 0.8 / 4 = 2
+	s := service.ScoreOpportunity(4, 10, est(2), est(0.8), est(4), w)
+	assert.InDelta(t, 5, s.Reach, 1e-9)
+	assert.InDelta(t, 2, s.RICE, 1e-9)
+	assert.InDelta(t, 0.4, s.ICE, 1e-9)
+	assert.True(t, s.Estimated)
+
+	// Nothing estimated yet: defaults keep it ranked by reach
+	s = service.ScoreOpportunity(2, 0, sql.NullFloat64{}, sql.NullFloat64{}, sql.NullFloat64{}, w)
+	assert.InDelta(t, 1, s.RICE, 1e-9)
+	assert.False(t, s.Estimated)
+
+	// Weight 0 ignores effort entirely
+	w.EffortWeight = 0
+	s = service.ScoreOpportunity(4, 10, est(2), est(0.8), est(4), w)
+	assert.InDelta(t, 8, s.RICE, 1e-9)
+}
+
+func TestSortByScoreFramework(t *testing.T) {
+	opps := []service.PrioritizedOpportunity{
+		{Struggle: "wide", Score: service.Score{RICE: 10, ICE: 1}},
+		{Struggle: "deep", Score: service.Score{RICE: 5, ICE: 3}},
+	}
+
+	service.SortByScore(opps, service.FrameworkICE)
+	assert.Equal(t, "deep", opps[0].Struggle)
+
+	service.SortByScore(opps, service.FrameworkRICE)
+	assert.Equal(t, "wide", opps[0].Struggle)
+}
+
+func TestPrioritizedOpportunities(t *testing.T) {
+	env := newTestRouter(t)
+
+	_, err := env.db.Exec("TRUNCATE TABLE opportunity_evidence, meetings, opportunities, themes, scoring_weights CASCADE")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	opps := service.NewOpportunityService(env.queries)
+	// The last call has no customer: its quotes count, it doesn't add a customer.
+	for _, customer := range []string{"acme", "acme", "globex", ""} {
+		params := repository.CreateMeetingParams{Title: "Call with " + customer, RawNotes: "notes", Source: "manual"}
+		if customer != "" {
+			c, err := env.queries.UpsertCustomer(ctx, repository.UpsertCustomerParams{Name: customer})
+			require.NoError(t, err)
+			params.CustomerID = uuid.NullUUID{UUID: c.ID, Valid: true}
+			params.Metadata = pqtype.NullRawMessage{RawMessage: json.RawMessage(`{"customer":"` + customer + `"}`), Valid: true}
+		}
+		meeting, err := env.queries.CreateMeeting(ctx, params)
+		require.NoError(t, err)
+
+		var extracted []models.ExtractedOpportunity
+		if customer != "globex" {
+			extracted = append(extracted, models.ExtractedOpportunity{
+				Type: "new", UserSegment: "enterprise", Struggle: "CSV exports break",
+				EvidenceQuotes: []models.EvidenceQuote{{Quote: "export broke for " + customer + meeting.ID.String()}},
+			})
+		}
+		if customer == "globex" || customer == "" {
+			extracted = append(extracted, models.ExtractedOpportunity{
+				Type: "new", UserSegment: "enterprise", Struggle: "No SSO",
+				EvidenceQuotes: []models.EvidenceQuote{{Quote: "we need SSO " + meeting.ID.String()}},
+			})
+		}
+		require.NoError(t, opps.ProcessExtractedOpportunities(ctx, meeting.ID, extracted))
+	}
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("X-API-Key", "noker-dev-key-2025")
+		w := httptest.NewRecorder()
+		env.router.ServeHTTP(w, req)
+		return w
+	}
+	prioritized := func(query string) api.PrioritizedOpportunitiesResponse {
+		w := do("GET", "/api/opportunities/prioritized?"+query, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp api.PrioritizedOpportunitiesResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	// CSV exports: 1 distinct customer, 3 quotes. No SSO: 1 customer, 2 quotes.
+	resp := prioritized("framework=rice")
+	require.Len(t, resp.Opportunities, 2)
+	assert.Equal(t, "CSV exports break", resp.Opportunities[0].Struggle)
+	assert.Equal(t, 1, resp.Opportunities[0].CustomerCount)
+	assert.Equal(t, 3, resp.Opportunities[0].EvidenceCount)
+	assert.Equal(t, 1, resp.Opportunities[1].CustomerCount, "a meeting without a customer adds none")
+	assert.Equal(t, 2, resp.Opportunities[1].EvidenceCount)
+	assert.Len(t, prioritized("framework=rice&limit=1").Opportunities, 1)
+
+	// A cheap, high-impact SSO wins under ICE, which ignores reach.
+	sso := resp.Opportunities[1].ID
+	w := do("PATCH", "/api/opportunities/"+sso.String(), `{"impact": 3, "confidence": 1, "effort": 0.5}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, sso, prioritized("framework=ice").Opportunities[0].ID)
+
+	// A workspace that only cares about reach puts CSV exports first again.
+	w = do("PUT", "/api/workspaces/growth/scoring", `{"reach_per_customer": 1,
+		"reach_weight": 1, "impact_weight": 0, "confidence_weight": 0, "effort_weight": 0}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NotEqual(t, sso, prioritized("framework=rice&workspace=growth").Opportunities[0].ID)
+	assert.Equal(t, sso, prioritized("framework=rice").Opportunities[0].ID)
+
+	assert.Equal(t, http.StatusBadRequest, do("GET", "/api/opportunities/prioritized?framework=moscow", "").Code)
+}
@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pedy4000/noker/internal/api"
+	"github.com/pedy4000/noker/internal/models"
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchRejectsBadParams(t *testing.T) {
+	router := newRouterWithoutDB(t)
+
+	for _, query := range []string{"", "q=+", "q=export&lang=klingon"} {
+		req := httptest.NewRequest("GET", "/api/search?"+query, nil)
+		req.Header.Set("X-API-Key", "noker-dev-key-2025")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestSearchStemsByMeetingLanguage(t *testing.T) {
+	env := newTestRouter(t)
+
+	_, err := env.db.Exec("TRUNCATE TABLE opportunity_evidence, meetings, opportunities, themes CASCADE")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	meeting, err := env.queries.CreateMeeting(ctx, repository.CreateMeetingParams{
+		Title:    "Acme call",
+		RawNotes: "Exporting reports takes all Monday.",
+		Source:   "manual",
+		Language: sql.NullString{String: "english", Valid: true},
+	})
+	require.NoError(t, err)
+
+	err = service.NewOpportunityService(env.queries).ProcessExtractedOpportunities(ctx, meeting.ID, []models.ExtractedOpportunity{{
+		Type:           "new",
+		UserSegment:    "enterprise",
+		Struggle:       "Manual report cleanup",
+		Theme:          "reporting",
+		EvidenceQuotes: []models.EvidenceQuote{{Quote: "Exporting reports takes all Monday."}},
+	}})
+	require.NoError(t, err)
+
+	search := func(query string) api.SearchResponse {
+		req := httptest.NewRequest("GET", "/api/search?"+query, nil)
+		req.Header.Set("X-API-Key", "noker-dev-key-2025")
+		w := httptest.NewRecorder()
+		env.router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp api.SearchResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	// "exports" only matches "Exporting" once both are stemmed as English.
+	stemmed := search("q=exports&lang=english")
+	require.Len(t, stemmed.Results, 1)
+	require.Len(t, stemmed.Results[0].Matches, 1)
+	assert.Contains(t, stemmed.Results[0].Matches[0].Highlight, "<mark>Exporting</mark>")
+
+	assert.Empty(t, search("q=exports").Results)
+
+	exact := search("q=cleanup")
+	require.Len(t, exact.Results, 1)
+	assert.Contains(t, exact.Results[0].StruggleHighlight, "<mark>cleanup</mark>")
+}
@@ -10,7 +10,6 @@ import (
 	"github.com/pedy4000/noker/internal/api"
 	"github.com/pedy4000/noker/internal/queue"
 	"github.com/pedy4000/noker/internal/repository"
-	"github.com/pedy4000/noker/pkg/config"
 	"github.com/pedy4000/noker/pkg/db"
 
 	"github.com/stretchr/testify/assert"
@@ -18,13 +17,12 @@ import (
 )
 
 func TestSlackCommandShowNewOpportunities(t *testing.T) {
-	cfg, _ := config.Load("../config.yaml")
-	cfg.Database.URL = testDB
+	cfg := loadOfflineConfig(t)
 	dbConn, _ := db.Connect(testDB, cfg)
-	queries := repository.New(dbConn)
+	queries := repository.NewStore(dbConn)
 	processor := queue.NewProcessor(queries, cfg)
 	processor.Start()
-	handler := api.NewHandler(queries, processor)
+	handler := api.NewHandler(queries, processor, cfg)
 	router := api.NewRouter(handler, cfg)
 
 	dbConn.Exec("TRUNCATE TABLE opportunity_evidence, meetings, opportunities CASCADE")
@@ -0,0 +1,141 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pedy4000/noker/internal/api"
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/internal/slack"
+	"github.com/pedy4000/noker/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSlackSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+
+// slackRequest builds a slash command request signed at, as Slack would send it.
+func slackRequest(secret string, at time.Time, form url.Values) *http.Request {
+	body := form.Encode()
+	req := httptest.NewRequest("POST", "/api/slack/commands", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set(slack.HeaderTimestamp, strconv.FormatInt(at.Unix(), 10))
+	req.Header.Set(slack.HeaderSignature, slack.Sign(secret, at.Unix(), []byte(body)))
+	return req
+}
+
+func TestSlackSignature(t *testing.T) {
+	cfg, _ := config.Load("../config.yaml")
+	cfg.Slack.SigningSecret = testSlackSecret
+	router := api.NewRouter(api.NewHandler(repository.NewStore(nil), nil, cfg), cfg)
+
+	send := func(req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	help := url.Values{"command": {"/ost"}, "text": {"help"}}
+
+	w := send(slackRequest(testSlackSecret, time.Now(), help))
+	require.Equal(t, http.StatusOK, w.Code)
+	var msg slack.Message
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &msg))
+	assert.Equal(t, "ephemeral", msg.ResponseType)
+	require.NotEmpty(t, msg.Blocks)
+	assert.Contains(t, msg.Blocks[0].Text.Text, "/ost add <notes>")
+
+	// Wrong secret, replayed request, tampered body, no signature at all.
+	assert.Equal(t, http.StatusUnauthorized, send(slackRequest("another-secret", time.Now(), help)).Code)
+	assert.Equal(t, http.StatusUnauthorized, send(slackRequest(testSlackSecret, time.Now().Add(-10*time.Minute), help)).Code)
+	tampered := slackRequest(testSlackSecret, time.Now(), help)
+	tampered.Body = http.NoBody
+	assert.Equal(t, http.StatusUnauthorized, send(tampered).Code)
+	unsigned := httptest.NewRequest("POST", "/api/slack/commands", strings.NewReader(help.Encode()))
+	assert.Equal(t, http.StatusUnauthorized, send(unsigned).Code)
+
+	// The API key is no substitute for the signature.
+	unsigned = httptest.NewRequest("POST", "/api/slack/commands", strings.NewReader(help.Encode()))
+	unsigned.Header.Set("X-API-Key", "noker-dev-key-2025")
+	assert.Equal(t, http.StatusUnauthorized, send(unsigned).Code)
+
+	cfg.Slack.SigningSecret = ""
+	assert.Equal(t, http.StatusNotFound, send(slackRequest("", time.Now(), help)).Code)
+}
+
+func TestSlackAddMeeting(t *testing.T) {
+	cfg := loadOfflineConfig(t)
+	cfg.Slack.SigningSecret = testSlackSecret
+	env := newTestRouterWith(t, cfg)
+	env.start(t)
+
+	_, err := env.db.Exec("TRUNCATE TABLE opportunity_evidence, meetings, opportunities CASCADE")
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var posted []slack.Message
+	slackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg slack.Message
+		json.NewDecoder(r.Body).Decode(&msg)
+		mu.Lock()
+		posted = append(posted, msg)
+		mu.Unlock()
+	}))
+	defer slackServer.Close()
+
+	send := func(text string) slack.Message {
+		w := httptest.NewRecorder()
+		env.router.ServeHTTP(w, slackRequest(testSlackSecret, time.Now(), url.Values{
+			"command":      {"/ost"},
+			"text":         {text},
+			"user_name":    {"dana"},
+			"response_url": {slackServer.URL},
+		}))
+		require.Equal(t, http.StatusOK, w.Code)
+		var msg slack.Message
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &msg))
+		return msg
+	}
+
+	// Too short to be notes: nothing is created.
+	ack := send("add hi")
+	assert.Contains(t, ack.Text, "/ost add")
+
+	ack = send("add Call with Acme\nCSV export fails, dates are wrong.")
+	assert.Equal(t, "ephemeral", ack.ResponseType)
+	assert.Contains(t, ack.Text, `"Call with Acme"`)
+
+	var source, title string
+	require.NoError(t, env.db.QueryRow("SELECT source, title FROM meetings").Scan(&source, &title))
+	assert.Equal(t, "slack", source)
+	assert.Equal(t, "Call with Acme", title)
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(posted) == 1
+	}, 10*time.Second, 100*time.Millisecond)
+
+	mu.Lock()
+	result := posted[0]
+	mu.Unlock()
+	assert.Equal(t, "in_channel", result.ResponseType)
+	assert.Contains(t, result.Text, "Found 1 opportunities")
+	blocks, _ := json.Marshal(result.Blocks)
+	assert.Contains(t, string(blocks), "CSV export corrupts data")
+
+	// The response_url is used once.
+	var pending int
+	env.db.QueryRow("SELECT COUNT(*) FROM meetings WHERE slack_response_url IS NOT NULL").Scan(&pending)
+	assert.Zero(t, pending)
+
+	// Other commands answer as GET /api/cmd does.
+	assert.Contains(t, send("show_new_opportunities").Text, "Found 1 new opportunities")
+}
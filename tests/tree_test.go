@@ -0,0 +1,168 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pedy4000/noker/internal/api"
+	"github.com/pedy4000/noker/internal/models"
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSolutionTree(t *testing.T) {
+	env := newTestRouter(t)
+
+	_, err := env.db.Exec("TRUNCATE TABLE opportunity_evidence, meetings, opportunities, themes, outcomes CASCADE")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	meeting, err := env.queries.CreateMeeting(ctx, repository.CreateMeetingParams{Title: "Call", RawNotes: "notes", Source: "manual"})
+	require.NoError(t, err)
+	require.NoError(t, service.NewOpportunityService(env.queries).ProcessExtractedOpportunities(ctx, meeting.ID, []models.ExtractedOpportunity{
+		{Type: "new", UserSegment: "finance", Struggle: "Month-end exports break", EvidenceQuotes: []models.EvidenceQuote{{Quote: "exports break"}}},
+		{Type: "new", UserSegment: "finance", Struggle: "Export dates are wrong", EvidenceQuotes: []models.EvidenceQuote{{Quote: "dates are wrong"}}},
+		{Type: "new", UserSegment: "ops", Struggle: "No SSO", EvidenceQuotes: []models.EvidenceQuote{{Quote: "we need SSO"}}},
+	}))
+
+	do := func(method, path, body string, v any) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("X-API-Key", "noker-dev-key-2025")
+		w := httptest.NewRecorder()
+		env.router.ServeHTTP(w, req)
+		if v != nil && w.Code < 300 {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), v), w.Body.String())
+		}
+		return w.Code
+	}
+
+	var list api.OpportunityListResponse
+	require.Equal(t, http.StatusOK, do("GET", "/api/opportunities?sort=recent", "", &list))
+	ids := map[string]string{}
+	for _, op := range list.Opportunities {
+		ids[op.Struggle] = op.ID.String()
+	}
+
+	var outcome api.OutcomeResponse
+	require.Equal(t, http.StatusCreated, do("POST", "/api/outcomes", `{"title": "Faster month-end close", "metric": "days to close"}`, &outcome))
+	for _, struggle := range []string{"Month-end exports break", "Export dates are wrong"} {
+		require.Equal(t, http.StatusOK, do("PATCH", "/api/opportunities/"+ids[struggle], `{"outcome_id": "`+outcome.ID.String()+`"}`, nil))
+	}
+
+	var solution api.SolutionResponse
+	require.Equal(t, http.StatusCreated, do("POST", "/api/opportunities/"+ids["Export dates are wrong"]+"/solutions", `{"title": "Locale-aware date export"}`, &solution))
+	assert.Equal(t, "idea", solution.Status)
+	var experiment api.ExperimentResponse
+	require.Equal(t, http.StatusCreated, do("POST", "/api/solutions/"+solution.ID.String()+"/experiments",
+		`{"assumption": "Customers know their locale", "status": "running"}`, &experiment))
+	require.Equal(t, http.StatusOK, do("PATCH", "/api/experiments/"+experiment.ID.String(), `{"status": "validated", "result": "9 of 10 did"}`, nil))
+
+	// Merging keeps the solution on the surviving opportunity.
+	require.Equal(t, http.StatusOK, do("POST", "/api/opportunities/"+ids["Month-end exports break"]+"/merge",
+		`{"source_ids": ["`+ids["Export dates are wrong"]+`"]}`, nil))
+
+	var tree api.TreeResponse
+	require.Equal(t, http.StatusOK, do("GET", "/api/tree", "", &tree))
+	require.Len(t, tree.Outcomes, 1)
+	require.Len(t, tree.Outcomes[0].Opportunities, 1)
+	opp := tree.Outcomes[0].Opportunities[0]
+	assert.Equal(t, "Month-end exports break", opp.Struggle)
+	require.Len(t, opp.Solutions, 1)
+	require.Len(t, opp.Solutions[0].Experiments, 1)
+	assert.Equal(t, "validated", opp.Solutions[0].Experiments[0].Status)
+	assert.Equal(t, "9 of 10 did", opp.Solutions[0].Experiments[0].Result)
+	require.Len(t, tree.Unassigned, 1)
+	assert.Equal(t, "No SSO", tree.Unassigned[0].Struggle)
+
+	// Deleting the outcome leaves its opportunities unassigned.
+	require.Equal(t, http.StatusNoContent, do("DELETE", "/api/outcomes/"+outcome.ID.String(), "", nil))
+	require.Equal(t, http.StatusOK, do("GET", "/api/tree", "", &tree))
+	assert.Empty(t, tree.Outcomes)
+	assert.Len(t, tree.Unassigned, 2)
+
+	assert.Equal(t, http.StatusNotFound, do("PATCH", "/api/opportunities/"+ids["No SSO"], `{"outcome_id": "`+outcome.ID.String()+`"}`, nil))
+	assert.Equal(t, http.StatusBadRequest, do("POST", "/api/solutions/"+solution.ID.String()+"/experiments", `{"assumption": "x", "status": "maybe"}`, nil))
+}
+
+func TestSolutionTreeNestsChildren(t *testing.T) {
+	env := newTestRouter(t)
+
+	_, err := env.db.Exec("TRUNCATE TABLE opportunity_evidence, meetings, opportunities, themes, outcomes CASCADE")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	meeting, err := env.queries.CreateMeeting(ctx, repository.CreateMeetingParams{Title: "Call", RawNotes: "notes", Source: "manual"})
+	require.NoError(t, err)
+	require.NoError(t, service.NewOpportunityService(env.queries).ProcessExtractedOpportunities(ctx, meeting.ID, []models.ExtractedOpportunity{
+		{Type: "new", UserSegment: "finance", Struggle: "Reports are unreliable", EvidenceQuotes: []models.EvidenceQuote{{Quote: "reports lie"}}},
+		{Type: "new", UserSegment: "finance", Struggle: "Report totals are wrong", EvidenceQuotes: []models.EvidenceQuote{{Quote: "totals are off"}}},
+		{Type: "new", UserSegment: "finance", Struggle: "Rounding differs from Excel", EvidenceQuotes: []models.EvidenceQuote{{Quote: "rounding"}}},
+		{Type: "new", UserSegment: "ops", Struggle: "No SSO", EvidenceQuotes: []models.EvidenceQuote{{Quote: "we need SSO"}}},
+		{Type: "new", UserSegment: "ops", Struggle: "No SCIM", EvidenceQuotes: []models.EvidenceQuote{{Quote: "we need SCIM"}}},
+	}))
+
+	do := func(method, path, body string, v any) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("X-API-Key", "noker-dev-key-2025")
+		w := httptest.NewRecorder()
+		env.router.ServeHTTP(w, req)
+		if v != nil && w.Code < 300 {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), v), w.Body.String())
+		}
+		return w.Code
+	}
+
+	var list api.OpportunityListResponse
+	require.Equal(t, http.StatusOK, do("GET", "/api/opportunities?sort=recent", "", &list))
+	ids := map[string]string{}
+	for _, op := range list.Opportunities {
+		ids[op.Struggle] = op.ID.String()
+	}
+
+	var outcome api.OutcomeResponse
+	require.Equal(t, http.StatusCreated, do("POST", "/api/outcomes", `{"title": "Trustworthy reporting"}`, &outcome))
+	require.Equal(t, http.StatusOK, do("PATCH", "/api/opportunities/"+ids["Reports are unreliable"], `{"outcome_id": "`+outcome.ID.String()+`"}`, nil))
+	require.Equal(t, http.StatusOK, do("PATCH", "/api/opportunities/"+ids["Report totals are wrong"], `{"parent_id": "`+ids["Reports are unreliable"]+`"}`, nil))
+	require.Equal(t, http.StatusOK, do("PATCH", "/api/opportunities/"+ids["Rounding differs from Excel"], `{"parent_id": "`+ids["Report totals are wrong"]+`"}`, nil))
+	require.Equal(t, http.StatusOK, do("PATCH", "/api/opportunities/"+ids["No SCIM"], `{"parent_id": "`+ids["No SSO"]+`"}`, nil))
+
+	// Children without an outcome of their own sit under their parent, not under unassigned.
+	for _, path := range []string{"/api/tree", "/api/tree?outcome=" + outcome.ID.String()} {
+		var tree api.TreeResponse
+		require.Equal(t, http.StatusOK, do("GET", path, "", &tree))
+		require.Len(t, tree.Outcomes, 1, path)
+		require.Len(t, tree.Outcomes[0].Opportunities, 1, path)
+		root := tree.Outcomes[0].Opportunities[0]
+		assert.Equal(t, "Reports are unreliable", root.Struggle)
+		require.Len(t, root.Children, 1, path)
+		assert.Equal(t, "Report totals are wrong", root.Children[0].Struggle)
+		require.Len(t, root.Children[0].Children, 1, path)
+		assert.Equal(t, "Rounding differs from Excel", root.Children[0].Children[0].Struggle)
+	}
+
+	var tree api.TreeResponse
+	require.Equal(t, http.StatusOK, do("GET", "/api/tree", "", &tree))
+	require.Len(t, tree.Unassigned, 1)
+	assert.Equal(t, "No SSO", tree.Unassigned[0].Struggle)
+	require.Len(t, tree.Unassigned[0].Children, 1)
+	assert.Equal(t, "No SCIM", tree.Unassigned[0].Children[0].Struggle)
+
+	// A child linked to another outcome starts a branch there.
+	var other api.OutcomeResponse
+	require.Equal(t, http.StatusCreated, do("POST", "/api/outcomes", `{"title": "Faster month-end close"}`, &other))
+	require.Equal(t, http.StatusOK, do("PATCH", "/api/opportunities/"+ids["Report totals are wrong"], `{"outcome_id": "`+other.ID.String()+`"}`, nil))
+	require.Equal(t, http.StatusOK, do("GET", "/api/tree?outcome="+other.ID.String(), "", &tree))
+	require.Len(t, tree.Outcomes, 1)
+	require.Len(t, tree.Outcomes[0].Opportunities, 1)
+	branch := tree.Outcomes[0].Opportunities[0]
+	assert.Equal(t, "Report totals are wrong", branch.Struggle)
+	require.Len(t, branch.Children, 1)
+	assert.Equal(t, "Rounding differs from Excel", branch.Children[0].Struggle)
+}
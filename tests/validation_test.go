@@ -17,9 +17,9 @@ func TestCreateMeetingValidationWithoutTitle(t *testing.T) {
 	cfg, _ := config.Load("../config.yaml")
 	cfg.Database.URL = testDB
 	dbConn, _ := db.Connect(testDB, cfg)
-	queries := repository.New(dbConn)
+	queries := repository.NewStore(dbConn)
 	processor := queue.NewProcessor(queries, cfg)
-	handler := api.NewHandler(queries, processor)
+	handler := api.NewHandler(queries, processor, cfg)
 	router := api.NewRouter(handler, cfg)
 
 	payload := map[string]any{
@@ -36,9 +36,9 @@ func TestCreateMeetingValidationShortTitle(t *testing.T) {
 	cfg, _ := config.Load("../config.yaml")
 	cfg.Database.URL = testDB
 	dbConn, _ := db.Connect(testDB, cfg)
-	queries := repository.New(dbConn)
+	queries := repository.NewStore(dbConn)
 	processor := queue.NewProcessor(queries, cfg)
-	handler := api.NewHandler(queries, processor)
+	handler := api.NewHandler(queries, processor, cfg)
 	router := api.NewRouter(handler, cfg)
 
 	payload := map[string]any{
@@ -55,9 +55,9 @@ func TestCreateMeetingValidationInValidSource(t *testing.T) {
 	cfg, _ := config.Load("../config.yaml")
 	cfg.Database.URL = testDB
 	dbConn, _ := db.Connect(testDB, cfg)
-	queries := repository.New(dbConn)
+	queries := repository.NewStore(dbConn)
 	processor := queue.NewProcessor(queries, cfg)
-	handler := api.NewHandler(queries, processor)
+	handler := api.NewHandler(queries, processor, cfg)
 	router := api.NewRouter(handler, cfg)
 
 	payload := map[string]any{
@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pedy4000/noker/internal/api"
+	"github.com/pedy4000/noker/internal/models"
+	"github.com/pedy4000/noker/internal/queue"
+	"github.com/pedy4000/noker/internal/repository"
+	"github.com/pedy4000/noker/internal/service"
+	"github.com/pedy4000/noker/internal/webhook"
+	"github.com/pedy4000/noker/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSignature(t *testing.T) {
+	body := []byte(`{"event":"theme.created"}`)
+
+	sig := webhook.Sign("s3cret-s3cret-s3cret", 1700000000, body)
+	assert.Equal(t, sig, webhook.Sign("s3cret-s3cret-s3cret", 1700000000, body))
+	assert.Len(t, sig, len("sha256=")+64)
+	assert.NotEqual(t, sig, webhook.Sign("another-secret-value", 1700000000, body))
+	assert.NotEqual(t, sig, webhook.Sign("s3cret-s3cret-s3cret", 1700000001, body))
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	cfg, err := config.Load("../config.yaml")
+	require.NoError(t, err)
+	cfg.Database.URL = testDB
+	cfg.Webhooks.PollIntervalMs = 50
+	cfg.Webhooks.RetryBaseMs = 100
+	cfg.Webhooks.RetryMaxMs = 200
+	env := newTestRouterWith(t, cfg)
+
+	_, err = env.db.Exec("TRUNCATE TABLE opportunity_evidence, meetings, opportunities, themes, webhooks CASCADE")
+	require.NoError(t, err)
+
+	// The receiver fails its first request, so one delivery needs a retry.
+	var mu sync.Mutex
+	var received []*http.Request
+	var bodies [][]byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, r)
+		bodies = append(bodies, body)
+		if len(received) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer receiver.Close()
+
+	do := func(method, path, body string, v any) int {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		req.Header.Set("X-API-Key", "noker-dev-key-2025")
+		w := httptest.NewRecorder()
+		env.router.ServeHTTP(w, req)
+		if v != nil && w.Code < 300 {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), v), w.Body.String())
+		}
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusBadRequest, do("POST", "/api/webhooks", `{"url": "`+receiver.URL+`", "events": ["meeting.exploded"]}`, nil))
+
+	var hook api.WebhookResponse
+	require.Equal(t, http.StatusCreated, do("POST", "/api/webhooks",
+		`{"url": "`+receiver.URL+`", "events": ["opportunity.created", "theme.created"]}`, &hook))
+	require.NotEmpty(t, hook.Secret)
+
+	ctx := context.Background()
+	meeting, err := env.queries.CreateMeeting(ctx, repository.CreateMeetingParams{Title: "Call", RawNotes: "notes", Source: "manual"})
+	require.NoError(t, err)
+	extracted := []models.ExtractedOpportunity{
+		{Type: "new", UserSegment: "finance", Struggle: "Exports break", Theme: "Reporting", EvidenceQuotes: []models.EvidenceQuote{{Quote: "exports break"}}},
+	}
+	opps := service.NewOpportunityService(env.queries)
+	require.NoError(t, opps.ProcessExtractedOpportunities(ctx, meeting.ID, extracted))
+	// Replaying the same result queues nothing new.
+	require.NoError(t, opps.ProcessExtractedOpportunities(ctx, meeting.ID, extracted))
+
+	dispatcher := queue.NewWebhookDispatcher(env.queries, cfg)
+	dispatcher.Start()
+	defer dispatcher.Stop()
+
+	var log api.WebhookDeliveryListResponse
+	require.Eventually(t, func() bool {
+		log = api.WebhookDeliveryListResponse{}
+		do("GET", "/api/webhooks/"+hook.ID.String()+"/deliveries?status=delivered", "", &log)
+		return len(log.Deliveries) == 2
+	}, 5*time.Second, 50*time.Millisecond)
+
+	events := map[string]api.WebhookDeliveryResponse{}
+	for _, d := range log.Deliveries {
+		events[d.Event] = d
+	}
+	require.Contains(t, events, webhook.EventThemeCreated)
+	require.Contains(t, events, webhook.EventOpportunityCreated)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, received, 3) // two deliveries, one of them twice
+	for i, r := range received {
+		ts, err := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+		require.NoError(t, err)
+		assert.Equal(t, webhook.Sign(hook.Secret, ts, bodies[i]), r.Header.Get(webhook.HeaderSignature))
+	}
+	retried := 0
+	for _, d := range events {
+		if d.Attempts == 2 {
+			retried++
+		}
+	}
+	assert.Equal(t, 1, retried)
+
+	var payload struct {
+		Event string                  `json:"event"`
+		Data  webhook.OpportunityData `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(events[webhook.EventOpportunityCreated].Payload, &payload))
+	assert.Equal(t, "Exports break", payload.Data.Struggle)
+	assert.Equal(t, []string{"exports break"}, payload.Data.Quotes)
+}